@@ -68,6 +68,31 @@ func TestToolQueue(t *testing.T) {
 	}
 }
 
+func TestToolQueueAdd(t *testing.T) {
+	q := NewQueue(nil, RiskBasedClassifier)
+	if !q.IsComplete() {
+		t.Error("empty queue should be complete")
+	}
+
+	q.Add(ToolInfo{ID: "1", Name: "Read", Risk: RiskLow})
+	q.Add(ToolInfo{ID: "2", Name: "Write", Risk: RiskHigh})
+	if q.Count() != 2 || q.IsComplete() {
+		t.Fatalf("expected 2 pending tools, got %d", q.Count())
+	}
+	if q.Items()[0].Action != ActionAutoApprove || q.Items()[1].Action != ActionNeedsApproval {
+		t.Errorf("expected added tools classified, got %+v", q.Items())
+	}
+
+	// Tools added after the queue drains are next
+	q.SetOutcome(OutcomeApproved, nil)
+	q.Advance()
+	q.Advance()
+	q.Add(ToolInfo{ID: "3", Name: "Delete"})
+	if item := q.Next(); item == nil || item.Tool.ID != "3" {
+		t.Errorf("expected added tool next, got %+v", item)
+	}
+}
+
 func TestToolQueueProgressHint(t *testing.T) {
 	tools := []ToolInfo{
 		{ID: "1", Name: "A"},
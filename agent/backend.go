@@ -33,6 +33,16 @@ type ApprovalRequest struct {
 	ID       string
 	Tool     ToolInfo
 	Response chan<- ApprovalDecision
+	// EditResponse, if set, lets the user edit the tool's parameters before
+	// approving. The answer is sent here instead of Response.
+	EditResponse chan<- ApprovalResponse
+}
+
+// ApprovalResponse is a decision on an approval request, with the
+// parameters the user edited before approving.
+type ApprovalResponse struct {
+	Decision ApprovalDecision
+	Params   map[string]any // Parameters to run the tool with; nil if unchanged
 }
 
 // ApprovalDecision represents the user's decision on a tool approval.
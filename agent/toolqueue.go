@@ -74,6 +74,21 @@ func NewQueue(tools []ToolInfo, classifier Classifier) *Queue {
 	return q
 }
 
+// Add appends a tool to the end of the queue, classifying it like the
+// tools given to NewQueue.
+func (q *Queue) Add(tool ToolInfo) {
+	action, reason := ActionNeedsApproval, ""
+	if q.classifier != nil {
+		action, reason = q.classifier(tool)
+	}
+	q.items = append(q.items, ToolDisposition{
+		Tool:    tool,
+		Action:  action,
+		Outcome: OutcomePending,
+		Reason:  reason,
+	})
+}
+
 // Next returns the next tool disposition to process.
 // Returns nil if all tools have been processed.
 func (q *Queue) Next() *ToolDisposition {
@@ -27,10 +27,18 @@ const (
 
 // ContentBlock represents a block of content within a message.
 type ContentBlock struct {
-	Type       string // "text", "tool_use", "tool_result"
+	Type       string // "text", "tool_use", "tool_result", "attachment"
 	Text       string
 	ToolUse    *ToolUse
 	ToolResult *ToolResult
+	Attachment *Attachment
+}
+
+// Attachment is a file attached to a user message.
+type Attachment struct {
+	Path     string
+	MIMEType string
+	Content  []byte
 }
 
 // ToolUse represents a tool invocation.
@@ -71,6 +79,9 @@ const (
 	EventComplete
 	// EventError indicates an error occurred.
 	EventError
+	// EventThinking indicates streaming reasoning, shown apart from the
+	// answer.
+	EventThinking
 )
 
 // TokenUsage contains token usage statistics.
@@ -86,6 +97,11 @@ func NewTextEvent(text string) Event {
 	return Event{Type: EventText, Text: text}
 }
 
+// NewThinkingEvent creates a reasoning event.
+func NewThinkingEvent(text string) Event {
+	return Event{Type: EventThinking, Text: text}
+}
+
 // NewToolCallEvent creates a tool call event.
 func NewToolCallEvent(tool ToolUse) Event {
 	return Event{Type: EventToolCall, Tool: &tool}
@@ -0,0 +1,528 @@
+// approvals.go
+package tux
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/shell"
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// approvalKeys are the queue-wide keys, shown when more than one approval is
+// waiting.
+const approvalKeys = "A approve all · D deny all · b review all"
+
+// approvalsDoneMsg closes an approval queue once every request in it has
+// been answered.
+type approvalsDoneMsg struct{ queue *approvalQueue }
+
+// askApproval adds an approval request to the queue shown to the user,
+// opening the queue if none is showing. Requests are answered one at a time
+// in arrival order.
+func (a *App) askApproval(event Event) {
+	if a.approvals == nil {
+		a.approvals = &approvalQueue{
+			app:   a,
+			queue: agent.NewQueue(nil, nil),
+			theme: a.shell.Theme(),
+		}
+		a.approvals.add(event)
+		a.shell.PushModal(a.approvals)
+		return
+	}
+	a.approvals.add(event)
+}
+
+// dropExpiredApprovals denies the waiting approval requests of runs that
+// have ended, been cancelled or been superseded; nothing will act on their
+// answers.
+func (a *App) dropExpiredApprovals() {
+	if a.approvals == nil {
+		return
+	}
+	q := a.approvals
+	if q.dropExpired() {
+		a.closeApprovals(q)
+	}
+}
+
+// approvalExpired reports whether an approval request belongs to a run that
+// is no longer current. Requests without a run ID never expire.
+func (a *App) approvalExpired(event Event) bool {
+	if event.RunID == 0 {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return event.RunID != a.runID || !a.running || (a.ctx != nil && a.ctx.Err() != nil)
+}
+
+// closeApprovals pops an answered approval queue, unless a request arrived
+// after its last answer; then the queue stays open to show it.
+func (a *App) closeApprovals(q *approvalQueue) {
+	if q != a.approvals || !q.queue.IsComplete() {
+		return
+	}
+	a.shell.PopModal()
+}
+
+// approvalQueue is the modal for the approval requests waiting on the user.
+// It shows one ApprovalModal at a time, with the queue's progress in its
+// title, and a review screen for answering the rest in one go. Only touched
+// inside the update loop.
+type approvalQueue struct {
+	app    *App
+	queue  *agent.Queue
+	events []Event // Requests by queue position
+	theme  theme.Theme
+
+	// Requests answered ahead of their turn by a rule remembered while
+	// they waited, by queue position
+	resolved map[int]ApprovalDecision
+
+	modal   *shell.ApprovalModal // Current request
+	decided bool                 // Whether modal reported a decision
+	edit    *shell.FormModal     // Parameter editor; nil when not editing
+	edited  bool                 // Whether edit was submitted or cancelled
+	review  *content.MultiSelect // Review screen; nil when not reviewing
+	width   int
+	height  int
+}
+
+// add appends a request to the queue.
+func (q *approvalQueue) add(event Event) {
+	q.queue.Add(agent.ToolInfo{ID: event.ToolID, Name: event.ToolName, Params: event.ToolParams})
+	q.events = append(q.events, event)
+
+	switch {
+	case q.review != nil:
+		items := append(q.review.Items(), q.reviewItem(len(q.events)-1))
+		q.review.SetItems(items)
+	case q.modal == nil:
+		q.show()
+	default:
+		q.refreshHints()
+	}
+}
+
+// show opens the next unanswered request.
+func (q *approvalQueue) show() {
+	d := q.queue.Next()
+	if d == nil {
+		q.modal = nil
+		return
+	}
+	// Parameters shown in the diff are left out of the list
+	event := q.events[q.queue.Current()]
+	diff, shown := diffPreview(event, q.theme)
+	params := d.Tool.Params
+	if len(shown) > 0 {
+		params = make(map[string]any, len(d.Tool.Params))
+		for k, v := range d.Tool.Params {
+			if !slices.Contains(shown, k) {
+				params[k] = v
+			}
+		}
+	}
+	q.modal = shell.NewApprovalModal(shell.ApprovalModalConfig{
+		Tool: shell.ToolInfo{
+			ID:      d.Tool.ID,
+			Name:    d.Tool.Name,
+			Params:  params,
+			Preview: d.Tool.Preview,
+			Diff:    diff,
+		},
+		Editable: event.EditResponse != nil && shell.CanEditParams(d.Tool.Params),
+		OnDecision: func(decision shell.ApprovalDecision) {
+			if decision == shell.DecisionEdit {
+				q.openEdit()
+				return
+			}
+			q.decided = true
+			q.answer(decision)
+		},
+	})
+	q.modal.OnPush(q.width, q.height)
+	q.refreshHints()
+}
+
+// refreshHints shows the queue's progress and keys on the current request
+// once more than one request has arrived.
+func (q *approvalQueue) refreshHints() {
+	if q.modal == nil || q.queue.Count() < 2 {
+		return
+	}
+	q.modal.SetQueueHint(q.queue.ProgressHint())
+	if q.queue.Count()-q.queue.Current() > 1 {
+		q.modal.SetFooter(approvalKeys)
+	} else {
+		q.modal.SetFooter("")
+	}
+}
+
+// answer sends the decision for the current request and moves past it.
+func (q *approvalQueue) answer(decision ApprovalDecision) {
+	q.respond(ApprovalResponse{Decision: decision})
+}
+
+// respond sends the response for the current request and moves past it.
+func (q *approvalQueue) respond(resp ApprovalResponse) {
+	event := q.events[q.queue.Current()]
+	remembered := q.app.rememberDecision(event, resp.Decision)
+	respondApproval(event, resp)
+	q.queue.SetOutcome(approvalOutcome(resp.Decision), nil)
+	q.queue.Advance()
+
+	if remembered {
+		q.resolveRemembered()
+	}
+	q.skipResolved()
+}
+
+// resolveRemembered answers the waiting requests that the policy now
+// decides, as it would have had they arrived after the rule.
+func (q *approvalQueue) resolveRemembered() {
+	for i := q.queue.Current(); i < len(q.events); i++ {
+		if _, ok := q.resolved[i]; ok {
+			continue
+		}
+		d, ok := q.app.policyDecision(q.events[i])
+		if !ok {
+			continue
+		}
+		respondApproval(q.events[i], ApprovalResponse{Decision: d})
+		if q.resolved == nil {
+			q.resolved = make(map[int]ApprovalDecision)
+		}
+		q.resolved[i] = d
+	}
+}
+
+// dropExpired denies the waiting requests whose run is over and moves past
+// them, reporting whether any were dropped.
+func (q *approvalQueue) dropExpired() bool {
+	dropped := false
+	for i := q.queue.Current(); i < len(q.events); i++ {
+		if _, ok := q.resolved[i]; ok || !q.app.approvalExpired(q.events[i]) {
+			continue
+		}
+		respondApproval(q.events[i], ApprovalResponse{Decision: DecisionDeny})
+		if q.resolved == nil {
+			q.resolved = make(map[int]ApprovalDecision)
+		}
+		q.resolved[i] = DecisionDeny
+		dropped = true
+	}
+	if !dropped {
+		return false
+	}
+
+	current := q.queue.Current()
+	q.skipResolved()
+	switch {
+	case q.queue.IsComplete():
+		q.modal, q.edit, q.review = nil, nil, nil
+	case q.review != nil:
+		q.openReview()
+	case q.queue.Current() != current:
+		q.edit = nil
+		q.show()
+	default:
+		q.refreshHints()
+	}
+	return true
+}
+
+// skipResolved moves past requests that are already answered.
+func (q *approvalQueue) skipResolved() {
+	for !q.queue.IsComplete() {
+		d, ok := q.resolved[q.queue.Current()]
+		if !ok {
+			return
+		}
+		q.queue.SetOutcome(approvalOutcome(d), nil)
+		q.queue.Advance()
+	}
+}
+
+// approvalOutcome is the queue outcome of a decision.
+func approvalOutcome(decision ApprovalDecision) agent.ToolOutcome {
+	if decision == DecisionApprove || decision == DecisionAlwaysAllow {
+		return agent.OutcomeApproved
+	}
+	return agent.OutcomeDenied
+}
+
+// respondApproval answers an approval request on its EditResponse channel
+// if it has one, or its Response channel. The answer is dropped if the
+// request's run ends before the agent takes it.
+func respondApproval(event Event, resp ApprovalResponse) {
+	var done <-chan struct{}
+	if event.ctx != nil {
+		done = event.ctx.Done()
+	}
+	// Send asynchronously to avoid blocking UI thread
+	switch {
+	case event.EditResponse != nil:
+		go func() {
+			select {
+			case event.EditResponse <- resp:
+			case <-done:
+			}
+		}()
+	case event.Response != nil:
+		go func() {
+			select {
+			case event.Response <- resp.Decision:
+			case <-done:
+			}
+		}()
+	}
+}
+
+// answerRest gives every unanswered request the same decision.
+func (q *approvalQueue) answerRest(decision ApprovalDecision) {
+	for !q.queue.IsComplete() {
+		q.answer(decision)
+	}
+}
+
+// next shows the next request, or closes the queue when all are answered.
+func (q *approvalQueue) next() tea.Cmd {
+	q.review = nil
+	q.edit = nil
+	if q.queue.IsComplete() {
+		q.modal = nil
+		return func() tea.Msg { return approvalsDoneMsg{queue: q} }
+	}
+	q.show()
+	return nil
+}
+
+// openEdit opens the parameter editor for the current request. Submitting
+// it approves the request with the edited parameters; cancelling it returns
+// to the request.
+func (q *approvalQueue) openEdit() {
+	params := q.events[q.queue.Current()].ToolParams
+	form := shell.NewFormModal(shell.FormModalConfig{
+		Title: "Edit " + q.events[q.queue.Current()].ToolName,
+		Form:  shell.NewParamsForm(params),
+		Theme: q.theme,
+		OnSubmit: func(values shell.Values) {
+			q.edited = true
+			edited, err := shell.ParamsFromValues(params, values)
+			if err != nil {
+				// The form's validators make this unreachable; deny rather
+				// than run the tool with parameters nobody chose
+				q.answer(DecisionDeny)
+				return
+			}
+			q.respond(ApprovalResponse{Decision: DecisionApprove, Params: edited})
+		},
+		OnCancel: func() {
+			q.edited = true
+			q.edit = nil
+		},
+	})
+	form.OnPush(q.width, q.height)
+	q.edit = form
+}
+
+// handleEditKey handles keys in the parameter editor. The form's own pop is
+// replaced by moving to the next request, or back to the current one.
+func (q *approvalQueue) handleEditKey(key tea.KeyMsg) (bool, tea.Cmd) {
+	q.edited = false
+	handled, cmd := q.edit.HandleKey(key)
+	if !q.edited {
+		return handled, cmd
+	}
+	if q.edit == nil {
+		return true, nil
+	}
+	return true, q.next()
+}
+
+// ID implements shell.Modal.
+func (q *approvalQueue) ID() string { return "approvals" }
+
+// Title implements shell.Modal.
+func (q *approvalQueue) Title() string { return "Tool Approval" }
+
+// Size implements shell.Modal.
+func (q *approvalQueue) Size() shell.Size {
+	if q.edit != nil {
+		return q.edit.Size()
+	}
+	if q.review == nil && q.modal != nil {
+		return q.modal.Size()
+	}
+	return shell.SizeMedium
+}
+
+// OnPush implements shell.Modal.
+func (q *approvalQueue) OnPush(width, height int) {
+	q.width, q.height = width, height
+	if q.modal != nil {
+		q.modal.OnPush(width, height)
+	}
+	if q.edit != nil {
+		q.edit.OnPush(width, height)
+	}
+}
+
+// OnPop implements shell.Modal. Requests still unanswered when the queue is
+// dismissed are denied, so the agent is not left waiting.
+func (q *approvalQueue) OnPop() {
+	q.answerRest(DecisionDeny)
+	if q.app.approvals == q {
+		q.app.approvals = nil
+	}
+}
+
+// HandleKey implements shell.Modal.
+func (q *approvalQueue) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
+	if q.review != nil {
+		return q.handleReviewKey(key)
+	}
+	if q.edit != nil {
+		return q.handleEditKey(key)
+	}
+	if q.modal == nil {
+		return true, nil
+	}
+
+	if q.queue.Count()-q.queue.Current() > 1 {
+		switch key.String() {
+		case "A":
+			q.answerRest(DecisionApprove)
+			return true, q.next()
+		case "D":
+			q.answerRest(DecisionDeny)
+			return true, q.next()
+		case "b":
+			q.openReview()
+			return true, nil
+		}
+	}
+
+	q.decided = false
+	handled, cmd := q.modal.HandleKey(key)
+	switch {
+	case q.decided:
+		// The modal's own pop is replaced by moving to the next request
+		return true, q.next()
+	case q.edit != nil:
+		// Or by the parameter editor
+		return true, nil
+	}
+	return handled, cmd
+}
+
+// HandleMouse implements shell.MouseHandler.
+func (q *approvalQueue) HandleMouse(msg tea.MouseMsg) (bool, tea.Cmd) {
+	if q.review != nil || q.edit != nil || q.modal == nil {
+		return false, nil
+	}
+	q.decided = false
+	handled, cmd := q.modal.HandleMouse(msg)
+	switch {
+	case q.decided:
+		return true, q.next()
+	case q.edit != nil:
+		return true, nil
+	}
+	return handled, cmd
+}
+
+// openReview lists the unanswered requests for answering together. All
+// start selected.
+func (q *approvalQueue) openReview() {
+	var items []content.MultiSelectItem
+	for i := q.queue.Current(); i < len(q.events); i++ {
+		if _, ok := q.resolved[i]; !ok {
+			items = append(items, q.reviewItem(i))
+		}
+	}
+	q.review = content.NewMultiSelect(items)
+}
+
+// reviewItem is the review screen's entry for the request at queue
+// position i.
+func (q *approvalQueue) reviewItem(i int) content.MultiSelectItem {
+	event := q.events[i]
+	label := event.ToolName
+	if params := firstLine(formatParams(event.ToolParams)); params != "" {
+		label += "  " + params
+	}
+	return content.MultiSelectItem{Label: label, Key: strconv.Itoa(i), Selected: true}
+}
+
+// handleReviewKey handles keys on the review screen: enter approves the
+// selected requests and denies the rest, esc goes back.
+func (q *approvalQueue) handleReviewKey(key tea.KeyMsg) (bool, tea.Cmd) {
+	// Enter and Esc finish or clear the list's filter first
+	if q.review.Filtering() || (key.Type == tea.KeyEsc && q.review.Filter() != "") {
+		q.review.Update(key)
+		return true, nil
+	}
+	switch key.Type {
+	case tea.KeyEsc:
+		q.review = nil
+		return true, nil
+	case tea.KeyEnter:
+		approved := make(map[int]bool)
+		for _, key := range q.review.Value().([]string) {
+			i, _ := strconv.Atoi(key)
+			approved[i] = true
+		}
+		for !q.queue.IsComplete() {
+			decision := DecisionDeny
+			if approved[q.queue.Current()] {
+				decision = DecisionApprove
+			}
+			q.answer(decision)
+		}
+		return true, q.next()
+	}
+	q.review.Update(key)
+	return true, nil
+}
+
+// Render implements shell.Modal.
+func (q *approvalQueue) Render(width, height int) string {
+	if q.review != nil {
+		return q.renderReview(width)
+	}
+	if q.edit != nil {
+		return q.edit.Render(width, height)
+	}
+	if q.modal == nil {
+		return ""
+	}
+	return q.modal.Render(width, height)
+}
+
+// renderReview renders the review screen.
+func (q *approvalQueue) renderReview(width int) string {
+	styles := q.theme.Styles()
+	inner := max(width-4-styles.ModalBox.GetHorizontalFrameSize(), 10)
+
+	lines := []string{
+		styles.ModalTitle.Render(fmt.Sprintf("Review %d tool calls %s", len(q.review.Items()), q.queue.ProgressHint())),
+		"",
+	}
+	for _, line := range strings.Split(q.review.View(), "\n") {
+		lines = append(lines, ansi.Truncate(line, inner, "…"))
+	}
+	lines = append(lines, "",
+		styles.ModalFooter.Render("space toggle · a all · n none · / filter · enter approve selected, deny the rest · esc back"))
+
+	return styles.ModalBox.Width(width - 4).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,312 @@
+package tux
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// askApprovals sends approval requests for the named tools and returns
+// their response channels.
+func askApprovals(app *App, names ...string) []chan ApprovalDecision {
+	responses := make([]chan ApprovalDecision, len(names))
+	for i, name := range names {
+		responses[i] = make(chan ApprovalDecision, 1)
+		app.processEvent(Event{
+			Type:       EventApproval,
+			ToolID:     name + "-id",
+			ToolName:   name,
+			ToolParams: map[string]any{"path": name + ".txt"},
+			Response:   responses[i],
+		})
+	}
+	return responses
+}
+
+// decisionOf waits for the decision sent on response.
+func decisionOf(t *testing.T, response chan ApprovalDecision) ApprovalDecision {
+	t.Helper()
+	select {
+	case d := <-response:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("expected a decision")
+		return DecisionDeny
+	}
+}
+
+// press sends a key to the app's shell and runs the command it returns.
+func press(app *App, key tea.KeyMsg) {
+	_, cmd := app.shell.Update(key)
+	if cmd == nil {
+		return
+	}
+	if msg := cmd(); msg != nil {
+		app.shell.Update(msg)
+	}
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestApprovalsShownInArrivalOrder(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	responses := askApprovals(app, "read", "write", "delete")
+
+	view := ansi.Strip(app.approvals.Render(80, 30))
+	if !strings.Contains(view, "[●??]") || !strings.Contains(view, "read") {
+		t.Errorf("expected first request with queue hint:\n%s", view)
+	}
+	if !strings.Contains(view, "A approve all") {
+		t.Errorf("expected queue keys:\n%s", view)
+	}
+
+	press(app, runeKey('y'))
+	view = ansi.Strip(app.approvals.Render(80, 30))
+	if !strings.Contains(view, "[✓●?]") || !strings.Contains(view, "write") {
+		t.Errorf("expected second request next:\n%s", view)
+	}
+
+	press(app, runeKey('n'))
+	view = ansi.Strip(app.approvals.Render(80, 30))
+	if !strings.Contains(view, "[✓✗●]") || strings.Contains(view, "A approve all") {
+		t.Errorf("expected last request without queue keys:\n%s", view)
+	}
+
+	press(app, runeKey('y'))
+	if app.shell.HasModal() || app.approvals != nil {
+		t.Error("expected queue closed once all are answered")
+	}
+
+	want := []ApprovalDecision{DecisionApprove, DecisionDeny, DecisionApprove}
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != want[i] {
+			t.Errorf("request %d: expected %v, got %v", i, want[i], d)
+		}
+	}
+}
+
+func TestApprovalsAnswerAllRemaining(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	responses := askApprovals(app, "read", "write", "delete")
+
+	press(app, runeKey('n'))
+	press(app, runeKey('A'))
+	if app.shell.HasModal() {
+		t.Error("expected queue closed after approving the rest")
+	}
+	want := []ApprovalDecision{DecisionDeny, DecisionApprove, DecisionApprove}
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != want[i] {
+			t.Errorf("request %d: expected %v, got %v", i, want[i], d)
+		}
+	}
+
+	responses = askApprovals(app, "a", "b")
+	press(app, runeKey('D'))
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != DecisionDeny {
+			t.Errorf("request %d: expected deny, got %v", i, d)
+		}
+	}
+}
+
+func TestApprovalsBatchReview(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	responses := askApprovals(app, "read", "write")
+
+	press(app, runeKey('b'))
+	// Requests arriving during review join it
+	responses = append(responses, askApprovals(app, "delete")...)
+
+	view := ansi.Strip(app.approvals.Render(80, 30))
+	for _, want := range []string{"Review 3 tool calls", "[✓] read  path=read.txt", "[✓] delete"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in review:\n%s", want, view)
+		}
+	}
+
+	// Deselect write, then approve the rest
+	press(app, tea.KeyMsg{Type: tea.KeyDown})
+	press(app, tea.KeyMsg{Type: tea.KeySpace})
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if app.shell.HasModal() {
+		t.Error("expected queue closed after review")
+	}
+	want := []ApprovalDecision{DecisionApprove, DecisionDeny, DecisionApprove}
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != want[i] {
+			t.Errorf("request %d: expected %v, got %v", i, want[i], d)
+		}
+	}
+}
+
+func TestApprovalsBatchReviewFilter(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	responses := askApprovals(app, "read", "write", "delete")
+	press(app, runeKey('b'))
+
+	// Enter keeps the filter rather than submitting the batch
+	for _, r := range "/del" {
+		press(app, runeKey(r))
+	}
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+	if app.approvals == nil || app.approvals.review == nil {
+		t.Fatal("expected Enter to keep the filter, not submit")
+	}
+
+	// Deselecting all only touches the matches; Esc clears the filter
+	press(app, runeKey('n'))
+	press(app, tea.KeyMsg{Type: tea.KeyEsc})
+	if app.approvals == nil || app.approvals.review == nil || app.approvals.review.Filter() != "" {
+		t.Fatal("expected Esc to clear the filter, not leave the review")
+	}
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+
+	want := []ApprovalDecision{DecisionApprove, DecisionApprove, DecisionDeny}
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != want[i] {
+			t.Errorf("request %d: expected %v, got %v", i, want[i], d)
+		}
+	}
+}
+
+func TestApprovalsDismissDeniesRemaining(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	responses := askApprovals(app, "read", "write")
+
+	press(app, tea.KeyMsg{Type: tea.KeyEsc})
+	if app.shell.HasModal() || app.approvals != nil {
+		t.Fatal("expected esc to dismiss the queue")
+	}
+	for i, response := range responses {
+		if d := decisionOf(t, response); d != DecisionDeny {
+			t.Errorf("request %d: expected deny, got %v", i, d)
+		}
+	}
+
+	// A later request opens a new queue
+	askApprovals(app, "delete")
+	if !app.shell.HasModal() || app.approvals.queue.Count() != 1 {
+		t.Error("expected a new queue for a later request")
+	}
+}
+
+func TestApprovalsEditParams(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	edits := make(chan ApprovalResponse, 1)
+	app.processEvent(Event{
+		Type:         EventApproval,
+		ToolID:       "w1",
+		ToolName:     "write",
+		ToolParams:   map[string]any{"path": "a.txt", "force": false},
+		EditResponse: edits,
+	})
+
+	view := ansi.Strip(app.approvals.Render(80, 30))
+	if !strings.Contains(view, "Edit") {
+		t.Fatalf("expected an Edit option:\n%s", view)
+	}
+
+	// Cancelling the editor returns to the request
+	press(app, runeKey('e'))
+	if view := ansi.Strip(app.approvals.Render(80, 30)); !strings.Contains(view, "Edit write") {
+		t.Fatalf("expected the parameter editor:\n%s", view)
+	}
+	press(app, tea.KeyMsg{Type: tea.KeyEsc})
+	if !app.shell.HasModal() || app.approvals == nil || app.approvals.edit != nil {
+		t.Fatal("expected cancelling the editor to return to the request")
+	}
+
+	// Fields are in name order: force, then path
+	press(app, runeKey('e'))
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+	press(app, tea.KeyMsg{Type: tea.KeyCtrlU})
+	for _, r := range "b.txt" {
+		press(app, runeKey(r))
+	}
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+
+	select {
+	case resp := <-edits:
+		if resp.Decision != DecisionApprove {
+			t.Errorf("expected approval, got %v", resp.Decision)
+		}
+		if resp.Params["path"] != "b.txt" || resp.Params["force"] != false {
+			t.Errorf("expected edited params, got %v", resp.Params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an edited response")
+	}
+	if app.shell.HasModal() {
+		t.Error("expected the queue closed after the edited approval")
+	}
+}
+
+func TestApprovalsNotEditableWithoutEditResponse(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	askApprovals(app, "write")
+
+	press(app, runeKey('e'))
+	if app.approvals == nil || app.approvals.edit != nil {
+		t.Error("expected no parameter editor without EditResponse")
+	}
+	if view := ansi.Strip(app.approvals.Render(80, 30)); strings.Contains(view, "Edit") {
+		t.Errorf("expected no Edit option:\n%s", view)
+	}
+}
+
+func TestApprovalsOfEndedRunDenied(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	startRun(app, 1)
+	app.running = true
+
+	response := make(chan ApprovalDecision, 1)
+	app.processEvent(Event{Type: EventApproval, RunID: 1, ToolID: "w1", ToolName: "write", Response: response, ctx: app.ctx})
+	app.runDone(1, nil)
+
+	if app.shell.HasModal() || app.approvals != nil {
+		t.Fatal("expected the queue closed once its run ended")
+	}
+	if d := decisionOf(t, response); d != DecisionDeny {
+		t.Errorf("expected deny, got %v", d)
+	}
+}
+
+func TestApprovalsOfInterruptedRunDropped(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	startRun(app, 1)
+	app.running = true
+
+	// Nothing reads the run's answers once it is cancelled
+	app.processEvent(Event{Type: EventApproval, RunID: 1, ToolID: "w1", ToolName: "write",
+		Response: make(chan ApprovalDecision), ctx: app.ctx})
+	responses := askApprovals(app, "read")
+	app.interrupt()
+
+	q := app.approvals
+	if q == nil || q.queue.Current() != 1 || q.events[1].ToolName != "read" {
+		t.Fatal("expected the queue to move on to the request not tied to the run")
+	}
+	if view := ansi.Strip(q.Render(80, 30)); !strings.Contains(view, "read") {
+		t.Errorf("expected the remaining request shown:\n%s", view)
+	}
+	press(app, runeKey('y'))
+	if d := decisionOf(t, responses[0]); d != DecisionApprove {
+		t.Errorf("expected approve, got %v", d)
+	}
+}
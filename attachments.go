@@ -0,0 +1,150 @@
+package tux
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/2389-research/tux/session"
+	"github.com/2389-research/tux/shell"
+)
+
+// DefaultAttachmentLimit is the largest file, in bytes, attached to a
+// prompt unless WithAttachmentLimit says otherwise.
+const DefaultAttachmentLimit = 1 << 20
+
+// Attachment is a file mentioned in a prompt as @path.
+type Attachment struct {
+	Path     string // As mentioned, without the @
+	Content  []byte
+	Size     int64
+	MIMEType string
+}
+
+// RunRequest is a prompt with the files it mentions.
+type RunRequest struct {
+	Prompt      string // As typed, mentions included
+	Attachments []Attachment
+}
+
+// RequestRunner is implemented by agents that take attachments. The App
+// calls RunRequest instead of Run on agents that implement it; other agents
+// get the prompt alone, its @path mentions left as plain text.
+type RequestRunner interface {
+	RunRequest(ctx context.Context, req RunRequest) error
+}
+
+// runAgent runs the agent on a prompt, with its attachments if the agent
+// takes them.
+func (a *App) runAgent(ctx context.Context, prompt string, attachments []Attachment) error {
+	if r, ok := a.agent.(RequestRunner); ok {
+		return r.RunRequest(ctx, RunRequest{Prompt: prompt, Attachments: attachments})
+	}
+	return a.agent.Run(ctx, prompt)
+}
+
+// resolveAttachments reads the files a prompt mentions. Mentions that are
+// not files are left as text; files over the limit are returned without
+// content in tooLarge.
+func (a *App) resolveAttachments(prompt string) (attached, tooLarge []Attachment) {
+	if a.mentions == nil {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	for _, m := range shell.FindMentions(prompt) {
+		if seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+
+		path := a.mentions.Resolve(m.Path)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Size() > a.attachmentLimit {
+			tooLarge = append(tooLarge, Attachment{Path: m.Path, Size: info.Size()})
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		attached = append(attached, Attachment{
+			Path:     m.Path,
+			Content:  content,
+			Size:     int64(len(content)),
+			MIMEType: detectMIME(path, content),
+		})
+	}
+	return attached, tooLarge
+}
+
+// detectMIME returns a file's MIME type from its extension, or failing
+// that from whether its content is text.
+func detectMIME(path string, content []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	if utf8.Valid(content) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// confirmAttachments warns that a prompt mentions files over the size
+// limit, and sends it without them if the user agrees. Until then the
+// prompt is kept in the input, where cancelling leaves it for editing.
+func (a *App) confirmAttachments(prompt string, attached, tooLarge []Attachment) {
+	var names []string
+	for _, at := range tooLarge {
+		names = append(names, fmt.Sprintf("%s (%s)", at.Path, shell.FormatSize(at.Size)))
+	}
+	subject, object := "This file is", "it"
+	if len(tooLarge) > 1 {
+		subject, object = "These files are", "them"
+	}
+	message := fmt.Sprintf("%s over the %s attachment limit:\n\n  %s\n\nSend the prompt without %s?",
+		subject, shell.FormatSize(a.attachmentLimit), strings.Join(names, "\n  "), object)
+
+	a.shell.SetInputValue(prompt)
+	a.shell.PushModal(shell.NewConfirmModal(shell.ConfirmModalConfig{
+		ID:      "attachment-limit",
+		Title:   "Attachment too large",
+		Message: message,
+		Options: []shell.ConfirmOption{
+			{Label: "Send without", Value: true},
+			{Label: "Keep editing", Value: false},
+		},
+		OnResult: func(v any) {
+			if send, _ := v.(bool); send {
+				a.shell.SetInputValue("")
+				a.sendPrompt(prompt, attached)
+			}
+		},
+	}))
+}
+
+// attachmentRecords returns the references to attachments kept in the
+// session transcript.
+func attachmentRecords(attachments []Attachment) []session.Attachment {
+	var out []session.Attachment
+	for _, at := range attachments {
+		out = append(out, session.Attachment{Path: at.Path, Size: at.Size, MIMEType: at.MIMEType})
+	}
+	return out
+}
+
+// attachmentRefs returns the collapsed references to attachments shown
+// under a prompt in the chat.
+func attachmentRefs(attachments []session.Attachment) []string {
+	var refs []string
+	for _, at := range attachments {
+		refs = append(refs, fmt.Sprintf("%s (%s)", at.Path, shell.FormatSize(at.Size)))
+	}
+	return refs
+}
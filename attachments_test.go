@@ -0,0 +1,151 @@
+package tux
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// requestAgent records run requests and runs until cancelled.
+type requestAgent struct {
+	*steerAgent
+	requests chan RunRequest
+}
+
+func newRequestAgent() *requestAgent {
+	return &requestAgent{steerAgent: newSteerAgent(), requests: make(chan RunRequest, 4)}
+}
+
+func (r *requestAgent) RunRequest(ctx context.Context, req RunRequest) error {
+	r.requests <- req
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// nextRequest waits for the agent to be run.
+func (r *requestAgent) nextRequest(t *testing.T) RunRequest {
+	t.Helper()
+	select {
+	case req := <-r.requests:
+		return req
+	case <-time.After(time.Second):
+		t.Fatal("expected the agent to be run")
+		return RunRequest{}
+	}
+}
+
+// chdirTree changes into a new directory holding the given files.
+func chdirTree(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+func TestAppAttachesMentionedFiles(t *testing.T) {
+	chdirTree(t, map[string]string{"notes.txt": "hello"})
+	agent := newRequestAgent()
+	app := New(agent)
+
+	app.submitPrompt("summarize @notes.txt, not @missing.go or @notes.txt")
+	req := agent.nextRequest(t)
+	if req.Prompt != "summarize @notes.txt, not @missing.go or @notes.txt" {
+		t.Errorf("expected the prompt as typed, got %q", req.Prompt)
+	}
+	if len(req.Attachments) != 1 {
+		t.Fatalf("expected one attachment, got %+v", req.Attachments)
+	}
+	at := req.Attachments[0]
+	if at.Path != "notes.txt" || string(at.Content) != "hello" || at.Size != 5 || at.MIMEType != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected attachment %+v", at)
+	}
+
+	// Shown collapsed under the prompt, and referenced in the transcript
+	app.chat.SetSize(80, 20)
+	if view := app.chat.View(); !strings.Contains(view, "▸ @notes.txt (5 B)") || strings.Contains(view, "hello") {
+		t.Errorf("expected a collapsed reference, got:\n%s", view)
+	}
+	app.mu.Lock()
+	recs := app.records
+	app.mu.Unlock()
+	if len(recs[0].Attachments) != 1 || recs[0].Attachments[0].Path != "notes.txt" || recs[0].Attachments[0].Size != 5 {
+		t.Errorf("expected the attachment recorded, got %+v", recs[0])
+	}
+
+	// Attachments of queued prompts go with them
+	app.submitPrompt("and @notes.txt again")
+	app.processEvent(Event{Type: EventComplete, RunID: 1})
+	if req := agent.nextRequest(t); len(req.Attachments) != 1 {
+		t.Errorf("expected the queued prompt's attachment, got %+v", req.Attachments)
+	}
+	app.cancelRun()
+}
+
+func TestAppAttachmentsPlainAgent(t *testing.T) {
+	chdirTree(t, map[string]string{"notes.txt": "hello"})
+	agent := newSteerAgent()
+	app := New(agent)
+
+	app.submitPrompt("summarize @notes.txt")
+	if got := agent.nextPrompt(t); got != "summarize @notes.txt" {
+		t.Errorf("expected the plain prompt, got %q", got)
+	}
+	app.cancelRun()
+}
+
+func TestAppWithoutAttachments(t *testing.T) {
+	chdirTree(t, map[string]string{"notes.txt": "hello"})
+	agent := newRequestAgent()
+	app := New(agent, WithoutAttachments())
+
+	app.submitPrompt("summarize @notes.txt")
+	if req := agent.nextRequest(t); len(req.Attachments) != 0 {
+		t.Errorf("expected no attachments, got %+v", req.Attachments)
+	}
+	app.cancelRun()
+}
+
+func TestAppAttachmentLimit(t *testing.T) {
+	chdirTree(t, map[string]string{"big.log": "0123456789", "small.txt": "ok"})
+	agent := newRequestAgent()
+	app := New(agent, WithAttachmentLimit(4))
+	press := func(key tea.KeyType) {
+		_, cmd := app.shell.Update(tea.KeyMsg{Type: key})
+		if cmd != nil {
+			app.shell.Update(cmd())
+		}
+	}
+
+	// Keeping editing leaves the prompt in the input
+	app.submitPrompt("compare @big.log with @small.txt")
+	if !app.shell.HasModal() || app.shell.InputValue() != "compare @big.log with @small.txt" {
+		t.Fatalf("expected a warning with the prompt kept, got modal %v, input %q", app.shell.HasModal(), app.shell.InputValue())
+	}
+	press(tea.KeyDown)
+	press(tea.KeyEnter)
+	if app.shell.HasModal() || app.isRunning() || app.shell.InputValue() == "" {
+		t.Fatal("expected the prompt left for editing")
+	}
+
+	// Sending goes without the large file
+	app.shell.SetInputValue("")
+	app.submitPrompt("compare @big.log with @small.txt")
+	press(tea.KeyEnter)
+	req := agent.nextRequest(t)
+	if len(req.Attachments) != 1 || req.Attachments[0].Path != "small.txt" {
+		t.Errorf("expected only the small file, got %+v", req.Attachments)
+	}
+	if app.shell.InputValue() != "" {
+		t.Errorf("expected the input cleared, got %q", app.shell.InputValue())
+	}
+	app.cancelRun()
+}
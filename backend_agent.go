@@ -0,0 +1,605 @@
+// backend_agent.go
+package tux
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/2389-research/tux/agent"
+)
+
+// Compile-time checks that BackendAgent implements Agent and takes
+// attachments.
+var (
+	_ Agent         = (*BackendAgent)(nil)
+	_ RequestRunner = (*BackendAgent)(nil)
+)
+
+// BackendAgent adapts an agent.Backend to the Agent interface.
+// It owns the conversation history and runs the agent loop: stream a turn,
+// run any requested tools through an agent.Queue, feed the results back,
+// and repeat until the backend finishes without requesting tools.
+type BackendAgent struct {
+	backend    agent.Backend
+	classifier agent.Classifier
+
+	mu       sync.Mutex
+	messages []agent.Message
+	next     chan Event // Channel handed out by the latest Subscribe
+	cancel   context.CancelFunc
+	msgSeq   int
+
+	// runMu serializes runs so a cancelled run finishes before the next starts.
+	runMu sync.Mutex
+}
+
+// NewBackendAgent creates an Agent that drives the given backend.
+// If classifier is nil, every tool call requires approval.
+func NewBackendAgent(backend agent.Backend, classifier agent.Classifier) *BackendAgent {
+	if backend == nil {
+		panic("tux.NewBackendAgent: backend cannot be nil")
+	}
+	return &BackendAgent{
+		backend:    backend,
+		classifier: classifier,
+		messages:   make([]agent.Message, 0),
+	}
+}
+
+// NewFromBackend creates an App driven directly by an agent.Backend.
+// Tool calls are classified with the classifier set via WithClassifier.
+func NewFromBackend(backend agent.Backend, opts ...Option) *App {
+	ba := NewBackendAgent(backend, nil)
+	app := New(ba, opts...)
+	ba.SetClassifier(app.config.classifier)
+	return app
+}
+
+// SetClassifier sets the classifier used to pre-sort tool calls.
+func (b *BackendAgent) SetClassifier(classifier agent.Classifier) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.classifier = classifier
+}
+
+// setDefaultClassifier sets the classifier unless one is already set.
+func (b *BackendAgent) setDefaultClassifier(classifier agent.Classifier) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.classifier == nil {
+		b.classifier = classifier
+	}
+}
+
+// Messages returns a copy of the conversation history.
+func (b *BackendAgent) Messages() []agent.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]agent.Message, len(b.messages))
+	copy(result, b.messages)
+	return result
+}
+
+// SetMessages replaces the conversation history, e.g. to resume a saved
+// conversation. Messages without an ID or timestamp get one, as in a run.
+func (b *BackendAgent) SetMessages(messages []agent.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = slices.Clone(messages)
+	for i := range b.messages {
+		b.msgSeq++
+		if b.messages[i].ID == "" {
+			b.messages[i].ID = fmt.Sprintf("msg-%d", b.msgSeq)
+		}
+		if b.messages[i].Timestamp.IsZero() {
+			b.messages[i].Timestamp = time.Now()
+		}
+	}
+}
+
+// Reset clears the conversation history.
+func (b *BackendAgent) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = make([]agent.Message, 0)
+}
+
+// Subscribe implements Agent.
+// Each call returns a fresh channel that receives the events of the next run
+// and is closed when that run completes.
+func (b *BackendAgent) Subscribe() <-chan Event {
+	ch := make(chan Event, 64)
+	b.mu.Lock()
+	b.next = ch
+	b.mu.Unlock()
+	return ch
+}
+
+// Cancel implements Agent.
+func (b *BackendAgent) Cancel() {
+	b.mu.Lock()
+	cancel := b.cancel
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	b.backend.Cancel()
+}
+
+// Run implements Agent.
+// It appends the prompt to the conversation and loops over backend turns
+// until the backend stops requesting tools, the context is cancelled, or an
+// error occurs.
+func (b *BackendAgent) Run(ctx context.Context, prompt string) error {
+	return b.run(ctx, agent.Message{Role: agent.RoleUser, Content: prompt})
+}
+
+// RunRequest implements RequestRunner. Like Run, but the user message also
+// holds the prompt and each attachment as content blocks; its Content is
+// the prompt alone, for backends that do not read blocks.
+func (b *BackendAgent) RunRequest(ctx context.Context, req RunRequest) error {
+	msg := agent.Message{Role: agent.RoleUser, Content: req.Prompt}
+	if len(req.Attachments) > 0 {
+		msg.ContentBlocks = []agent.ContentBlock{{Type: "text", Text: req.Prompt}}
+		for _, at := range req.Attachments {
+			msg.ContentBlocks = append(msg.ContentBlocks, agent.ContentBlock{
+				Type:       "attachment",
+				Attachment: &agent.Attachment{Path: at.Path, MIMEType: at.MIMEType, Content: at.Content},
+			})
+		}
+	}
+	return b.run(ctx, msg)
+}
+
+// run appends a user message to the conversation and runs the agent loop.
+func (b *BackendAgent) run(ctx context.Context, msg agent.Message) error {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	b.mu.Lock()
+	out := b.next
+	b.next = nil
+	b.cancel = cancel
+	b.mu.Unlock()
+
+	runID, _ := RunIDFromContext(ctx)
+	r := &backendRun{agent: b, ctx: ctx, cancel: cancel, out: out, runID: runID}
+	defer r.close()
+
+	// Forward approval requests that originate inside the backend
+	r.startApprovalPump()
+
+	b.appendMessage(msg)
+
+	for {
+		calls, err := r.streamTurn()
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			b.closeToolUses(nil, "cancelled by user")
+			return ctx.Err()
+		}
+		// The calls of a failed turn are not run
+		if r.failed != nil {
+			var done []agent.ToolResult
+			for _, call := range calls {
+				if call.result != nil {
+					done = append(done, *call.result)
+				}
+			}
+			b.closeToolUses(done, "not run: "+r.failed.Error())
+			return r.failed
+		}
+		// Stop once no more tools are requested
+		if len(calls) == 0 {
+			complete := Event{Type: EventComplete}
+			if r.usage != (TokenUsage{}) {
+				usage := r.usage
+				complete.Usage = &usage
+			}
+			r.emit(complete)
+			return nil
+		}
+
+		results := r.runTools(calls)
+		if ctx.Err() != nil {
+			b.closeToolUses(results, "cancelled by user")
+			return ctx.Err()
+		}
+
+		blocks := make([]agent.ContentBlock, 0, len(results))
+		for i := range results {
+			blocks = append(blocks, agent.ContentBlock{Type: "tool_result", ToolResult: &results[i]})
+		}
+		b.appendMessage(agent.Message{Role: agent.RoleTool, ContentBlocks: blocks})
+	}
+}
+
+// appendMessage adds a message to the history, filling in ID and timestamp.
+func (b *BackendAgent) appendMessage(msg agent.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.msgSeq++
+	if msg.ID == "" {
+		msg.ID = fmt.Sprintf("msg-%d", b.msgSeq)
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	b.messages = append(b.messages, msg)
+}
+
+// closeToolUses answers the tool calls of a cancelled or failed turn, so
+// the history stays valid for the next run: every tool_use in the last
+// assistant message gets its result from done, or an error with reason.
+func (b *BackendAgent) closeToolUses(done []agent.ToolResult, reason string) {
+	b.mu.Lock()
+	var uses []*agent.ToolUse
+	if n := len(b.messages); n > 0 && b.messages[n-1].Role == agent.RoleAssistant {
+		for _, block := range b.messages[n-1].ContentBlocks {
+			if block.ToolUse != nil {
+				uses = append(uses, block.ToolUse)
+			}
+		}
+	}
+	b.mu.Unlock()
+	if len(uses) == 0 {
+		return
+	}
+
+	blocks := make([]agent.ContentBlock, 0, len(uses))
+	for _, use := range uses {
+		result := agent.ToolResult{ToolUseID: use.ID, Content: reason, IsError: true}
+		for _, r := range done {
+			if r.ToolUseID == use.ID {
+				result = r
+				break
+			}
+		}
+		blocks = append(blocks, agent.ContentBlock{Type: "tool_result", ToolResult: &result})
+	}
+	b.appendMessage(agent.Message{Role: agent.RoleTool, ContentBlocks: blocks})
+}
+
+// setToolInput replaces the input of a recorded tool call, so the history
+// shows the parameters the tool actually ran with.
+func (b *BackendAgent) setToolInput(id string, input map[string]any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.messages) - 1; i >= 0; i-- {
+		for j, block := range b.messages[i].ContentBlocks {
+			if block.ToolUse == nil || block.ToolUse.ID != id {
+				continue
+			}
+			tool := *block.ToolUse
+			tool.Input = input
+			// Copy the blocks so messages handed out earlier are unchanged
+			blocks := slices.Clone(b.messages[i].ContentBlocks)
+			blocks[j].ToolUse = &tool
+			b.messages[i].ContentBlocks = blocks
+			return
+		}
+	}
+}
+
+// pendingCall is a tool call awaiting execution, with its result if the
+// backend already ran it during the stream.
+type pendingCall struct {
+	tool   agent.ToolUse
+	result *agent.ToolResult
+}
+
+// backendRun holds the per-run state of a BackendAgent.
+type backendRun struct {
+	agent  *BackendAgent
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    chan Event
+	runID  uint64
+	failed error      // First error the backend reported
+	usage  TokenUsage // Summed over the run's backend turns
+
+	pumpDone chan struct{}
+}
+
+// emit delivers an event to the subscriber, if any, stamped with the run ID.
+// Events are dropped once the run is cancelled.
+func (r *backendRun) emit(ev Event) {
+	if r.out == nil {
+		return
+	}
+	ev.RunID = r.runID
+	select {
+	case r.out <- ev:
+	case <-r.ctx.Done():
+	}
+}
+
+// close stops the approval pump and closes the subscriber channel.
+func (r *backendRun) close() {
+	r.cancel()
+	if r.pumpDone != nil {
+		<-r.pumpDone
+	}
+	if r.out != nil {
+		close(r.out)
+	}
+}
+
+// startApprovalPump forwards backend-originated approval requests to the UI
+// for the lifetime of the run.
+func (r *backendRun) startApprovalPump() {
+	requests := r.agent.backend.ApprovalRequests()
+	if requests == nil {
+		return
+	}
+	r.pumpDone = make(chan struct{})
+	go func() {
+		defer close(r.pumpDone)
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case req, ok := <-requests:
+				if !ok {
+					// Closed channel: wait out the run without spinning
+					<-r.ctx.Done()
+					return
+				}
+				decision, params, ok := r.requestApproval(req.Tool, req.EditResponse != nil)
+				if !ok {
+					return
+				}
+				if req.EditResponse != nil {
+					select {
+					case req.EditResponse <- agent.ApprovalResponse{Decision: decision, Params: params}:
+					case <-r.ctx.Done():
+						return
+					}
+				} else if req.Response != nil {
+					select {
+					case req.Response <- decision:
+					case <-r.ctx.Done():
+						return
+					}
+				} else {
+					_ = r.agent.backend.RespondToApproval(req.ID, decision)
+				}
+			}
+		}
+	}()
+}
+
+// streamTurn streams one backend turn, forwarding events to the UI.
+// Returns the tool calls requested during the turn.
+func (r *backendRun) streamTurn() ([]pendingCall, error) {
+	events, err := r.agent.backend.Stream(r.ctx, r.agent.Messages())
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	var calls []pendingCall
+
+	for ev := range events {
+		switch ev.Type {
+		case agent.EventText:
+			text.WriteString(ev.Text)
+			r.emit(Event{Type: EventText, Text: ev.Text})
+
+		case agent.EventThinking:
+			r.emit(Event{Type: EventThinking, Text: ev.Text})
+
+		case agent.EventToolCall:
+			if ev.Tool == nil {
+				continue
+			}
+			calls = append(calls, pendingCall{tool: *ev.Tool})
+			r.emit(Event{
+				Type:       EventToolCall,
+				ToolID:     ev.Tool.ID,
+				ToolName:   ev.Tool.Name,
+				ToolParams: ev.Tool.Input,
+			})
+
+		case agent.EventToolResult:
+			// The backend executed the tool itself; record the result
+			if ev.Result == nil {
+				continue
+			}
+			for i := range calls {
+				if calls[i].tool.ID == ev.Result.ToolUseID {
+					result := *ev.Result
+					calls[i].result = &result
+				}
+			}
+			r.emit(Event{
+				Type:       EventToolResult,
+				ToolID:     ev.Result.ToolUseID,
+				ToolOutput: ev.Result.Content,
+				Success:    !ev.Result.IsError,
+			})
+
+		case agent.EventError:
+			err := ev.Error
+			if err == nil {
+				err = fmt.Errorf("unknown error")
+			}
+			if r.failed == nil {
+				r.failed = err
+			}
+			r.emit(Event{Type: EventError, Error: err})
+
+		case agent.EventComplete:
+			// Turn end is signalled by the channel closing
+			if ev.Usage != nil {
+				r.usage = addUsage(r.usage, *ev.Usage)
+				r.emit(Event{Type: EventUsage, Usage: ev.Usage})
+			}
+		}
+	}
+
+	// Record the assistant turn
+	var blocks []agent.ContentBlock
+	if text.Len() > 0 {
+		blocks = append(blocks, agent.ContentBlock{Type: "text", Text: text.String()})
+	}
+	for i := range calls {
+		tool := calls[i].tool
+		blocks = append(blocks, agent.ContentBlock{Type: "tool_use", ToolUse: &tool})
+	}
+	if len(blocks) > 0 {
+		r.agent.appendMessage(agent.Message{
+			Role:          agent.RoleAssistant,
+			Content:       text.String(),
+			ContentBlocks: blocks,
+		})
+	}
+
+	return calls, nil
+}
+
+// runTools processes the turn's tool calls through a Queue, asking for
+// approval where the classifier requires it, and returns their results.
+func (r *backendRun) runTools(calls []pendingCall) []agent.ToolResult {
+	r.agent.mu.Lock()
+	classifier := r.agent.classifier
+	r.agent.mu.Unlock()
+
+	infos := make([]agent.ToolInfo, len(calls))
+	for i, call := range calls {
+		desc := r.agent.backend.DescribeTool(call.tool.Name)
+		infos[i] = agent.ToolInfo{
+			ID:     call.tool.ID,
+			Name:   call.tool.Name,
+			Params: call.tool.Input,
+			Risk:   desc.Risk,
+		}
+	}
+
+	q := agent.NewQueue(infos, classifier)
+	for i := 0; !q.IsComplete(); i++ {
+		item := q.Next()
+		call := calls[i]
+
+		// Already executed by the backend during the stream
+		if call.result != nil {
+			q.SetOutcome(agent.OutcomeExecuted, call.result)
+			q.Advance()
+			continue
+		}
+
+		approved := false
+		switch item.Action {
+		case agent.ActionAutoApprove:
+			approved = true
+		case agent.ActionAutoDeny:
+			approved = false
+		default:
+			decision, params, ok := r.requestApproval(item.Tool, true)
+			if !ok {
+				return q.Results()
+			}
+			approved = decision == agent.Approve || decision == agent.AlwaysAllow
+			if approved && params != nil {
+				// Run with the parameters the user edited
+				call.tool.Input = params
+				r.agent.setToolInput(call.tool.ID, params)
+			}
+		}
+
+		if !approved {
+			reason := "denied by user"
+			if item.Action == agent.ActionAutoDeny && item.Reason != "" {
+				reason = "denied: " + item.Reason
+			}
+			result := agent.ToolResult{ToolUseID: call.tool.ID, Content: reason, IsError: true}
+			q.SetOutcome(agent.OutcomeDenied, &result)
+			r.emitResult(result)
+			q.Advance()
+			continue
+		}
+
+		result, err := r.agent.backend.ExecuteTool(r.ctx, call.tool)
+		if result.ToolUseID == "" {
+			result.ToolUseID = call.tool.ID
+		}
+		if err != nil {
+			result.Content = err.Error()
+			result.IsError = true
+			q.SetOutcome(agent.OutcomeError, &result)
+		} else {
+			q.SetOutcome(agent.OutcomeExecuted, &result)
+		}
+		r.emitResult(result)
+		q.Advance()
+	}
+
+	return q.Results()
+}
+
+// emitResult forwards a tool result to the UI.
+func (r *backendRun) emitResult(result agent.ToolResult) {
+	r.emit(Event{
+		Type:       EventToolResult,
+		ToolID:     result.ToolUseID,
+		ToolOutput: result.Content,
+		Success:    !result.IsError,
+	})
+}
+
+// requestApproval emits an approval event and blocks until the user decides.
+// If editable, the user may edit the parameters; the edited parameters are
+// returned, or nil if unchanged. Returns false if the run is cancelled first.
+func (r *backendRun) requestApproval(tool agent.ToolInfo, editable bool) (agent.ApprovalDecision, map[string]any, bool) {
+	if r.out == nil {
+		// Nobody is listening; fail closed
+		return agent.Deny, nil, true
+	}
+
+	response := make(chan ApprovalDecision, 1)
+	var edited chan ApprovalResponse
+	if editable {
+		edited = make(chan ApprovalResponse, 1)
+	}
+	r.emit(Event{
+		Type:         EventApproval,
+		ToolID:       tool.ID,
+		ToolName:     tool.Name,
+		ToolParams:   tool.Params,
+		Response:     response,
+		EditResponse: edited,
+	})
+
+	select {
+	case d := <-response:
+		return toAgentDecision(d), nil, true
+	case resp := <-edited:
+		return toAgentDecision(resp.Decision), resp.Params, true
+	case <-r.ctx.Done():
+		return agent.Deny, nil, false
+	}
+}
+
+// toAgentDecision maps a UI approval decision to the backend's decision type.
+func toAgentDecision(d ApprovalDecision) agent.ApprovalDecision {
+	switch d {
+	case DecisionApprove:
+		return agent.Approve
+	case DecisionAlwaysAllow:
+		return agent.AlwaysAllow
+	case DecisionNeverAllow:
+		return agent.NeverAllow
+	default:
+		return agent.Deny
+	}
+}
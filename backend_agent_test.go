@@ -0,0 +1,630 @@
+// backend_agent_test.go
+package tux
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/agent"
+)
+
+// scriptedBackend replays a fixed sequence of turns, one per Stream call.
+type scriptedBackend struct {
+	mu        sync.Mutex
+	turns     [][]agent.Event
+	calls     int
+	seen      [][]agent.Message
+	executed  []string
+	inputs    map[string]map[string]any // Input each tool ran with, by ID
+	approvals chan agent.ApprovalRequest
+	risk      map[string]agent.RiskLevel
+	responded map[string]agent.ApprovalDecision
+	gate      chan struct{} // If set, Stream waits for it to close
+}
+
+func newScriptedBackend(turns ...[]agent.Event) *scriptedBackend {
+	return &scriptedBackend{
+		turns:     turns,
+		risk:      make(map[string]agent.RiskLevel),
+		responded: make(map[string]agent.ApprovalDecision),
+		inputs:    make(map[string]map[string]any),
+	}
+}
+
+func (b *scriptedBackend) Stream(ctx context.Context, messages []agent.Message) (<-chan agent.Event, error) {
+	if b.gate != nil {
+		select {
+		case <-b.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	b.mu.Lock()
+	if b.calls >= len(b.turns) {
+		b.mu.Unlock()
+		return nil, errors.New("script exhausted")
+	}
+	turn := b.turns[b.calls]
+	b.calls++
+	b.seen = append(b.seen, messages)
+	b.mu.Unlock()
+
+	ch := make(chan agent.Event, len(turn))
+	for _, ev := range turn {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (b *scriptedBackend) ExecuteTool(ctx context.Context, tool agent.ToolUse) (agent.ToolResult, error) {
+	b.mu.Lock()
+	b.executed = append(b.executed, tool.Name)
+	b.inputs[tool.ID] = tool.Input
+	b.mu.Unlock()
+	if tool.Name == "fail" {
+		return agent.ToolResult{}, errors.New("tool failed")
+	}
+	return agent.ToolResult{ToolUseID: tool.ID, Content: tool.Name + " ok"}, nil
+}
+
+func (b *scriptedBackend) ApprovalRequests() <-chan agent.ApprovalRequest {
+	return b.approvals
+}
+
+func (b *scriptedBackend) RespondToApproval(requestID string, decision agent.ApprovalDecision) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.responded[requestID] = decision
+	return nil
+}
+
+func (b *scriptedBackend) DescribeTool(name string) agent.ToolDescription {
+	return agent.ToolDescription{Name: name, Risk: b.risk[name]}
+}
+
+func (b *scriptedBackend) Cancel() {}
+
+func (b *scriptedBackend) executedTools() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.executed...)
+}
+
+func toolCall(id, name string) agent.Event {
+	return agent.NewToolCallEvent(agent.ToolUse{ID: id, Name: name, Input: map[string]any{"arg": id}})
+}
+
+// collectEvents drains a run's events, answering approvals with decide.
+func collectEvents(events <-chan Event, decide func(Event) ApprovalDecision) []Event {
+	var got []Event
+	for ev := range events {
+		if ev.Type == EventApproval && ev.Response != nil {
+			ev.Response <- decide(ev)
+		}
+		got = append(got, ev)
+	}
+	return got
+}
+
+func TestBackendAgentTextOnlyTurn(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{agent.NewTextEvent("Hello "), agent.NewTextEvent("world")},
+	)
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	done := make(chan []Event)
+	go func() { done <- collectEvents(events, nil) }()
+
+	if err := ba.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	got := <-done
+
+	if len(got) != 3 || got[len(got)-1].Type != EventComplete {
+		t.Fatalf("expected two text events and complete, got %+v", got)
+	}
+
+	msgs := ba.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected user and assistant messages, got %d", len(msgs))
+	}
+	if msgs[0].Role != agent.RoleUser || msgs[0].Content != "hi" {
+		t.Errorf("unexpected user message: %+v", msgs[0])
+	}
+	if msgs[1].Role != agent.RoleAssistant || msgs[1].Content != "Hello world" {
+		t.Errorf("unexpected assistant message: %+v", msgs[1])
+	}
+}
+
+func TestBackendAgentForwardsThinking(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{agent.NewThinkingEvent("hmm"), agent.NewTextEvent("ok")},
+	)
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	done := make(chan []Event)
+	go func() { done <- collectEvents(events, nil) }()
+
+	if err := ba.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	got := <-done
+	if len(got) != 3 || got[0].Type != EventThinking || got[0].Text != "hmm" {
+		t.Fatalf("expected thinking, text and complete, got %+v", got)
+	}
+	if msgs := ba.Messages(); msgs[len(msgs)-1].Content != "ok" {
+		t.Errorf("expected reasoning left out of the history, got %q", msgs[len(msgs)-1].Content)
+	}
+}
+
+func TestBackendAgentForwardsUsage(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "read"), agent.NewCompleteEvent(agent.TokenUsage{InputTokens: 100, OutputTokens: 10})},
+		[]agent.Event{agent.NewTextEvent("ok"), agent.NewCompleteEvent(agent.TokenUsage{InputTokens: 150, OutputTokens: 20, CacheHits: 100})},
+	)
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	done := make(chan []Event)
+	go func() { done <- collectEvents(events, func(Event) ApprovalDecision { return DecisionApprove }) }()
+
+	if err := ba.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	got := <-done
+
+	var reports []TokenUsage
+	for _, ev := range got {
+		if ev.Type == EventUsage {
+			reports = append(reports, *ev.Usage)
+		}
+	}
+	if len(reports) != 2 || reports[0].InputTokens != 100 || reports[1].InputTokens != 150 {
+		t.Errorf("expected usage per backend turn, got %+v", reports)
+	}
+
+	complete := got[len(got)-1]
+	want := TokenUsage{InputTokens: 250, OutputTokens: 30, CacheHits: 100}
+	if complete.Type != EventComplete || complete.Usage == nil || *complete.Usage != want {
+		t.Errorf("expected run total %+v on complete, got %+v", want, complete)
+	}
+}
+
+func TestBackendAgentMultiToolSession(t *testing.T) {
+	backend := newScriptedBackend(
+		// Turn 1: two tools
+		[]agent.Event{
+			agent.NewTextEvent("Reading files."),
+			toolCall("t1", "read"),
+			toolCall("t2", "write"),
+		},
+		// Turn 2: one more tool after seeing results
+		[]agent.Event{toolCall("t3", "delete")},
+		// Turn 3: final answer
+		[]agent.Event{agent.NewTextEvent("Done.")},
+	)
+	backend.risk["read"] = agent.RiskLow
+	backend.risk["write"] = agent.RiskMedium
+	backend.risk["delete"] = agent.RiskHigh
+
+	ba := NewBackendAgent(backend, agent.RiskBasedClassifier)
+	events := ba.Subscribe()
+	done := make(chan []Event)
+	go func() {
+		done <- collectEvents(events, func(ev Event) ApprovalDecision {
+			if ev.ToolName == "delete" {
+				return DecisionDeny
+			}
+			return DecisionApprove
+		})
+	}()
+
+	if err := ba.Run(context.Background(), "tidy up"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	got := <-done
+
+	// Low risk read is auto-approved; write and delete ask
+	var approvals []string
+	var results []Event
+	for _, ev := range got {
+		switch ev.Type {
+		case EventApproval:
+			approvals = append(approvals, ev.ToolName)
+		case EventToolResult:
+			results = append(results, ev)
+		}
+	}
+	if strings.Join(approvals, ",") != "write,delete" {
+		t.Errorf("expected approvals for write,delete, got %v", approvals)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 tool results, got %d", len(results))
+	}
+	if !results[0].Success || !results[1].Success || results[2].Success {
+		t.Errorf("unexpected result outcomes: %+v", results)
+	}
+	if exec := strings.Join(backend.executedTools(), ","); exec != "read,write" {
+		t.Errorf("expected read,write executed, got %s", exec)
+	}
+
+	// Tool results are fed back on the next turn
+	if len(backend.seen) != 3 {
+		t.Fatalf("expected 3 Stream calls, got %d", len(backend.seen))
+	}
+	second := backend.seen[1]
+	last := second[len(second)-1]
+	if last.Role != agent.RoleTool || len(last.ContentBlocks) != 2 {
+		t.Fatalf("expected tool message with 2 results, got %+v", last)
+	}
+	if last.ContentBlocks[0].ToolResult.Content != "read ok" {
+		t.Errorf("unexpected first tool result: %+v", last.ContentBlocks[0].ToolResult)
+	}
+	third := backend.seen[2]
+	denied := third[len(third)-1].ContentBlocks[0].ToolResult
+	if !denied.IsError || denied.Content != "denied by user" {
+		t.Errorf("expected denied result, got %+v", denied)
+	}
+
+	// Second prompt continues the same conversation
+	backend.turns = append(backend.turns, []agent.Event{agent.NewTextEvent("Again.")})
+	events = ba.Subscribe()
+	go func() { done <- collectEvents(events, nil) }()
+	if err := ba.Run(context.Background(), "once more"); err != nil {
+		t.Fatalf("second Run returned error: %v", err)
+	}
+	<-done
+	if n := len(ba.Messages()); n != 8 {
+		t.Errorf("expected 8 messages after two prompts, got %d", n)
+	}
+}
+
+func TestBackendAgentToolError(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "fail")},
+		[]agent.Event{agent.NewTextEvent("It failed.")},
+	)
+	ba := NewBackendAgent(backend, func(agent.ToolInfo) (agent.ToolAction, string) {
+		return agent.ActionAutoApprove, ""
+	})
+
+	events := ba.Subscribe()
+	done := make(chan []Event)
+	go func() { done <- collectEvents(events, nil) }()
+
+	if err := ba.Run(context.Background(), "go"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	for _, ev := range <-done {
+		if ev.Type == EventToolResult {
+			if ev.Success || ev.ToolOutput != "tool failed" {
+				t.Errorf("expected failed tool result, got %+v", ev)
+			}
+		}
+	}
+}
+
+func TestBackendAgentForwardsBackendApprovals(t *testing.T) {
+	backend := newScriptedBackend([]agent.Event{agent.NewTextEvent("ok")})
+	backend.approvals = make(chan agent.ApprovalRequest, 1)
+	backend.approvals <- agent.ApprovalRequest{
+		ID:   "req-1",
+		Tool: agent.ToolInfo{ID: "x", Name: "bash"},
+	}
+
+	// Hold the stream open until the approval has been answered
+	backend.gate = make(chan struct{})
+
+	ba := NewBackendAgent(backend, nil)
+	events := ba.Subscribe()
+	go func() {
+		for ev := range events {
+			if ev.Type == EventApproval {
+				ev.Response <- DecisionAlwaysAllow
+			}
+		}
+	}()
+
+	runDone := make(chan error, 1)
+	go func() { runDone <- ba.Run(context.Background(), "go") }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		backend.mu.Lock()
+		d, ok := backend.responded["req-1"]
+		backend.mu.Unlock()
+		if ok {
+			if d != agent.AlwaysAllow {
+				t.Errorf("expected AlwaysAllow response, got %v", d)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected backend approval to be forwarded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(backend.gate)
+	if err := <-runDone; err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
+
+func TestBackendAgentRunsEditedParams(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "bash")},
+		[]agent.Event{agent.NewTextEvent("Done.")},
+	)
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range events {
+			if ev.Type != EventApproval {
+				continue
+			}
+			if ev.EditResponse == nil {
+				t.Error("expected an editable approval")
+				ev.Response <- DecisionDeny
+				continue
+			}
+			ev.EditResponse <- ApprovalResponse{
+				Decision: DecisionApprove,
+				Params:   map[string]any{"arg": "edited"},
+			}
+		}
+	}()
+
+	if err := ba.Run(context.Background(), "go"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	<-done
+
+	if got := backend.inputs["t1"]["arg"]; got != "edited" {
+		t.Errorf("expected tool to run with edited params, got %v", got)
+	}
+	// The history shows what actually ran
+	for _, msg := range ba.Messages() {
+		for _, block := range msg.ContentBlocks {
+			if block.ToolUse != nil && block.ToolUse.Input["arg"] != "edited" {
+				t.Errorf("expected edited input in history, got %v", block.ToolUse.Input)
+			}
+		}
+	}
+}
+
+func TestBackendAgentBackendApprovalEdits(t *testing.T) {
+	backend := newScriptedBackend([]agent.Event{agent.NewTextEvent("ok")})
+	backend.approvals = make(chan agent.ApprovalRequest, 1)
+	edits := make(chan agent.ApprovalResponse, 1)
+	backend.approvals <- agent.ApprovalRequest{
+		ID:           "req-1",
+		Tool:         agent.ToolInfo{ID: "x", Name: "bash", Params: map[string]any{"command": "rm -rf /"}},
+		EditResponse: edits,
+	}
+	backend.gate = make(chan struct{})
+
+	ba := NewBackendAgent(backend, nil)
+	events := ba.Subscribe()
+	go func() {
+		for ev := range events {
+			if ev.Type == EventApproval && ev.EditResponse != nil {
+				ev.EditResponse <- ApprovalResponse{
+					Decision: DecisionApprove,
+					Params:   map[string]any{"command": "ls"},
+				}
+			}
+		}
+	}()
+
+	runDone := make(chan error, 1)
+	go func() { runDone <- ba.Run(context.Background(), "go") }()
+
+	select {
+	case resp := <-edits:
+		if resp.Decision != agent.Approve || resp.Params["command"] != "ls" {
+			t.Errorf("expected approval with edited params, got %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the edited approval to be forwarded")
+	}
+
+	close(backend.gate)
+	if err := <-runDone; err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
+
+func TestBackendAgentStreamError(t *testing.T) {
+	backend := newScriptedBackend()
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	go collectEvents(events, nil)
+
+	if err := ba.Run(context.Background(), "hi"); err == nil {
+		t.Error("expected Stream error to be returned")
+	}
+}
+
+func TestBackendAgentCancelledDuringApproval(t *testing.T) {
+	backend := newScriptedBackend([]agent.Event{toolCall("t1", "write")})
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	asked := make(chan struct{})
+	go func() {
+		for ev := range events {
+			if ev.Type == EventApproval {
+				close(asked)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan error, 1)
+	go func() { runDone <- ba.Run(ctx, "go") }()
+
+	<-asked
+	cancel()
+
+	select {
+	case err := <-runDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run should return after cancellation")
+	}
+	if len(backend.executedTools()) != 0 {
+		t.Error("tool should not run after cancellation")
+	}
+}
+
+func TestBackendAgentCancelAnswersToolUses(t *testing.T) {
+	backend := newScriptedBackend([]agent.Event{toolCall("t1", "read"), toolCall("t2", "write")})
+	ba := NewBackendAgent(backend, func(tool agent.ToolInfo) (agent.ToolAction, string) {
+		if tool.Name == "read" {
+			return agent.ActionAutoApprove, ""
+		}
+		return agent.ActionNeedsApproval, ""
+	})
+
+	events := ba.Subscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for ev := range events {
+			if ev.Type == EventApproval {
+				cancel()
+			}
+		}
+	}()
+	if err := ba.Run(ctx, "go"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// Every tool_use has a result, so the next run's history is valid
+	msgs := ba.Messages()
+	last := msgs[len(msgs)-1]
+	if last.Role != agent.RoleTool || len(last.ContentBlocks) != 2 {
+		t.Fatalf("expected results for both calls, got %+v", last)
+	}
+	done, cancelled := last.ContentBlocks[0].ToolResult, last.ContentBlocks[1].ToolResult
+	if done.ToolUseID != "t1" || done.Content != "read ok" || done.IsError {
+		t.Errorf("expected the finished call's result kept, got %+v", done)
+	}
+	if cancelled.ToolUseID != "t2" || !cancelled.IsError || cancelled.Content != "cancelled by user" {
+		t.Errorf("expected the pending call marked cancelled, got %+v", cancelled)
+	}
+}
+
+func TestBackendAgentFailedTurnAnswersToolUses(t *testing.T) {
+	failure := errors.New("overloaded")
+	backend := newScriptedBackend([]agent.Event{toolCall("t1", "read"), agent.NewErrorEvent(failure)})
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	got := make(chan []Event, 1)
+	go func() { got <- collectEvents(events, func(Event) ApprovalDecision { return DecisionApprove }) }()
+	if err := ba.Run(context.Background(), "go"); !errors.Is(err, failure) {
+		t.Fatalf("expected the backend's error returned, got %v", err)
+	}
+	for _, ev := range <-got {
+		if ev.Type == EventComplete || ev.Type == EventApproval {
+			t.Errorf("expected the failed turn to stop without completing, got %v", ev.Type)
+		}
+	}
+
+	msgs := ba.Messages()
+	last := msgs[len(msgs)-1]
+	if last.Role != agent.RoleTool || len(last.ContentBlocks) != 1 {
+		t.Fatalf("expected a result for the call, got %+v", last)
+	}
+	if r := last.ContentBlocks[0].ToolResult; r.ToolUseID != "t1" || !r.IsError || r.Content != "not run: overloaded" {
+		t.Errorf("expected the call answered with an error, got %+v", r)
+	}
+}
+
+func TestAppShowsFailedTurnOnce(t *testing.T) {
+	backend := newScriptedBackend([]agent.Event{toolCall("t1", "read"), agent.NewErrorEvent(errors.New("overloaded"))})
+	app := NewFromBackend(backend)
+
+	app.submitInput("go")
+	deadline := time.Now().Add(time.Second)
+	for app.isRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the turn to end")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if len(app.errors) != 1 {
+		t.Errorf("expected the error shown once, got %v", app.errors)
+	}
+}
+
+func TestNewFromBackendEndToEnd(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "read"), toolCall("t2", "read")},
+		[]agent.Event{agent.NewTextEvent("All read.")},
+	)
+	app := NewFromBackend(backend, WithClassifier(func(agent.ToolInfo) (agent.ToolAction, string) {
+		return agent.ActionAutoApprove, ""
+	}))
+
+	app.submitInput("read both")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(app.chat.View(), "All read.") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected final answer in chat, got: %s", app.chat.View())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	view := app.tools.View()
+	if strings.Count(view, "✓") != 2 {
+		t.Errorf("expected two successful tool calls, got: %s", view)
+	}
+}
+
+func TestBackendAgentRunRequest(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{agent.NewTextEvent("Read it.")},
+	)
+	ba := NewBackendAgent(backend, nil)
+
+	events := ba.Subscribe()
+	go collectEvents(events, nil)
+	err := ba.RunRequest(context.Background(), RunRequest{
+		Prompt:      "summarize @notes.txt",
+		Attachments: []Attachment{{Path: "notes.txt", Content: []byte("hello"), Size: 5, MIMEType: "text/plain"}},
+	})
+	if err != nil {
+		t.Fatalf("RunRequest returned error: %v", err)
+	}
+
+	msg := backend.seen[0][0]
+	if msg.Content != "summarize @notes.txt" || len(msg.ContentBlocks) != 2 {
+		t.Fatalf("expected the prompt and a block per attachment, got %+v", msg)
+	}
+	if msg.ContentBlocks[0].Text != "summarize @notes.txt" {
+		t.Errorf("expected the prompt as the first block, got %+v", msg.ContentBlocks[0])
+	}
+	at := msg.ContentBlocks[1].Attachment
+	if msg.ContentBlocks[1].Type != "attachment" || at == nil || at.Path != "notes.txt" || string(at.Content) != "hello" || at.MIMEType != "text/plain" {
+		t.Errorf("unexpected attachment block %+v", msg.ContentBlocks[1])
+	}
+}
@@ -2,14 +2,18 @@
 package tux
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 
 	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/markdown"
+	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Compile-time check that ChatContent implements content.Content.
@@ -20,21 +24,39 @@ type ChatContent struct {
 	mu             sync.Mutex
 	theme          theme.Theme
 	messages       []chatMessage
-	current        strings.Builder // Current streaming message
+	markdown       *markdown.Renderer
+	current        *markdown.Stream // Current streaming message
 	width          int
 	height         int
 	userStyle      lipgloss.Style
 	assistantStyle lipgloss.Style
+	thinkingStyle  lipgloss.Style
+
+	// Reasoning of the message being streamed, and whether finished
+	// reasoning is shown in full
+	thinking     strings.Builder
+	showThinking bool
 
 	// Viewport for scrolling
 	viewport   viewport.Model
 	autoScroll bool // Whether to auto-scroll to bottom on new content
 	ready      bool // Whether viewport has been sized
+
+	// Inline, finished messages are printed into the terminal's scrollback
+	// instead of kept in the viewport: print prints them, and printed is
+	// how many have been
+	print   func(string)
+	printed int
 }
 
 type chatMessage struct {
-	role    string // "user" or "assistant"
-	content string
+	role     string // "user" or "assistant"
+	content  string
+	thinking string   // Reasoning shown above an assistant message
+	attached []string // References to files attached to a user message
+	rendered string   // Cached render at the current width
+
+	renderedThinking string // Cached render of the reasoning block
 }
 
 // NewChatContent creates a new ChatContent.
@@ -42,12 +64,21 @@ func NewChatContent(th theme.Theme) *ChatContent {
 	if th == nil {
 		panic("NewChatContent: nil theme")
 	}
+	// Keys are resolved by the shell keymap, not the viewport
+	vp := viewport.New(0, 0)
+	vp.KeyMap = viewport.KeyMap{}
+
+	md := markdown.New(th)
+	md.SetTextColor(th.AssistantColor())
 	return &ChatContent{
 		theme:          th,
 		messages:       make([]chatMessage, 0),
+		markdown:       md,
+		current:        md.NewStream(),
 		userStyle:      lipgloss.NewStyle().Foreground(th.UserColor()),
 		assistantStyle: lipgloss.NewStyle().Foreground(th.AssistantColor()),
-		viewport:       viewport.New(0, 0),
+		thinkingStyle:  lipgloss.NewStyle().Foreground(th.Muted()).Italic(true),
+		viewport:       vp,
 		autoScroll:     true, // Auto-scroll by default
 	}
 }
@@ -58,66 +89,47 @@ func (c *ChatContent) Init() tea.Cmd {
 }
 
 // Update implements content.Content.
-// Handles keyboard navigation for scrolling.
+// Scrolling is driven by shell.ActionMsg so it follows the keymap.
 func (c *ChatContent) Update(msg tea.Msg) (content.Content, tea.Cmd) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		// Track if user manually scrolled (disable auto-scroll)
-		wasAtBottom := c.viewport.AtBottom()
-
-		switch msg.String() {
-		case "k", "up":
+	case shell.ActionMsg:
+		switch msg.Action {
+		case shell.ActionLineUp:
 			c.viewport.LineUp(1)
-			c.autoScroll = false
-		case "j", "down":
+		case shell.ActionLineDown:
 			c.viewport.LineDown(1)
-			if !c.viewport.AtBottom() {
-				c.autoScroll = false
-			}
-		case "g":
-			// For "gg", go to top (single g is handled, double-g needs state)
-			// Simplified: just go to top on single g
-			c.viewport.GotoTop()
-			c.autoScroll = false
-		case "G":
-			c.viewport.GotoBottom()
-			c.autoScroll = true
-		case "ctrl+u":
+		case shell.ActionScrollUp:
 			c.viewport.HalfViewUp()
-			c.autoScroll = false
-		case "ctrl+d":
+		case shell.ActionScrollDown:
 			c.viewport.HalfViewDown()
-			if !c.viewport.AtBottom() {
-				c.autoScroll = false
-			}
-		case "pgup":
-			c.viewport.ViewUp()
-			c.autoScroll = false
-		case "pgdown":
-			c.viewport.ViewDown()
-			if !c.viewport.AtBottom() {
-				c.autoScroll = false
-			}
-		default:
-			// Let viewport handle other keys (arrows, etc.)
-			var cmd tea.Cmd
-			c.viewport, cmd = c.viewport.Update(msg)
-			// Check if user scrolled away from bottom
-			if wasAtBottom && !c.viewport.AtBottom() {
-				c.autoScroll = false
-			} else if c.viewport.AtBottom() {
-				c.autoScroll = true
-			}
-			return c, cmd
+		case shell.ActionScrollTop:
+			c.viewport.GotoTop()
+		case shell.ActionScrollBottom:
+			c.viewport.GotoBottom()
+		}
+		// Follow new content only while the bottom is in view
+		c.autoScroll = c.viewport.AtBottom()
+
+	case tea.MouseMsg:
+		if tea.MouseEvent(msg).IsWheel() {
+			c.viewport, _ = c.viewport.Update(msg)
+			c.autoScroll = c.viewport.AtBottom()
 		}
 	}
 
 	return c, nil
 }
 
+// SetScrollLines sets how many lines a mouse wheel step scrolls.
+func (c *ChatContent) SetScrollLines(lines int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.viewport.MouseWheelDelta = lines
+}
+
 // View implements content.Content.
 func (c *ChatContent) View() string {
 	c.mu.Lock()
@@ -136,30 +148,135 @@ func (c *ChatContent) View() string {
 func (c *ChatContent) renderContent() string {
 	var parts []string
 
-	for _, msg := range c.messages {
-		var style lipgloss.Style
-		if msg.role == "user" {
-			style = c.userStyle
-		} else {
-			style = c.assistantStyle
+	for i := c.printed; i < len(c.messages); i++ {
+		msg := &c.messages[i]
+		if msg.thinking != "" {
+			if msg.renderedThinking == "" {
+				msg.renderedThinking = c.renderThinking(msg.thinking, c.showThinking)
+			}
+			parts = append(parts, msg.renderedThinking)
 		}
-		parts = append(parts, style.Render(msg.content))
+		if msg.content == "" {
+			continue
+		}
+		if msg.rendered == "" {
+			msg.rendered = c.renderMessage(*msg)
+		}
+		parts = append(parts, msg.rendered)
 	}
 
-	// Add current streaming message if any
+	// Add current streaming message if any. Its reasoning stays open
+	// until the answer starts.
+	if c.thinking.Len() > 0 {
+		expanded := c.showThinking || c.current.Len() == 0
+		parts = append(parts, c.renderThinking(c.thinking.String(), expanded))
+	}
 	if c.current.Len() > 0 {
-		parts = append(parts, c.assistantStyle.Render(c.current.String()))
+		parts = append(parts, c.current.View())
 	}
 
 	return strings.Join(parts, "\n\n")
 }
 
+// renderMessage renders a finished message: assistant messages as markdown,
+// user messages as wrapped plain text.
+func (c *ChatContent) renderMessage(msg chatMessage) string {
+	if msg.role == "user" {
+		text := msg.content
+		if c.width > 0 {
+			text = ansi.Wrap(text, c.width, "")
+		}
+		text = c.userStyle.Render(text)
+		for _, ref := range msg.attached {
+			line := "▸ @" + ref
+			if c.width > 0 {
+				line = ansi.Truncate(line, c.width, "…")
+			}
+			text += "\n" + c.thinkingStyle.Render(line)
+		}
+		return text
+	}
+	return c.markdown.Render(msg.content)
+}
+
+// renderThinking renders reasoning as a dimmed block, or as a one-line
+// summary when collapsed.
+func (c *ChatContent) renderThinking(text string, expanded bool) string {
+	text = strings.TrimSpace(text)
+	if !expanded {
+		lines := strings.Count(text, "\n") + 1
+		return c.thinkingStyle.Render(fmt.Sprintf("▸ Thinking (%d lines)", lines))
+	}
+	if c.width > 2 {
+		text = ansi.Wrap(text, c.width-2, "")
+	}
+	body := "  " + strings.ReplaceAll(text, "\n", "\n  ")
+	return c.thinkingStyle.Render("▾ Thinking\n" + body)
+}
+
+// SetTheme implements shell.Themed. Messages are re-rendered in the new
+// theme's colours.
+func (c *ChatContent) SetTheme(th theme.Theme) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = th
+	c.markdown = markdown.New(th)
+	c.markdown.SetTextColor(th.AssistantColor())
+	c.markdown.SetWidth(c.width)
+	pending := c.current.Source()
+	c.current = c.markdown.NewStream()
+	c.current.Append(pending)
+	c.userStyle = lipgloss.NewStyle().Foreground(th.UserColor())
+	c.assistantStyle = lipgloss.NewStyle().Foreground(th.AssistantColor())
+	c.thinkingStyle = lipgloss.NewStyle().Foreground(th.Muted()).Italic(true)
+	for i := range c.messages {
+		c.messages[i].rendered = ""
+		c.messages[i].renderedThinking = ""
+	}
+	c.updateViewport()
+}
+
+// setPrinter prints finished messages with print, at the width the content
+// is sized to, instead of showing them. Used in inline mode.
+func (c *ChatContent) setPrinter(print func(string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.print = print
+	c.updateViewport()
+}
+
+// printFinished prints the finished messages not yet printed, once the
+// content has a width to render them at.
+// Must be called with mutex held.
+func (c *ChatContent) printFinished() {
+	if c.print == nil || !c.ready {
+		return
+	}
+	for ; c.printed < len(c.messages); c.printed++ {
+		msg := &c.messages[c.printed]
+		var parts []string
+		if msg.thinking != "" {
+			parts = append(parts, c.renderThinking(msg.thinking, c.showThinking))
+		}
+		if msg.content != "" {
+			if msg.rendered == "" {
+				msg.rendered = c.renderMessage(*msg)
+			}
+			parts = append(parts, msg.rendered)
+		}
+		if len(parts) > 0 {
+			c.print(strings.Join(parts, "\n\n") + "\n")
+		}
+	}
+}
+
 // updateViewport rebuilds the viewport content and optionally scrolls to bottom.
 // Must be called with mutex held.
 func (c *ChatContent) updateViewport() {
 	if !c.ready {
 		return
 	}
+	c.printFinished()
 	content := c.renderContent()
 	c.viewport.SetContent(content)
 	if c.autoScroll {
@@ -181,11 +298,20 @@ func (c *ChatContent) Value() any {
 func (c *ChatContent) SetSize(width, height int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	widthChanged := width != c.width
 	c.width = width
 	c.height = height
 	c.viewport.Width = width
 	c.viewport.Height = height
-	if !c.ready {
+	if widthChanged {
+		// Re-wrap everything at the new width
+		c.markdown.SetWidth(width)
+		for i := range c.messages {
+			c.messages[i].rendered = ""
+			c.messages[i].renderedThinking = ""
+		}
+	}
+	if !c.ready || widthChanged {
 		c.ready = true
 		c.updateViewport()
 	}
@@ -195,17 +321,51 @@ func (c *ChatContent) SetSize(width, height int) {
 func (c *ChatContent) AppendText(text string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.current.WriteString(text)
+	c.current.Append(text)
 	c.updateViewport()
 }
 
+// AppendThinking appends streaming reasoning to the current assistant
+// message.
+func (c *ChatContent) AppendThinking(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.thinking.WriteString(text)
+	c.updateViewport()
+}
+
+// ToggleThinking expands or collapses the reasoning of finished messages.
+func (c *ChatContent) ToggleThinking() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.showThinking = !c.showThinking
+	for i := range c.messages {
+		c.messages[i].renderedThinking = ""
+	}
+	c.updateViewport()
+}
+
+// ThinkingShown reports whether finished reasoning is expanded.
+func (c *ChatContent) ThinkingShown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.showThinking
+}
+
 // AddUserMessage adds a user message to the conversation.
 func (c *ChatContent) AddUserMessage(content string) {
+	c.addUserMessage(content, nil)
+}
+
+// addUserMessage adds a user message with references to the files attached
+// to it, shown collapsed under it.
+func (c *ChatContent) addUserMessage(content string, attached []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.messages = append(c.messages, chatMessage{
-		role:    "user",
-		content: content,
+		role:     "user",
+		content:  content,
+		attached: attached,
 	})
 	c.updateViewport()
 }
@@ -214,16 +374,34 @@ func (c *ChatContent) AddUserMessage(content string) {
 func (c *ChatContent) FinishAssistantMessage() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.current.Len() > 0 {
+	if c.current.Len() > 0 || c.thinking.Len() > 0 {
 		c.messages = append(c.messages, chatMessage{
-			role:    "assistant",
-			content: c.current.String(),
+			role:     "assistant",
+			content:  c.current.Source(),
+			thinking: c.thinking.String(),
+			// The stream already rendered it
+			rendered: c.current.View(),
 		})
 		c.current.Reset()
+		c.thinking.Reset()
 		c.updateViewport()
 	}
 }
 
+// pendingText returns the assistant message currently being streamed.
+func (c *ChatContent) pendingText() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current.Source()
+}
+
+// pendingThinking returns the reasoning of the message being streamed.
+func (c *ChatContent) pendingThinking() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.thinking.String()
+}
+
 // UserMessages returns all user message contents in order (oldest to newest).
 func (c *ChatContent) UserMessages() []string {
 	c.mu.Lock()
@@ -243,7 +421,9 @@ func (c *ChatContent) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.messages = make([]chatMessage, 0)
+	c.printed = 0
 	c.current.Reset()
+	c.thinking.Reset()
 	c.autoScroll = true // Reset auto-scroll on clear
 	c.updateViewport()
 }
@@ -251,11 +431,17 @@ func (c *ChatContent) Clear() {
 // AddAssistantMessage adds a completed assistant message to the conversation.
 // Use this when restoring a previous session, not for streaming.
 func (c *ChatContent) AddAssistantMessage(content string) {
+	c.addAssistantMessage(content, "")
+}
+
+// addAssistantMessage adds a completed assistant message with its reasoning.
+func (c *ChatContent) addAssistantMessage(content, thinking string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.messages = append(c.messages, chatMessage{
-		role:    "assistant",
-		content: content,
+		role:     "assistant",
+		content:  content,
+		thinking: thinking,
 	})
 	c.updateViewport()
 }
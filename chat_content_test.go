@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestNewChatContent(t *testing.T) {
@@ -171,3 +173,131 @@ func TestChatContentClearResetsAutoScroll(t *testing.T) {
 		t.Error("Clear should reset autoScroll to true")
 	}
 }
+
+func TestChatContentRendersMarkdown(t *testing.T) {
+	th := theme.NewDraculaTheme()
+	chat := NewChatContent(th)
+	chat.SetSize(40, 50)
+
+	// Streamed and finished messages render the same way
+	chat.AppendText("## Plan\n\n- read **files**\n")
+	chat.AppendText("- write `code`\n\n```go\nx := 1\n```")
+	streamed := ansi.Strip(chat.View())
+	chat.FinishAssistantMessage()
+	finished := ansi.Strip(chat.View())
+
+	if streamed != finished {
+		t.Errorf("finishing changed the rendering\nstreamed:\n%s\nfinished:\n%s", streamed, finished)
+	}
+	for _, want := range []string{"Plan", "• read files", "• write code", "  x := 1"} {
+		if !strings.Contains(finished, want) {
+			t.Errorf("expected %q in view\n%s", want, finished)
+		}
+	}
+	if strings.Contains(finished, "**") || strings.Contains(finished, "```") {
+		t.Errorf("expected markdown syntax to be rendered\n%s", finished)
+	}
+}
+
+func TestChatContentWrapsToWidth(t *testing.T) {
+	th := theme.NewDraculaTheme()
+	chat := NewChatContent(th)
+	chat.SetSize(30, 50)
+
+	chat.AddUserMessage(strings.Repeat("question ", 10))
+	chat.AddAssistantMessage(strings.Repeat("answer ", 10))
+
+	for _, line := range strings.Split(ansi.Strip(chat.View()), "\n") {
+		if w := ansi.StringWidth(line); w > 30 {
+			t.Errorf("line exceeds width (%d): %q", w, line)
+		}
+	}
+
+	// Narrowing re-wraps already rendered messages
+	chat.SetSize(20, 50)
+	for _, line := range strings.Split(ansi.Strip(chat.View()), "\n") {
+		if w := ansi.StringWidth(line); w > 20 {
+			t.Errorf("line exceeds new width (%d): %q", w, line)
+		}
+	}
+}
+
+func TestChatContentMouseWheel(t *testing.T) {
+	th := theme.NewDraculaTheme()
+	chat := NewChatContent(th)
+	chat.SetSize(80, 5)
+	chat.SetScrollLines(2)
+	for i := 0; i < 20; i++ {
+		chat.AddUserMessage("Message " + string(rune('A'+i)))
+	}
+
+	chat.Update(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp})
+	chat.mu.Lock()
+	offset := chat.viewport.YOffset
+	bottom := chat.viewport.TotalLineCount() - chat.viewport.Height
+	autoScroll := chat.autoScroll
+	chat.mu.Unlock()
+
+	if offset != bottom-2 {
+		t.Errorf("expected wheel to scroll up 2 lines from %d, got offset %d", bottom, offset)
+	}
+	if autoScroll {
+		t.Error("expected scrolling up to stop following new output")
+	}
+}
+
+func TestChatContentThinking(t *testing.T) {
+	chat := NewChatContent(theme.NewDraculaTheme())
+	chat.SetSize(80, 20)
+
+	// Reasoning is shown while it streams
+	chat.AppendThinking("check the docs\nthen answer")
+	if view := ansi.Strip(chat.View()); !strings.Contains(view, "then answer") {
+		t.Errorf("expected streaming reasoning in full\n%s", view)
+	}
+
+	// And collapses once the answer starts
+	chat.AppendText("Use a map.")
+	chat.FinishAssistantMessage()
+	view := ansi.Strip(chat.View())
+	if !strings.Contains(view, "▸ Thinking (2 lines)") || strings.Contains(view, "then answer") {
+		t.Errorf("expected reasoning collapsed above the answer\n%s", view)
+	}
+	if strings.Index(view, "Thinking") > strings.Index(view, "Use a map.") {
+		t.Errorf("expected reasoning above the answer\n%s", view)
+	}
+
+	chat.ToggleThinking()
+	if view := ansi.Strip(chat.View()); !strings.Contains(view, "then answer") {
+		t.Errorf("expected toggle to expand reasoning\n%s", view)
+	}
+}
+
+func TestChatContentPrintsFinishedMessages(t *testing.T) {
+	chat := NewChatContent(theme.NewDraculaTheme())
+	var printed []string
+	chat.setPrinter(func(s string) { printed = append(printed, ansi.Strip(s)) })
+
+	// Nothing is printed before the content has a width
+	chat.AddUserMessage("Hi")
+	if len(printed) != 0 {
+		t.Fatalf("expected nothing printed before sizing, got %q", printed)
+	}
+	chat.SetSize(40, 10)
+	if len(printed) != 1 || !strings.Contains(printed[0], "Hi") {
+		t.Fatalf("expected the user message printed on sizing, got %q", printed)
+	}
+
+	// The streaming answer stays in view until finished
+	chat.AppendText("Hello there")
+	if len(printed) != 1 || !strings.Contains(chat.View(), "Hello there") {
+		t.Errorf("expected the stream shown, not printed; printed %q", printed)
+	}
+	chat.FinishAssistantMessage()
+	if len(printed) != 2 || !strings.Contains(printed[1], "Hello there") {
+		t.Errorf("expected the answer printed once finished, got %q", printed)
+	}
+	if strings.Contains(ansi.Strip(chat.View()), "Hello there") {
+		t.Error("expected printed messages gone from the view")
+	}
+}
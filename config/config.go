@@ -13,15 +13,17 @@ import (
 
 // Config holds all UI configuration settings.
 type Config struct {
-	Theme         ThemeConfig         `toml:"theme"`
-	Mouse         MouseConfig         `toml:"mouse"`
-	Keybindings   KeybindingsConfig   `toml:"keybindings"`
-	StatusBar     StatusBarConfig     `toml:"statusbar"`
-	TabBar        TabBarConfig        `toml:"tabbar"`
-	Input         InputConfig         `toml:"input"`
-	Modal         ModalConfig         `toml:"modal"`
-	Autocomplete  AutocompleteConfig  `toml:"autocomplete"`
-	Accessibility AccessibilityConfig `toml:"accessibility"`
+	Theme         ThemeConfig            `toml:"theme"`
+	Mouse         MouseConfig            `toml:"mouse"`
+	Keybindings   KeybindingsConfig      `toml:"keybindings"`
+	StatusBar     StatusBarConfig        `toml:"statusbar"`
+	TabBar        TabBarConfig           `toml:"tabbar"`
+	Input         InputConfig            `toml:"input"`
+	Modal         ModalConfig            `toml:"modal"`
+	Display       DisplayConfig          `toml:"display"`
+	Autocomplete  AutocompleteConfig     `toml:"autocomplete"`
+	Accessibility AccessibilityConfig    `toml:"accessibility"`
+	Models        map[string]ModelConfig `toml:"models"`
 }
 
 // ThemeConfig holds theme settings.
@@ -73,7 +75,9 @@ type MouseConfig struct {
 // KeybindingsConfig holds keybinding settings.
 type KeybindingsConfig struct {
 	Submit       []string          `toml:"submit"`
+	Newline      []string          `toml:"newline"`
 	Cancel       []string          `toml:"cancel"`
+	Interrupt    []string          `toml:"interrupt"`
 	Help         []string          `toml:"help"`
 	QuickActions []string          `toml:"quick_actions"`
 	NextTab      []string          `toml:"next_tab"`
@@ -132,7 +136,18 @@ type ModalConfig struct {
 	CloseOnClickOutside bool    `toml:"close_on_click_outside"`
 }
 
-// AutocompleteConfig holds autocomplete settings.
+// DisplayConfig holds display settings. Inline, the app draws a live
+// region of InlineHeight rows below the terminal's scrollback instead of
+// taking the alternate screen, and finished messages are printed above it.
+type DisplayConfig struct {
+	Inline       bool `toml:"inline"`
+	InlineHeight int  `toml:"inline_height"`
+}
+
+// AutocompleteConfig holds autocomplete settings. When enabled,
+// completions are shown as the user types, once the word before the cursor
+// has MinChars characters and typing has paused for DelayMs; disabled, Tab
+// does not complete either.
 type AutocompleteConfig struct {
 	Enabled        bool `toml:"enabled"`
 	MaxSuggestions int  `toml:"max_suggestions"`
@@ -147,6 +162,27 @@ type AccessibilityConfig struct {
 	ScreenReaderHints bool `toml:"screen_reader_hints"`
 }
 
+// ModelConfig holds the context window and prices of a model, keyed by
+// model name in [models.<name>]. Prices are in USD per million tokens.
+type ModelConfig struct {
+	ContextWindow  int     `toml:"context_window"`
+	InputPrice     float64 `toml:"input_price"`
+	OutputPrice    float64 `toml:"output_price"`
+	CacheReadPrice float64 `toml:"cache_read_price"` // Input tokens read from cache; InputPrice if 0
+}
+
+// Cost returns the price in USD of a request. cached is the part of the
+// input tokens read from cache.
+func (m ModelConfig) Cost(input, output, cached int) float64 {
+	cacheRead := m.CacheReadPrice
+	if cacheRead == 0 {
+		cacheRead = m.InputPrice
+	}
+	return (float64(input-cached)*m.InputPrice +
+		float64(cached)*cacheRead +
+		float64(output)*m.OutputPrice) / 1e6
+}
+
 // Default returns the default configuration.
 func Default() *Config {
 	return &Config{
@@ -161,18 +197,20 @@ func Default() *Config {
 		},
 		Keybindings: KeybindingsConfig{
 			Submit:       []string{"enter"},
+			Newline:      []string{"shift+enter", "alt+enter", "ctrl+j"},
 			Cancel:       []string{"esc"},
-			Help:         []string{"ctrl+h", "?", "f1"},
-			QuickActions: []string{"ctrl+k"},
-			NextTab:      []string{"ctrl+tab", "ctrl+n"},
-			PrevTab:      []string{"ctrl+shift+tab", "ctrl+p"},
+			Interrupt:    []string{"esc"},
+			Help:         []string{"?", "f1"},
+			QuickActions: []string{":"},
+			NextTab:      []string{"tab", "ctrl+tab"},
+			PrevTab:      []string{"shift+tab", "ctrl+shift+tab"},
 			ScrollUp:     []string{"ctrl+u", "pgup"},
 			ScrollDown:   []string{"ctrl+d", "pgdn"},
 			ScrollTop:    []string{"g g", "home"},
 			ScrollBottom: []string{"G", "end"},
 		},
 		StatusBar: StatusBarConfig{
-			Order: []string{"model", "status", "tokens", "mode", "progress", "hints"},
+			Order: []string{"model", "status", "error", "progress", "tokens", "cost", "mode", "message", "hints"},
 		},
 		TabBar: TabBarConfig{
 			Position:   "top",
@@ -191,6 +229,9 @@ func Default() *Config {
 			Animation:       "none",
 			CloseOnEsc:      true,
 		},
+		Display: DisplayConfig{
+			InlineHeight: 12,
+		},
 		Autocomplete: AutocompleteConfig{
 			Enabled:        true,
 			MaxSuggestions: 10,
@@ -214,12 +255,13 @@ func Load(appName string) (*Config, error) {
 		return cfg, nil // No user config, return defaults
 	}
 
-	userCfg, err := loadFile(path)
+	userCfg, md, err := loadFile(path)
 	if err != nil {
 		return cfg, fmt.Errorf("loading config from %s: %w", path, err)
 	}
 
 	merge(cfg, userCfg)
+	mergeSetBools(cfg, userCfg, md)
 
 	if errs := cfg.Validate(); len(errs) > 0 {
 		return cfg, &ValidationError{Errors: errs}
@@ -232,12 +274,13 @@ func Load(appName string) (*Config, error) {
 func LoadFile(path string) (*Config, error) {
 	cfg := Default()
 
-	userCfg, err := loadFile(path)
+	userCfg, md, err := loadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
 	merge(cfg, userCfg)
+	mergeSetBools(cfg, userCfg, md)
 
 	if errs := cfg.Validate(); len(errs) > 0 {
 		return cfg, &ValidationError{Errors: errs}
@@ -257,12 +300,7 @@ func findConfigFile(appName string) string {
 	}
 
 	// Check XDG config
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		home, _ := os.UserHomeDir()
-		configDir = filepath.Join(home, ".config")
-	}
-	xdgPath := filepath.Join(configDir, appName, "ui.toml")
+	xdgPath := filepath.Join(Dir(appName), "ui.toml")
 	if _, err := os.Stat(xdgPath); err == nil {
 		return xdgPath
 	}
@@ -277,13 +315,27 @@ func findConfigFile(appName string) string {
 	return ""
 }
 
-// loadFile loads config from a TOML file.
-func loadFile(path string) (*Config, error) {
+// Dir returns the XDG config directory for the given app
+// ($XDG_CONFIG_HOME/appname, falling back to ~/.config/appname).
+// The directory is not created.
+func Dir(appName string) string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, _ := os.UserHomeDir()
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, appName)
+}
+
+// loadFile loads config from a TOML file, with the metadata telling which
+// keys it sets.
+func loadFile(path string) (*Config, toml.MetaData, error) {
 	var cfg Config
-	if _, err := toml.DecodeFile(path, &cfg); err != nil {
-		return nil, err
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return nil, md, err
 	}
-	return &cfg, nil
+	return &cfg, md, nil
 }
 
 // Path returns the path to the config file for the given app, or empty if none exists.
@@ -276,6 +276,22 @@ func TestValidateConfig(t *testing.T) {
 		t.Error("expected validation error for invalid backdrop opacity")
 	}
 
+	// Negative inline height
+	cfg = Default()
+	cfg.Display.InlineHeight = -1
+	errs = cfg.Validate()
+	if len(errs) == 0 {
+		t.Error("expected validation error for negative inline height")
+	}
+
+	// Negative autocomplete delay
+	cfg = Default()
+	cfg.Autocomplete.DelayMs = -10
+	errs = cfg.Validate()
+	if len(errs) == 0 {
+		t.Error("expected validation error for negative autocomplete delay")
+	}
+
 	// Invalid keybinding
 	cfg = Default()
 	cfg.Keybindings.Help = []string{"ctrl++"}
@@ -284,6 +300,14 @@ func TestValidateConfig(t *testing.T) {
 		t.Error("expected validation error for invalid keybinding")
 	}
 
+	// Invalid newline keybinding
+	cfg = Default()
+	cfg.Keybindings.Newline = []string{"shift+"}
+	errs = cfg.Validate()
+	if len(errs) == 0 {
+		t.Error("expected validation error for invalid newline keybinding")
+	}
+
 	// Invalid custom keybinding
 	cfg = Default()
 	cfg.Keybindings.Custom = map[string][]string{
@@ -293,6 +317,118 @@ func TestValidateConfig(t *testing.T) {
 	if len(errs) == 0 {
 		t.Error("expected validation error for invalid custom keybinding")
 	}
+
+	// Negative model price
+	cfg = Default()
+	cfg.Models = map[string]ModelConfig{"opus": {InputPrice: -1}}
+	errs = cfg.Validate()
+	if len(errs) == 0 {
+		t.Error("expected validation error for negative model price")
+	}
+}
+
+func TestLoadFileAutocompleteEnabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ui.toml")
+
+	if err := os.WriteFile(path, []byte("[autocomplete]\ndelay_ms = 200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Autocomplete.Enabled || cfg.Autocomplete.DelayMs != 200 {
+		t.Errorf("expected autocomplete left enabled, got %+v", cfg.Autocomplete)
+	}
+
+	if err := os.WriteFile(path, []byte("[autocomplete]\nenabled = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Autocomplete.Enabled {
+		t.Error("expected enabled = false to turn autocomplete off")
+	}
+}
+
+func TestLoadFileMouseOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ui.toml")
+	content := "[mouse]\nenabled = false\nhover_enabled = false\nshift_passthrough = false\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MouseConfig{ScrollLines: Default().Mouse.ScrollLines}
+	if cfg.Mouse != want {
+		t.Errorf("expected the mouse turned off, got %+v", cfg.Mouse)
+	}
+}
+
+func TestLoadFileBackdropOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ui.toml")
+	if err := os.WriteFile(path, []byte("[modal]\nbackdrop = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Default().Modal.Backdrop || cfg.Modal.Backdrop {
+		t.Errorf("expected backdrop = false to turn the backdrop off, got %+v", cfg.Modal)
+	}
+}
+
+func TestLoadFileModels(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ui.toml")
+
+	content := `
+[models."claude-sonnet"]
+context_window = 200000
+input_price = 3
+output_price = 15
+cache_read_price = 0.3
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, ok := cfg.Models["claude-sonnet"]
+	if !ok {
+		t.Fatalf("expected claude-sonnet model, got %v", cfg.Models)
+	}
+	want := ModelConfig{ContextWindow: 200000, InputPrice: 3, OutputPrice: 15, CacheReadPrice: 0.3}
+	if m != want {
+		t.Errorf("expected %+v, got %+v", want, m)
+	}
+}
+
+func TestModelConfigCost(t *testing.T) {
+	m := ModelConfig{InputPrice: 3, OutputPrice: 15, CacheReadPrice: 0.3}
+
+	// 1M input tokens of which 500k cached, plus 100k output
+	got := m.Cost(1_000_000, 100_000, 500_000)
+	want := 1.5 + 0.15 + 1.5
+	if diff := got - want; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected cost %v, got %v", want, got)
+	}
+
+	// Without a cache price, cached tokens cost the input price
+	m.CacheReadPrice = 0
+	if got := m.Cost(1_000_000, 0, 500_000); got != 3 {
+		t.Errorf("expected cost 3, got %v", got)
+	}
 }
 
 func TestValidationError(t *testing.T) {
@@ -628,6 +764,19 @@ func TestMergeAccessibility(t *testing.T) {
 	// Just verify it doesn't panic - bool merging is tricky
 }
 
+func TestMergeDisplay(t *testing.T) {
+	base := Default()
+	merge(base, &Config{})
+	if base.Display.Inline || base.Display.InlineHeight != 12 {
+		t.Errorf("expected display defaults kept, got %+v", base.Display)
+	}
+
+	merge(base, &Config{Display: DisplayConfig{Inline: true, InlineHeight: 20}})
+	if !base.Display.Inline || base.Display.InlineHeight != 20 {
+		t.Errorf("expected inline with height 20, got %+v", base.Display)
+	}
+}
+
 func TestFindConfigFileXDG(t *testing.T) {
 	dir := t.TempDir()
 
@@ -935,3 +1084,16 @@ func TestLoadViaEnvVarPath(t *testing.T) {
 		t.Errorf("expected theme gruvbox, got %s", cfg.Theme.Name)
 	}
 }
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if got := Dir("myapp"); got != filepath.Join("/tmp/xdg", "myapp") {
+		t.Errorf("expected /tmp/xdg/myapp, got %s", got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home, _ := os.UserHomeDir()
+	if got := Dir("myapp"); got != filepath.Join(home, ".config", "myapp") {
+		t.Errorf("expected ~/.config/myapp, got %s", got)
+	}
+}
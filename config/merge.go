@@ -1,5 +1,7 @@
 package config
 
+import "github.com/BurntSushi/toml"
+
 // merge merges user config into base config.
 // Only non-zero values from user config override base values.
 func merge(base, user *Config) {
@@ -10,8 +12,24 @@ func merge(base, user *Config) {
 	mergeTabBar(&base.TabBar, &user.TabBar)
 	mergeInput(&base.Input, &user.Input)
 	mergeModal(&base.Modal, &user.Modal)
+	mergeDisplay(&base.Display, &user.Display)
 	mergeAutocomplete(&base.Autocomplete, &user.Autocomplete)
 	mergeAccessibility(&base.Accessibility, &user.Accessibility)
+	mergeModels(base, user)
+}
+
+// mergeModels adds the user's models, replacing app-defined entries of the
+// same name.
+func mergeModels(base, user *Config) {
+	if len(user.Models) == 0 {
+		return
+	}
+	if base.Models == nil {
+		base.Models = make(map[string]ModelConfig)
+	}
+	for name, m := range user.Models {
+		base.Models[name] = m
+	}
 }
 
 func mergeTheme(base, user *ThemeConfig) {
@@ -78,10 +96,7 @@ func mergeColors(base, user *ColorsConfig) {
 }
 
 func mergeMouse(base, user *MouseConfig) {
-	// For bools, we check if user explicitly set them by using a different approach
-	// Since TOML decodes missing bools as false, we can't distinguish
-	// We'll just always override if user config was loaded
-	// This is a limitation - in practice, users specify what they want to change
+	// Bools are merged by mergeSetBools
 	if user.ScrollLines != 0 {
 		base.ScrollLines = user.ScrollLines
 	}
@@ -91,9 +106,15 @@ func mergeKeybindings(base, user *KeybindingsConfig) {
 	if len(user.Submit) > 0 {
 		base.Submit = user.Submit
 	}
+	if len(user.Newline) > 0 {
+		base.Newline = user.Newline
+	}
 	if len(user.Cancel) > 0 {
 		base.Cancel = user.Cancel
 	}
+	if len(user.Interrupt) > 0 {
+		base.Interrupt = user.Interrupt
+	}
 	if len(user.Help) > 0 {
 		base.Help = user.Help
 	}
@@ -169,6 +190,13 @@ func mergeInput(base, user *InputConfig) {
 	if user.Placeholder != "" {
 		base.Placeholder = user.Placeholder
 	}
+	// Both default to off, so a user's true always wins
+	if user.Multiline {
+		base.Multiline = true
+	}
+	if user.ShowCharCount {
+		base.ShowCharCount = true
+	}
 	if user.MaxHeight != 0 {
 		base.MaxHeight = user.MaxHeight
 	}
@@ -186,6 +214,35 @@ func mergeModal(base, user *ModalConfig) {
 	}
 }
 
+func mergeDisplay(base, user *DisplayConfig) {
+	if user.Inline {
+		base.Inline = true
+	}
+	if user.InlineHeight != 0 {
+		base.InlineHeight = user.InlineHeight
+	}
+}
+
+// mergeSetBools copies the bools the user's file sets. merge cannot tell
+// false from unset, so keys that turn a default off are handled here.
+func mergeSetBools(base, user *Config, md toml.MetaData) {
+	if md.IsDefined("autocomplete", "enabled") {
+		base.Autocomplete.Enabled = user.Autocomplete.Enabled
+	}
+	if md.IsDefined("mouse", "enabled") {
+		base.Mouse.Enabled = user.Mouse.Enabled
+	}
+	if md.IsDefined("mouse", "hover_enabled") {
+		base.Mouse.HoverEnabled = user.Mouse.HoverEnabled
+	}
+	if md.IsDefined("mouse", "shift_passthrough") {
+		base.Mouse.ShiftPassthrough = user.Mouse.ShiftPassthrough
+	}
+	if md.IsDefined("modal", "backdrop") {
+		base.Modal.Backdrop = user.Modal.Backdrop
+	}
+}
+
 func mergeAutocomplete(base, user *AutocompleteConfig) {
 	if user.MaxSuggestions != 0 {
 		base.MaxSuggestions = user.MaxSuggestions
@@ -24,6 +24,9 @@ func (c *Config) Validate() []string {
 	errs = append(errs, c.validateKeybindings()...)
 	errs = append(errs, c.validateTabBar()...)
 	errs = append(errs, c.validateModal()...)
+	errs = append(errs, c.validateDisplay()...)
+	errs = append(errs, c.validateAutocomplete()...)
+	errs = append(errs, c.validateModels()...)
 
 	return errs
 }
@@ -84,7 +87,9 @@ func (c *Config) validateKeybindings() []string {
 
 	bindings := map[string][]string{
 		"keybindings.submit":        c.Keybindings.Submit,
+		"keybindings.newline":       c.Keybindings.Newline,
 		"keybindings.cancel":        c.Keybindings.Cancel,
+		"keybindings.interrupt":     c.Keybindings.Interrupt,
 		"keybindings.help":          c.Keybindings.Help,
 		"keybindings.quick_actions": c.Keybindings.QuickActions,
 		"keybindings.next_tab":      c.Keybindings.NextTab,
@@ -176,6 +181,21 @@ func isValidKeybinding(s string) bool {
 	return false
 }
 
+func (c *Config) validateModels() []string {
+	var errs []string
+
+	for name, m := range c.Models {
+		if m.ContextWindow < 0 {
+			errs = append(errs, fmt.Sprintf("models.%s.context_window: %d must not be negative", name, m.ContextWindow))
+		}
+		if m.InputPrice < 0 || m.OutputPrice < 0 || m.CacheReadPrice < 0 {
+			errs = append(errs, fmt.Sprintf("models.%s: prices must not be negative", name))
+		}
+	}
+
+	return errs
+}
+
 func (c *Config) validateTabBar() []string {
 	var errs []string
 
@@ -206,3 +226,25 @@ func (c *Config) validateModal() []string {
 
 	return errs
 }
+
+func (c *Config) validateDisplay() []string {
+	if c.Display.InlineHeight < 0 {
+		return []string{fmt.Sprintf("display.inline_height: %d is not valid (must not be negative)", c.Display.InlineHeight)}
+	}
+	return nil
+}
+
+func (c *Config) validateAutocomplete() []string {
+	var errs []string
+	a := c.Autocomplete
+	if a.MaxSuggestions < 0 {
+		errs = append(errs, fmt.Sprintf("autocomplete.max_suggestions: %d is not valid (must not be negative)", a.MaxSuggestions))
+	}
+	if a.MinChars < 0 {
+		errs = append(errs, fmt.Sprintf("autocomplete.min_chars: %d is not valid (must not be negative)", a.MinChars))
+	}
+	if a.DelayMs < 0 {
+		errs = append(errs, fmt.Sprintf("autocomplete.delay_ms: %d is not valid (must not be negative)", a.DelayMs))
+	}
+	return errs
+}
@@ -0,0 +1,55 @@
+// conformance_test.go
+package tux_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux"
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/tuxtest"
+)
+
+// slowBackend streams a fixed reply a word at a time until cancelled.
+type slowBackend struct{}
+
+func (slowBackend) Stream(ctx context.Context, messages []agent.Message) (<-chan agent.Event, error) {
+	ch := make(chan agent.Event)
+	go func() {
+		defer close(ch)
+		for _, word := range []string{"one ", "two ", "three"} {
+			select {
+			case ch <- agent.NewTextEvent(word):
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-time.After(5 * time.Millisecond):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (slowBackend) ExecuteTool(ctx context.Context, tool agent.ToolUse) (agent.ToolResult, error) {
+	return agent.ToolResult{ToolUseID: tool.ID}, nil
+}
+
+func (slowBackend) ApprovalRequests() <-chan agent.ApprovalRequest { return nil }
+
+func (slowBackend) RespondToApproval(string, agent.ApprovalDecision) error { return nil }
+
+func (slowBackend) DescribeTool(name string) agent.ToolDescription {
+	return agent.ToolDescription{Name: name}
+}
+
+func (slowBackend) Cancel() {}
+
+func TestBackendAgentConformance(t *testing.T) {
+	tuxtest.AgentConformance(t, func() tux.Agent {
+		return tux.NewBackendAgent(slowBackend{}, nil)
+	})
+}
@@ -0,0 +1,704 @@
+package content
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DiffOp is the kind of a line in a diff.
+type DiffOp int
+
+const (
+	// DiffContext is a line present in both versions.
+	DiffContext DiffOp = iota
+	// DiffAdded is a line only in the new version.
+	DiffAdded
+	// DiffRemoved is a line only in the old version.
+	DiffRemoved
+)
+
+// DiffLine is one line of a diff hunk.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+	Old  int // Line number in the old version; 0 for added lines
+	New  int // Line number in the new version; 0 for removed lines
+}
+
+// DiffHunk is a run of changed lines with the context around them.
+type DiffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []DiffLine
+}
+
+// Header returns the hunk's unified diff header, e.g. "@@ -1,3 +1,4 @@".
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// DiffFile is the change to one file.
+type DiffFile struct {
+	Name     string
+	Hunks    []DiffHunk
+	TooLarge bool // Whether the change was over MaxDiffLines and not diffed
+}
+
+// Stats returns the number of added and removed lines.
+func (f DiffFile) Stats() (added, removed int) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			switch l.Op {
+			case DiffAdded:
+				added++
+			case DiffRemoved:
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// DefaultDiffContext is the number of unchanged lines kept around changes.
+const DefaultDiffContext = 3
+
+// MaxDiffLines is the most lines, old and new together, DiffChange
+// compares.
+const MaxDiffLines = 5000
+
+// DiffChange returns the change from old to new to a file, or a file
+// marked TooLarge if they have more than MaxDiffLines lines.
+func DiffChange(name, old, new string) DiffFile {
+	if strings.Count(old, "\n")+strings.Count(new, "\n") > MaxDiffLines {
+		return DiffFile{Name: name, TooLarge: true}
+	}
+	return DiffFile{Name: name, Hunks: ComputeDiff(old, new, DefaultDiffContext)}
+}
+
+// ComputeDiff returns the line changes between old and new as hunks with
+// context unchanged lines around each change.
+func ComputeDiff(old, new string, context int) []DiffHunk {
+	return groupHunks(diffLines(splitLines(old), splitLines(new)), context)
+}
+
+// splitLines splits text into lines, ignoring a final newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns a shortest edit script from a to b as diff lines, using
+// the linear space variant of Myers' algorithm.
+func diffLines(a, b []string) []DiffLine {
+	size := len(a) + len(b) + 3
+	d := &differ{a: a, b: b, forward: make([]int, size), backward: make([]int, size)}
+	d.compare(0, len(a), 0, len(b))
+
+	oldN, newN := 0, 0
+	for i := range d.lines {
+		l := &d.lines[i]
+		if l.Op != DiffAdded {
+			oldN++
+			l.Old = oldN
+		}
+		if l.Op != DiffRemoved {
+			newN++
+			l.New = newN
+		}
+	}
+	return d.lines
+}
+
+// differ holds the state of diffLines: the furthest reaching paths of the
+// forward and backward searches, by diagonal, and the lines found so far.
+type differ struct {
+	a, b              []string
+	forward, backward []int
+	lines             []DiffLine
+}
+
+// compare appends the edit script from a[a0:a1] to b[b0:b1], splitting it
+// at a middle snake until one side is empty.
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.lines = append(d.lines, DiffLine{Op: DiffContext, Text: d.a[a0]})
+		a0++
+		b0++
+	}
+	suffix := a1
+	for a1 > a0 && b1 > b0 && d.a[a1-1] == d.b[b1-1] {
+		a1--
+		b1--
+	}
+
+	switch {
+	case a0 == a1:
+		for _, text := range d.b[b0:b1] {
+			d.lines = append(d.lines, DiffLine{Op: DiffAdded, Text: text})
+		}
+	case b0 == b1:
+		for _, text := range d.a[a0:a1] {
+			d.lines = append(d.lines, DiffLine{Op: DiffRemoved, Text: text})
+		}
+	default:
+		x0, y0, x1, y1 := d.middleSnake(a0, a1, b0, b1)
+		d.compare(a0, x0, b0, y0)
+		for _, text := range d.a[x0:x1] {
+			d.lines = append(d.lines, DiffLine{Op: DiffContext, Text: text})
+		}
+		d.compare(x1, a1, y1, b1)
+	}
+
+	for _, text := range d.a[a1:suffix] {
+		d.lines = append(d.lines, DiffLine{Op: DiffContext, Text: text})
+	}
+}
+
+// middleSnake returns the start and end of the snake in the middle of a
+// shortest edit script from a[a0:a1] to b[b0:b1], searching from both ends
+// at once. Both ranges must be non-empty.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x0, y0, x1, y1 int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	offset := (n+m+1)/2 + 1
+	fv, bv := d.forward, d.backward
+	fv[offset+1], bv[offset+1] = 0, 0
+
+	for step := 0; step <= (n+m+1)/2; step++ {
+		// Forward from the top left; x counts from a0
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && fv[offset+k-1] < fv[offset+k+1]) {
+				x = fv[offset+k+1]
+			} else {
+				x = fv[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			fv[offset+k] = x
+			if kb := delta - k; odd && kb >= -(step-1) && kb <= step-1 && x+bv[offset+kb] >= n {
+				return a0 + sx, b0 + sy, a0 + x, b0 + y
+			}
+		}
+
+		// Backward from the bottom right; x counts back from a1
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && bv[offset+k-1] < bv[offset+k+1]) {
+				x = bv[offset+k+1]
+			} else {
+				x = bv[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[a1-1-x] == d.b[b1-1-y] {
+				x++
+				y++
+			}
+			bv[offset+k] = x
+			if kf := delta - k; !odd && kf >= -step && kf <= step && x+fv[offset+kf] >= n {
+				return a1 - x, b1 - y, a1 - sx, b1 - sy
+			}
+		}
+	}
+	panic("content: no middle snake")
+}
+
+// groupHunks cuts a full diff into hunks of changes, each with up to context
+// unchanged lines either side. Changes closer than that share a hunk.
+func groupHunks(lines []DiffLine, context int) []DiffHunk {
+	var hunks []DiffHunk
+	i := 0
+	for i < len(lines) {
+		// Find the next change
+		for i < len(lines) && lines[i].Op == DiffContext {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+		start := max(i-context, 0)
+
+		// Extend while the next change is within reach
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Op != DiffContext {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(lines))
+
+		hunks = append(hunks, newHunk(lines[:start], lines[start:end]))
+		i = end
+	}
+	return hunks
+}
+
+// newHunk builds a hunk from its lines, numbering it like diff -u. before
+// are the lines preceding it.
+func newHunk(before, lines []DiffLine) DiffHunk {
+	h := DiffHunk{Lines: lines}
+	for _, l := range before {
+		if l.Op != DiffAdded {
+			h.OldStart++
+		}
+		if l.Op != DiffRemoved {
+			h.NewStart++
+		}
+	}
+	for _, l := range lines {
+		if l.Op != DiffAdded {
+			h.OldLines++
+		}
+		if l.Op != DiffRemoved {
+			h.NewLines++
+		}
+	}
+	// A side with lines starts at its first; an empty side at the line it
+	// follows
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
+
+// ParseUnifiedDiff parses a unified diff, such as the output of diff -u or
+// git diff, into its files.
+func ParseUnifiedDiff(text string) ([]DiffFile, error) {
+	var files []DiffFile
+	var file *DiffFile
+	var hunk *DiffHunk
+	var oldN, newN, oldLeft, newLeft int
+
+	for i, line := range splitLines(text) {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			var l DiffLine
+			switch {
+			case strings.HasPrefix(line, "+"):
+				l = DiffLine{Op: DiffAdded, Text: line[1:], New: newN}
+				newN++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				l = DiffLine{Op: DiffRemoved, Text: line[1:], Old: oldN}
+				oldN++
+				oldLeft--
+			case strings.HasPrefix(line, " "), line == "":
+				l = DiffLine{Op: DiffContext, Text: strings.TrimPrefix(line, " "), Old: oldN, New: newN}
+				oldN++
+				newN++
+				oldLeft--
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				continue // "\ No newline at end of file"
+			default:
+				return nil, fmt.Errorf("line %d: unexpected %q in hunk", i+1, line)
+			}
+			hunk.Lines = append(hunk.Lines, l)
+			continue
+		}
+		if strings.HasPrefix(line, `\`) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			files = append(files, DiffFile{})
+			file = &files[len(files)-1]
+			hunk = nil
+		case strings.HasPrefix(line, "--- "):
+			if file == nil || len(file.Hunks) > 0 {
+				files = append(files, DiffFile{})
+				file = &files[len(files)-1]
+			}
+			if file.Name == "" {
+				file.Name = diffFileName(line[4:])
+			}
+			hunk = nil
+		case strings.HasPrefix(line, "+++ "):
+			if file == nil {
+				files = append(files, DiffFile{})
+				file = &files[len(files)-1]
+			}
+			if name := diffFileName(line[4:]); name != "" {
+				file.Name = name
+			}
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				files = append(files, DiffFile{})
+				file = &files[len(files)-1]
+			}
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			file.Hunks = append(file.Hunks, h)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldN, newN = h.OldStart, h.NewStart
+			oldLeft, newLeft = h.OldLines, h.NewLines
+		}
+	}
+	return files, nil
+}
+
+// diffFileName returns the path in a ---/+++ line, without git's a/ and b/
+// prefixes. /dev/null, for a created or deleted file, yields "".
+func diffFileName(s string) string {
+	name, _, _ := strings.Cut(s, "\t")
+	name = strings.TrimSpace(name)
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		name = name[2:]
+	}
+	return name
+}
+
+// parseHunkHeader parses "@@ -l,s +l,s @@ ...".
+func parseHunkHeader(line string) (DiffHunk, error) {
+	var h DiffHunk
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return h, fmt.Errorf("bad hunk header %q", line)
+	}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(fields[1][1:]); err != nil {
+		return h, fmt.Errorf("bad hunk header %q", line)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(fields[2][1:]); err != nil {
+		return h, fmt.Errorf("bad hunk header %q", line)
+	}
+	return h, nil
+}
+
+// parseRange parses "start,count" or "start", where count defaults to 1.
+func parseRange(s string) (start, count int, err error) {
+	startText, countText, ok := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startText); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if ok {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// DiffLayout selects how a Diff is laid out.
+type DiffLayout int
+
+const (
+	// DiffAuto shows the versions side by side when there is room.
+	DiffAuto DiffLayout = iota
+	// DiffUnified interleaves removed and added lines.
+	DiffUnified
+	// DiffSplit shows the old version on the left and the new on the right.
+	DiffSplit
+)
+
+// SplitMinWidth is the narrowest width at which DiffAuto goes side by side.
+const SplitMinWidth = 120
+
+// Diff is a scrollable, coloured view of file changes.
+type Diff struct {
+	files    []DiffFile
+	layout   DiffLayout
+	viewport *Viewport
+	width    int
+	height   int
+
+	// Styles
+	addedStyle   lipgloss.Style
+	removedStyle lipgloss.Style
+	numberStyle  lipgloss.Style
+	hunkStyle    lipgloss.Style
+	fileStyle    lipgloss.Style
+}
+
+// NewDiff creates an empty diff view coloured by th. Added lines use the
+// theme's Success colour and removed lines its Error colour.
+func NewDiff(th theme.Theme) *Diff {
+	if th == nil {
+		th = theme.NewDraculaTheme()
+	}
+	styles := th.Styles()
+	return &Diff{
+		viewport:     NewViewport(),
+		addedStyle:   styles.Success,
+		removedStyle: styles.Error,
+		numberStyle:  styles.Muted,
+		hunkStyle:    styles.Info,
+		fileStyle:    styles.Emphasized,
+	}
+}
+
+// Init implements Content.
+func (d *Diff) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements Content. "s" switches between unified and side by side;
+// other keys and the mouse wheel scroll.
+func (d *Diff) Update(msg tea.Msg) (Content, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "s" {
+		d.ToggleLayout()
+		return d, nil
+	}
+	_, cmd := d.viewport.Update(msg)
+	return d, cmd
+}
+
+// View implements Content.
+func (d *Diff) View() string {
+	return d.viewport.View()
+}
+
+// Value implements Content. Returns nil as a diff doesn't produce values.
+func (d *Diff) Value() any {
+	return nil
+}
+
+// SetSize implements Content.
+func (d *Diff) SetSize(width, height int) {
+	if width == d.width && height == d.height {
+		return
+	}
+	d.width = width
+	d.height = height
+	d.viewport.SetSize(width, height)
+	d.render()
+}
+
+// SetScrollLines sets how many lines a mouse wheel step scrolls.
+func (d *Diff) SetScrollLines(lines int) {
+	d.viewport.SetScrollLines(lines)
+}
+
+// SetChange shows the change from old to new content of the named file.
+func (d *Diff) SetChange(name, old, new string) {
+	d.SetFiles([]DiffFile{DiffChange(name, old, new)})
+}
+
+// SetUnified shows a unified diff.
+func (d *Diff) SetUnified(text string) error {
+	files, err := ParseUnifiedDiff(text)
+	if err != nil {
+		return err
+	}
+	d.SetFiles(files)
+	return nil
+}
+
+// SetFiles shows the given file changes.
+func (d *Diff) SetFiles(files []DiffFile) {
+	d.files = files
+	d.render()
+	d.viewport.ScrollToTop()
+}
+
+// Files returns the file changes shown.
+func (d *Diff) Files() []DiffFile {
+	return d.files
+}
+
+// Empty reports whether there are no changes to show.
+func (d *Diff) Empty() bool {
+	for _, f := range d.files {
+		if len(f.Hunks) > 0 || f.TooLarge {
+			return false
+		}
+	}
+	return true
+}
+
+// SetLayout sets how the diff is laid out.
+func (d *Diff) SetLayout(layout DiffLayout) {
+	d.layout = layout
+	d.render()
+}
+
+// ToggleLayout switches between unified and side by side.
+func (d *Diff) ToggleLayout() {
+	if d.IsSplit() {
+		d.SetLayout(DiffUnified)
+	} else {
+		d.SetLayout(DiffSplit)
+	}
+}
+
+// IsSplit reports whether the diff is shown side by side.
+func (d *Diff) IsSplit() bool {
+	switch d.layout {
+	case DiffSplit:
+		return true
+	case DiffUnified:
+		return false
+	default:
+		return d.width >= SplitMinWidth
+	}
+}
+
+// ScrollUp scrolls up by n lines.
+func (d *Diff) ScrollUp(n int) {
+	d.viewport.ScrollUp(n)
+}
+
+// ScrollDown scrolls down by n lines.
+func (d *Diff) ScrollDown(n int) {
+	d.viewport.ScrollDown(n)
+}
+
+// LineCount returns the number of rendered lines.
+func (d *Diff) LineCount() int {
+	return d.viewport.LineCount()
+}
+
+// render lays the files out for the current size and layout.
+func (d *Diff) render() {
+	var lines []string
+	for _, f := range d.files {
+		if f.TooLarge {
+			lines = append(lines, d.fileStyle.Render(f.Name)+" "+d.numberStyle.Render("too large to preview"))
+			continue
+		}
+		if f.Name != "" {
+			added, removed := f.Stats()
+			lines = append(lines, d.fileStyle.Render(f.Name)+" "+
+				d.addedStyle.Render(fmt.Sprintf("+%d", added))+" "+
+				d.removedStyle.Render(fmt.Sprintf("-%d", removed)))
+		}
+		for _, h := range f.Hunks {
+			lines = append(lines, d.hunkStyle.Render(h.Header()))
+			if d.IsSplit() {
+				lines = append(lines, d.renderSplit(h)...)
+			} else {
+				lines = append(lines, d.renderUnified(h)...)
+			}
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, d.numberStyle.Render("No changes"))
+	}
+	d.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// numberWidth is the width of the line number gutters for the hunk.
+func numberWidth(h DiffHunk) int {
+	return len(strconv.Itoa(max(h.OldStart+h.OldLines, h.NewStart+h.NewLines)))
+}
+
+// number formats a line number for a gutter, blank for 0.
+func (d *Diff) number(n, width int) string {
+	if n == 0 {
+		return d.numberStyle.Render(strings.Repeat(" ", width))
+	}
+	return d.numberStyle.Render(fmt.Sprintf("%*d", width, n))
+}
+
+// lineStyle returns the style and sign for a line.
+func (d *Diff) lineStyle(op DiffOp) (lipgloss.Style, string) {
+	switch op {
+	case DiffAdded:
+		return d.addedStyle, "+"
+	case DiffRemoved:
+		return d.removedStyle, "-"
+	default:
+		return lipgloss.NewStyle(), " "
+	}
+}
+
+// cell renders a line's sign and text in width cells.
+func (d *Diff) cell(l DiffLine, width int) string {
+	style, sign := d.lineStyle(l.Op)
+	text := sign + " " + strings.ReplaceAll(l.Text, "\t", "    ")
+	if width > 0 {
+		text = ansi.Truncate(text, width, "…")
+	}
+	return style.Render(text)
+}
+
+// renderUnified renders a hunk with both line numbers in the gutter.
+func (d *Diff) renderUnified(h DiffHunk) []string {
+	nw := numberWidth(h)
+	textWidth := 0
+	if d.width > 0 {
+		textWidth = max(d.width-2*nw-2, 1)
+	}
+	lines := make([]string, len(h.Lines))
+	for i, l := range h.Lines {
+		lines[i] = d.number(l.Old, nw) + " " + d.number(l.New, nw) + " " + d.cell(l, textWidth)
+	}
+	return lines
+}
+
+// renderSplit renders a hunk with the old version on the left and the new
+// on the right, pairing removed lines with the added lines replacing them.
+func (d *Diff) renderSplit(h DiffHunk) []string {
+	nw := numberWidth(h)
+	half := max((d.width-1)/2, nw+3)
+	textWidth := half - nw - 1
+
+	side := func(l *DiffLine, n int) string {
+		if l == nil {
+			return strings.Repeat(" ", half)
+		}
+		text := d.number(n, nw) + " " + d.cell(*l, textWidth)
+		if pad := half - ansi.StringWidth(text); pad > 0 {
+			text += strings.Repeat(" ", pad)
+		}
+		return text
+	}
+
+	var lines []string
+	for i := 0; i < len(h.Lines); {
+		if h.Lines[i].Op == DiffContext {
+			l := h.Lines[i]
+			lines = append(lines, side(&l, l.Old)+" "+side(&l, l.New))
+			i++
+			continue
+		}
+
+		// A block of removals followed by the additions replacing them
+		var removed, added []DiffLine
+		for ; i < len(h.Lines) && h.Lines[i].Op == DiffRemoved; i++ {
+			removed = append(removed, h.Lines[i])
+		}
+		for ; i < len(h.Lines) && h.Lines[i].Op == DiffAdded; i++ {
+			added = append(added, h.Lines[i])
+		}
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			var left, right *DiffLine
+			var oldN, newN int
+			if j < len(removed) {
+				left, oldN = &removed[j], removed[j].Old
+			}
+			if j < len(added) {
+				right, newN = &added[j], added[j].New
+			}
+			lines = append(lines, side(left, oldN)+" "+side(right, newN))
+		}
+	}
+	return lines
+}
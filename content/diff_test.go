@@ -0,0 +1,232 @@
+package content
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// unified renders hunks as diff -u does, without file headers.
+func unified(hunks []DiffHunk) string {
+	var b strings.Builder
+	for _, h := range hunks {
+		b.WriteString(h.Header() + "\n")
+		for _, l := range h.Lines {
+			_, sign := (&Diff{}).lineStyle(l.Op)
+			b.WriteString(sign + l.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestComputeDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	got := unified(ComputeDiff(old, new, 1))
+	want := "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -10,1 +10,2 @@\n j\n+k\n"
+	if got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Nearby changes share a hunk
+	if hunks := ComputeDiff(old, new, 5); len(hunks) != 1 {
+		t.Errorf("expected one hunk with wide context, got %d", len(hunks))
+	}
+}
+
+func TestComputeDiffEdges(t *testing.T) {
+	if hunks := ComputeDiff("same\n", "same\n", 3); len(hunks) != 0 {
+		t.Errorf("expected no hunks for equal text, got %+v", hunks)
+	}
+
+	got := unified(ComputeDiff("", "one\ntwo\n", 3))
+	if got != "@@ -0,0 +1,2 @@\n+one\n+two\n" {
+		t.Errorf("unexpected diff for a new file:\n%s", got)
+	}
+
+	// Insertion without context starts after the line it follows
+	got = unified(ComputeDiff("a\nb\n", "a\nx\nb\n", 0))
+	if got != "@@ -1,0 +2,1 @@\n+x\n" {
+		t.Errorf("unexpected insertion hunk:\n%s", got)
+	}
+}
+
+func TestComputeDiffIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return lines
+	}
+
+	for range 500 {
+		a, b := random(), random()
+		var gotA, gotB []string
+		edits := 0
+		for _, l := range diffLines(a, b) {
+			if l.Op != DiffAdded {
+				gotA = append(gotA, l.Text)
+			}
+			if l.Op != DiffRemoved {
+				gotB = append(gotB, l.Text)
+			}
+			if l.Op != DiffContext {
+				edits++
+			}
+		}
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("diff of %q and %q does not rebuild them", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); edits != want {
+			t.Fatalf("diff of %q and %q has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestComputeDiffLargeRewrite(t *testing.T) {
+	var old, new strings.Builder
+	for i := range MaxDiffLines / 2 {
+		fmt.Fprintf(&old, "old %d\n", i)
+		fmt.Fprintf(&new, "new %d\n", i)
+	}
+
+	start := time.Now()
+	hunks := ComputeDiff(old.String(), new.String(), DefaultDiffContext)
+	if len(hunks) != 1 || len(hunks[0].Lines) != MaxDiffLines {
+		t.Fatalf("expected one hunk replacing every line, got %d hunks", len(hunks))
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the largest allowed diff to be quick, took %v", elapsed)
+	}
+
+	// Over the limit the change is not diffed
+	old.WriteString("one more\n")
+	f := DiffChange("big.txt", old.String(), new.String())
+	if !f.TooLarge || f.Hunks != nil {
+		t.Fatalf("expected the change marked too large, got %d hunks", len(f.Hunks))
+	}
+	d := NewDiff(nil)
+	d.SetSize(80, 10)
+	d.SetFiles([]DiffFile{f})
+	if d.Empty() || !strings.Contains(ansi.Strip(d.View()), "big.txt too large to preview") {
+		t.Errorf("expected a too large notice:\n%s", ansi.Strip(d.View()))
+	}
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	text := `diff --git a/main.go b/main.go
+index 1234..5678 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+ 
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+\ No newline at end of file
+`
+	files, err := ParseUnifiedDiff(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "main.go" || files[1].Name != "new.txt" {
+		t.Fatalf("expected main.go and new.txt, got %+v", files)
+	}
+
+	h := files[0].Hunks[0]
+	if len(h.Lines) != 4 || h.Lines[1].Op != DiffRemoved || h.Lines[1].Old != 2 || h.Lines[2].New != 2 {
+		t.Errorf("unexpected hunk: %+v", h)
+	}
+	if added, removed := files[0].Stats(); added != 1 || removed != 1 {
+		t.Errorf("expected +1 -1, got +%d -%d", added, removed)
+	}
+	if l := files[1].Hunks[0].Lines; len(l) != 1 || l[0].Text != "hello" || l[0].New != 1 {
+		t.Errorf("unexpected new file lines: %+v", l)
+	}
+
+	if _, err := ParseUnifiedDiff("@@ -x +1 @@\n"); err == nil {
+		t.Error("expected error for a bad hunk header")
+	}
+}
+
+func TestDiffView(t *testing.T) {
+	d := NewDiff(theme.NewDraculaTheme())
+	d.SetSize(80, 20)
+	d.SetChange("greet.go", "hello\nworld\n", "hello\nthere\n")
+
+	view := ansi.Strip(d.View())
+	for _, want := range []string{"greet.go +1 -1", "@@ -1,2 +1,2 @@", "1 1   hello", "2   - world", "  2 + there"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in unified view:\n%s", want, view)
+		}
+	}
+	if d.IsSplit() {
+		t.Error("expected unified layout when narrow")
+	}
+
+	// Side by side pairs the removed line with its replacement
+	d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if !d.IsSplit() {
+		t.Fatal("expected s to switch to side by side")
+	}
+	var row string
+	for _, line := range strings.Split(ansi.Strip(d.View()), "\n") {
+		if strings.Contains(line, "world") {
+			row = line
+		}
+	}
+	if !strings.Contains(row, "there") || strings.Index(row, "world") > strings.Index(row, "there") {
+		t.Errorf("expected old and new on one row: %q", row)
+	}
+	for _, line := range strings.Split(d.View(), "\n") {
+		if w := ansi.StringWidth(line); w > 80 {
+			t.Errorf("line wider than the view (%d): %q", w, ansi.Strip(line))
+		}
+	}
+}
+
+func TestDiffAutoLayout(t *testing.T) {
+	d := NewDiff(nil)
+	d.SetChange("f", "a\n", "b\n")
+
+	d.SetSize(SplitMinWidth, 10)
+	if !d.IsSplit() {
+		t.Error("expected side by side on a wide view")
+	}
+	d.SetLayout(DiffUnified)
+	if d.IsSplit() {
+		t.Error("expected explicit unified layout to win")
+	}
+}
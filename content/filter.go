@@ -0,0 +1,124 @@
+package content
+
+import (
+	"sort"
+
+	"github.com/2389-research/tux/fuzzy"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listFilter is the inline filter of SelectList and MultiSelect: "/"
+// starts typing a query, Enter keeps it and Esc clears it. Items are
+// matched fuzzily by label.
+type listFilter struct {
+	query     string
+	editing   bool    // Whether keys are typed into the query
+	visible   []int   // Indexes of the items shown, best match first
+	positions [][]int // Runes of each item's label matching the query
+}
+
+// apply matches the query against labels.
+func (f *listFilter) apply(labels []string) {
+	f.visible = f.visible[:0]
+	f.positions = make([][]int, len(labels))
+	scores := make([]int, len(labels))
+	for i, label := range labels {
+		r, ok := fuzzy.Match(f.query, label)
+		if !ok {
+			continue
+		}
+		f.visible = append(f.visible, i)
+		f.positions[i], scores[i] = r.Positions, r.Score
+	}
+	sort.SliceStable(f.visible, func(a, b int) bool {
+		return scores[f.visible[a]] > scores[f.visible[b]]
+	})
+}
+
+// handleKey edits the filter. It reports whether the key was used and
+// whether the query changed.
+func (f *listFilter) handleKey(msg tea.KeyMsg) (handled, changed bool) {
+	if !f.editing {
+		switch {
+		case msg.String() == "/":
+			f.editing = true
+			return true, false
+		case msg.Type == tea.KeyEsc && f.query != "":
+			f.query = ""
+			return true, true
+		}
+		return false, false
+	}
+
+	switch msg.Type {
+	case tea.KeyRunes:
+		f.query += string(msg.Runes)
+		return true, true
+	case tea.KeySpace:
+		f.query += " "
+		return true, true
+	case tea.KeyBackspace:
+		if r := []rune(f.query); len(r) > 0 {
+			f.query = string(r[:len(r)-1])
+			return true, true
+		}
+		return true, false
+	case tea.KeyEnter:
+		f.editing = false
+		return true, false
+	case tea.KeyEsc:
+		f.editing = false
+		changed = f.query != ""
+		f.query = ""
+		return true, changed
+	}
+	return false, false
+}
+
+// shown reports whether the filter line is drawn above the items.
+func (f *listFilter) shown() bool {
+	return f.editing || f.query != ""
+}
+
+// view renders the filter line.
+func (f *listFilter) view(style lipgloss.Style) string {
+	line := "/" + f.query
+	if f.editing {
+		line += "▏"
+	}
+	return style.Render(line)
+}
+
+// move returns the item index delta visible items away from current,
+// staying within the visible items.
+func (f *listFilter) move(current, delta int) int {
+	if len(f.visible) == 0 {
+		return current
+	}
+	at := 0
+	for k, i := range f.visible {
+		if i == current {
+			at = k
+			break
+		}
+	}
+	at = max(0, min(at+delta, len(f.visible)-1))
+	return f.visible[at]
+}
+
+// first returns the best match, or current when nothing matches.
+func (f *listFilter) first(current int) int {
+	if len(f.visible) == 0 {
+		return current
+	}
+	return f.visible[0]
+}
+
+// last returns the worst match, or current when nothing matches.
+func (f *listFilter) last(current int) int {
+	if len(f.visible) == 0 {
+		return current
+	}
+	return f.visible[len(f.visible)-1]
+}
@@ -1,8 +1,11 @@
 package content
 
 import (
+	"slices"
 	"strings"
 
+	"github.com/2389-research/tux/fuzzy"
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,23 +17,28 @@ type MultiSelectItem struct {
 	Selected bool
 }
 
-// MultiSelect is a multiple-selection list content primitive.
+// MultiSelect is a multiple-selection list content primitive. Typing "/"
+// filters the items fuzzily by label, as in SelectList; once Enter keeps
+// the query, space toggles the matches as usual.
 type MultiSelect struct {
 	items  []MultiSelectItem
 	cursor int
 	width  int
 	height int
+	filter listFilter
 
 	// Styles
 	cursorStyle     lipgloss.Style
 	selectedStyle   lipgloss.Style
 	unselectedStyle lipgloss.Style
 	checkStyle      lipgloss.Style
+	mutedStyle      lipgloss.Style
+	highlightStyle  lipgloss.Style
 }
 
 // NewMultiSelect creates a new multi-select list.
 func NewMultiSelect(items []MultiSelectItem) *MultiSelect {
-	return &MultiSelect{
+	m := &MultiSelect{
 		items:  items,
 		cursor: 0,
 		cursorStyle: lipgloss.NewStyle().
@@ -41,7 +49,25 @@ func NewMultiSelect(items []MultiSelectItem) *MultiSelect {
 			Foreground(lipgloss.Color("#f8f8f2")),
 		checkStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#50fa7b")),
+		mutedStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6272a4")),
+		highlightStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#bd93f9")).
+			Bold(true),
 	}
+	m.applyFilter()
+	return m
+}
+
+// SetTheme restyles the list with a theme's colours, highlighting filter
+// matches in its primary colour.
+func (m *MultiSelect) SetTheme(th theme.Theme) {
+	m.cursorStyle = lipgloss.NewStyle().Foreground(th.Primary())
+	m.selectedStyle = lipgloss.NewStyle().Foreground(th.Success())
+	m.unselectedStyle = lipgloss.NewStyle().Foreground(th.Foreground())
+	m.checkStyle = lipgloss.NewStyle().Foreground(th.Success())
+	m.mutedStyle = lipgloss.NewStyle().Foreground(th.Muted())
+	m.highlightStyle = lipgloss.NewStyle().Foreground(th.Primary()).Bold(true)
 }
 
 // Init implements Content.
@@ -55,25 +81,28 @@ func (m *MultiSelect) Update(msg tea.Msg) (Content, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyUp:
-			if m.cursor > 0 {
-				m.cursor--
-			}
+			m.cursor = m.filter.move(m.cursor, -1)
+			return m, nil
 		case tea.KeyDown:
-			if m.cursor < len(m.items)-1 {
-				m.cursor++
+			m.cursor = m.filter.move(m.cursor, 1)
+			return m, nil
+		}
+		if handled, changed := m.filter.handleKey(msg); handled {
+			if changed {
+				m.applyFilter()
 			}
-		case tea.KeySpace:
+			return m, nil
+		}
+		if msg.Type == tea.KeySpace {
+			// Its string is " " too; toggle only once
 			m.Toggle()
+			return m, nil
 		}
 		switch msg.String() {
 		case "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
+			m.cursor = m.filter.move(m.cursor, -1)
 		case "j":
-			if m.cursor < len(m.items)-1 {
-				m.cursor++
-			}
+			m.cursor = m.filter.move(m.cursor, 1)
 		case " ", "x":
 			m.Toggle()
 		case "a":
@@ -88,12 +117,21 @@ func (m *MultiSelect) Update(msg tea.Msg) (Content, tea.Cmd) {
 // View implements Content.
 func (m *MultiSelect) View() string {
 	if len(m.items) == 0 {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("#6272a4")).Render("No items")
+		return m.mutedStyle.Render("No items")
 	}
 
 	var b strings.Builder
 
-	for i, item := range m.items {
+	if m.filter.shown() {
+		b.WriteString(m.filter.view(m.mutedStyle))
+		if len(m.filter.visible) == 0 {
+			b.WriteString("\n")
+			b.WriteString(m.mutedStyle.Render("No matches"))
+		}
+	}
+
+	for k, i := range m.filter.visible {
+		item := m.items[i]
 		cursor := "  "
 		if i == m.cursor {
 			cursor = m.cursorStyle.Render("▸ ")
@@ -106,14 +144,13 @@ func (m *MultiSelect) View() string {
 			style = m.selectedStyle
 		}
 
+		if k > 0 || m.filter.shown() {
+			b.WriteString("\n")
+		}
 		b.WriteString(cursor)
 		b.WriteString(check)
 		b.WriteString(" ")
-		b.WriteString(style.Render(item.Label))
-
-		if i < len(m.items)-1 {
-			b.WriteString("\n")
-		}
+		b.WriteString(fuzzy.Highlight(item.Label, m.filter.positions[i], style, m.highlightStyle))
 	}
 
 	return b.String()
@@ -136,23 +173,24 @@ func (m *MultiSelect) SetSize(width, height int) {
 	m.height = height
 }
 
-// Toggle toggles the selection of the current item.
+// Toggle toggles the selection of the current item, unless the filter
+// hides it.
 func (m *MultiSelect) Toggle() {
-	if m.cursor >= 0 && m.cursor < len(m.items) {
+	if slices.Contains(m.filter.visible, m.cursor) {
 		m.items[m.cursor].Selected = !m.items[m.cursor].Selected
 	}
 }
 
-// SelectAll selects all items.
+// SelectAll selects all items the filter shows.
 func (m *MultiSelect) SelectAll() {
-	for i := range m.items {
+	for _, i := range m.filter.visible {
 		m.items[i].Selected = true
 	}
 }
 
-// SelectNone deselects all items.
+// SelectNone deselects all items the filter shows.
 func (m *MultiSelect) SelectNone() {
-	for i := range m.items {
+	for _, i := range m.filter.visible {
 		m.items[i].Selected = false
 	}
 }
@@ -168,7 +206,7 @@ func (m *MultiSelect) SelectedCount() int {
 	return count
 }
 
-// SetItems replaces all items.
+// SetItems replaces all items, keeping the filter.
 func (m *MultiSelect) SetItems(items []MultiSelectItem) {
 	m.items = items
 	if m.cursor >= len(items) {
@@ -177,9 +215,46 @@ func (m *MultiSelect) SetItems(items []MultiSelectItem) {
 	if m.cursor < 0 {
 		m.cursor = 0
 	}
+	m.filter.apply(m.labels())
 }
 
 // Items returns all items.
 func (m *MultiSelect) Items() []MultiSelectItem {
 	return m.items
 }
+
+// Filter returns the filter query, or "" when unfiltered.
+func (m *MultiSelect) Filter() string {
+	return m.filter.query
+}
+
+// SetFilter filters the items by query, moving the cursor to the best
+// match.
+func (m *MultiSelect) SetFilter(query string) {
+	m.filter.query = query
+	m.applyFilter()
+}
+
+// Filtering reports whether keys are being typed into the filter.
+func (m *MultiSelect) Filtering() bool {
+	return m.filter.editing
+}
+
+// applyFilter matches the filter against the items and moves the cursor
+// to the best match. Clearing the filter, or matching nothing, keeps the
+// cursor.
+func (m *MultiSelect) applyFilter() {
+	m.filter.apply(m.labels())
+	if m.filter.query != "" {
+		m.cursor = m.filter.first(m.cursor)
+	}
+}
+
+// labels returns the items' labels, which the filter matches.
+func (m *MultiSelect) labels() []string {
+	labels := make([]string, len(m.items))
+	for i, item := range m.items {
+		labels[i] = item.Label
+	}
+	return labels
+}
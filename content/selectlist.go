@@ -3,6 +3,8 @@ package content
 import (
 	"strings"
 
+	"github.com/2389-research/tux/fuzzy"
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,23 +16,27 @@ type SelectItem struct {
 	Value       any
 }
 
-// SelectList is a single-selection list content primitive.
+// SelectList is a single-selection list content primitive. Typing "/"
+// filters the items fuzzily by label: the query is typed until Enter keeps
+// it or Esc clears it, and the arrow keys move among the matches meanwhile.
 type SelectList struct {
 	items    []SelectItem
 	selected int
 	width    int
 	height   int
+	filter   listFilter
 
 	// Styles
 	cursorStyle     lipgloss.Style
 	selectedStyle   lipgloss.Style
 	unselectedStyle lipgloss.Style
 	descStyle       lipgloss.Style
+	highlightStyle  lipgloss.Style
 }
 
 // NewSelectList creates a new SelectList with the given items.
 func NewSelectList(items []SelectItem) *SelectList {
-	return &SelectList{
+	s := &SelectList{
 		items:    items,
 		selected: 0,
 		cursorStyle: lipgloss.NewStyle().
@@ -42,7 +48,22 @@ func NewSelectList(items []SelectItem) *SelectList {
 			Foreground(lipgloss.Color("#f8f8f2")),
 		descStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#6272a4")),
+		highlightStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#bd93f9")).
+			Bold(true),
 	}
+	s.applyFilter()
+	return s
+}
+
+// SetTheme restyles the list with a theme's colours, highlighting filter
+// matches in its primary colour.
+func (s *SelectList) SetTheme(th theme.Theme) {
+	s.cursorStyle = lipgloss.NewStyle().Foreground(th.Primary())
+	s.selectedStyle = lipgloss.NewStyle().Foreground(th.Success()).Bold(true)
+	s.unselectedStyle = lipgloss.NewStyle().Foreground(th.Foreground())
+	s.descStyle = lipgloss.NewStyle().Foreground(th.Muted())
+	s.highlightStyle = lipgloss.NewStyle().Foreground(th.Primary()).Bold(true)
 }
 
 // Init implements Content.
@@ -56,32 +77,78 @@ func (s *SelectList) Update(msg tea.Msg) (Content, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyUp, tea.KeyShiftTab:
-			if s.selected > 0 {
-				s.selected--
-			}
+			s.selected = s.filter.move(s.selected, -1)
+			return s, nil
 		case tea.KeyDown, tea.KeyTab:
-			if s.selected < len(s.items)-1 {
-				s.selected++
+			s.selected = s.filter.move(s.selected, 1)
+			return s, nil
+		}
+		if handled, changed := s.filter.handleKey(msg); handled {
+			if changed {
+				s.applyFilter()
 			}
+			return s, nil
 		}
 		switch msg.String() {
 		case "k":
-			if s.selected > 0 {
-				s.selected--
-			}
+			s.selected = s.filter.move(s.selected, -1)
 		case "j":
-			if s.selected < len(s.items)-1 {
-				s.selected++
-			}
+			s.selected = s.filter.move(s.selected, 1)
 		case "g":
-			s.selected = 0
+			s.selected = s.filter.first(s.selected)
 		case "G":
-			s.selected = len(s.items) - 1
+			s.selected = s.filter.last(s.selected)
 		}
+
+	case tea.MouseMsg:
+		s.handleMouse(msg)
 	}
 	return s, nil
 }
 
+// handleMouse moves the selection with the wheel and selects the item
+// under the pointer on hover or click. Coordinates are relative to the
+// list's top-left cell.
+func (s *SelectList) handleMouse(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		s.selected = s.filter.move(s.selected, -1)
+		return
+	case tea.MouseButtonWheelDown:
+		s.selected = s.filter.move(s.selected, 1)
+		return
+	}
+
+	hover := msg.Action == tea.MouseActionMotion
+	click := msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+	if !hover && !click {
+		return
+	}
+	if i := s.itemAt(msg.Y); i >= 0 {
+		s.selected = i
+	}
+}
+
+// itemAt returns the index of the item drawn on row y, or -1.
+func (s *SelectList) itemAt(y int) int {
+	row := 0
+	if s.filter.shown() {
+		row = 1
+	}
+	for _, i := range s.filter.visible {
+		item := s.items[i]
+		rows := 1
+		if item.Description != "" {
+			rows = 2
+		}
+		if y >= row && y < row+rows {
+			return i
+		}
+		row += rows
+	}
+	return -1
+}
+
 // View implements Content.
 func (s *SelectList) View() string {
 	if len(s.items) == 0 {
@@ -90,7 +157,16 @@ func (s *SelectList) View() string {
 
 	var b strings.Builder
 
-	for i, item := range s.items {
+	if s.filter.shown() {
+		b.WriteString(s.filter.view(s.descStyle))
+		if len(s.filter.visible) == 0 {
+			b.WriteString("\n")
+			b.WriteString(s.descStyle.Render("No matches"))
+		}
+	}
+
+	for k, i := range s.filter.visible {
+		item := s.items[i]
 		cursor := "  "
 		style := s.unselectedStyle
 
@@ -99,17 +175,16 @@ func (s *SelectList) View() string {
 			style = s.selectedStyle
 		}
 
+		if k > 0 || s.filter.shown() {
+			b.WriteString("\n")
+		}
 		b.WriteString(cursor)
-		b.WriteString(style.Render(item.Label))
+		b.WriteString(fuzzy.Highlight(item.Label, s.filter.positions[i], style, s.highlightStyle))
 
 		if item.Description != "" {
 			b.WriteString("\n    ")
 			b.WriteString(s.descStyle.Render(item.Description))
 		}
-
-		if i < len(s.items)-1 {
-			b.WriteString("\n")
-		}
 	}
 
 	return b.String()
@@ -149,7 +224,7 @@ func (s *SelectList) SelectedItem() *SelectItem {
 	return nil
 }
 
-// SetItems replaces all items in the list.
+// SetItems replaces all items in the list, keeping the filter.
 func (s *SelectList) SetItems(items []SelectItem) {
 	s.items = items
 	if s.selected >= len(items) {
@@ -158,9 +233,44 @@ func (s *SelectList) SetItems(items []SelectItem) {
 	if s.selected < 0 {
 		s.selected = 0
 	}
+	s.filter.apply(s.labels())
 }
 
 // Items returns all items in the list.
 func (s *SelectList) Items() []SelectItem {
 	return s.items
 }
+
+// Filter returns the filter query, or "" when unfiltered.
+func (s *SelectList) Filter() string {
+	return s.filter.query
+}
+
+// SetFilter filters the items by query, selecting the best match.
+func (s *SelectList) SetFilter(query string) {
+	s.filter.query = query
+	s.applyFilter()
+}
+
+// Filtering reports whether keys are being typed into the filter.
+func (s *SelectList) Filtering() bool {
+	return s.filter.editing
+}
+
+// applyFilter matches the filter against the items and selects the best
+// match. Clearing the filter, or matching nothing, keeps the selection.
+func (s *SelectList) applyFilter() {
+	s.filter.apply(s.labels())
+	if s.filter.query != "" {
+		s.selected = s.filter.first(s.selected)
+	}
+}
+
+// labels returns the items' labels, which the filter matches.
+func (s *SelectList) labels() []string {
+	labels := make([]string, len(s.items))
+	for i, item := range s.items {
+		labels[i] = item.Label
+	}
+	return labels
+}
@@ -1,9 +1,12 @@
 package content
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func TestSelectList(t *testing.T) {
@@ -249,3 +252,106 @@ func TestSelectListInit(t *testing.T) {
 		t.Error("Init should return nil")
 	}
 }
+
+func TestSelectListMouse(t *testing.T) {
+	list := NewSelectList([]SelectItem{
+		{Label: "A", Description: "first"},
+		{Label: "B"},
+		{Label: "C"},
+	})
+
+	// A takes two rows with its description
+	list.Update(tea.MouseMsg{Y: 2, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if list.Selected() != 1 {
+		t.Errorf("expected click on row 2 to select B, got %d", list.Selected())
+	}
+
+	list.Update(tea.MouseMsg{Y: 1, Action: tea.MouseActionMotion})
+	if list.Selected() != 0 {
+		t.Errorf("expected hover on description to select A, got %d", list.Selected())
+	}
+
+	list.Update(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	if list.Selected() != 1 {
+		t.Errorf("expected wheel to move selection, got %d", list.Selected())
+	}
+
+	list.Update(tea.MouseMsg{Y: 10, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if list.Selected() != 1 {
+		t.Error("expected click below the items to keep the selection")
+	}
+}
+
+func TestSelectListFilter(t *testing.T) {
+	list := NewSelectList([]SelectItem{
+		{Label: "Open file", Value: "open"},
+		{Label: "Save file", Value: "save"},
+		{Label: "Go to symbol", Value: "symbol", Description: "In this file"},
+	})
+	keys := func(s string) {
+		for _, r := range s {
+			list.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// j and k are typed into the filter, not used to move
+	keys("/sy")
+	if !list.Filtering() || list.Filter() != "sy" {
+		t.Fatalf("expected the filter typed, got %q (filtering %v)", list.Filter(), list.Filtering())
+	}
+	if list.Value() != "symbol" {
+		t.Errorf("expected the best match selected, got %v", list.Value())
+	}
+	if view := list.View(); !strings.Contains(view, "/sy") || strings.Contains(view, "Open file") {
+		t.Errorf("expected only matches under the filter line, got:\n%s", view)
+	}
+
+	// Arrows move among the matches while typing
+	list.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if list.Value() != "save" {
+		t.Errorf("expected the new best match selected, got %v", list.Value())
+	}
+	list.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if list.Value() != "symbol" {
+		t.Errorf("expected down to reach the next match, got %v", list.Value())
+	}
+
+	// Enter keeps the filter; clicks land on the rows below its line
+	list.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if list.Filtering() || list.Filter() != "s" {
+		t.Errorf("expected the filter kept, got %q (filtering %v)", list.Filter(), list.Filtering())
+	}
+	list.Update(tea.MouseMsg{Y: 1, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+	if list.Value() != "save" {
+		t.Errorf("expected a click on the first match, got %v", list.Value())
+	}
+
+	keys("/zzz")
+	if view := list.View(); !strings.Contains(view, "No matches") {
+		t.Errorf("expected no matches, got:\n%s", view)
+	}
+
+	// Esc clears it, keeping the selection
+	list.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if list.Filtering() || list.Filter() != "" || len(strings.Split(list.View(), "\n")) != 4 {
+		t.Errorf("expected every item shown again, got:\n%s", list.View())
+	}
+	if list.Value() != "save" {
+		t.Errorf("expected the selection kept, got %v", list.Value())
+	}
+}
+
+func TestSelectListHighlightsMatches(t *testing.T) {
+	list := NewSelectList([]SelectItem{{Label: "getConfig"}, {Label: "gadget"}})
+	list.highlightStyle = lipgloss.NewStyle().Transform(strings.ToUpper)
+	list.SetFilter("gc")
+
+	if view := list.View(); !strings.Contains(view, "GetConfig") {
+		t.Errorf("expected matched characters highlighted, got:\n%s", view)
+	}
+
+	list.SetTheme(theme.NewNordTheme())
+	if got := list.highlightStyle.GetForeground(); got != theme.NewNordTheme().Primary() {
+		t.Errorf("expected the theme's primary colour, got %v", got)
+	}
+}
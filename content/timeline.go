@@ -96,6 +96,11 @@ func (t *Timeline) Value() any {
 	return nil
 }
 
+// SetScrollLines sets how many lines a mouse wheel step scrolls.
+func (t *Timeline) SetScrollLines(lines int) {
+	t.viewport.MouseWheelDelta = lines
+}
+
 // SetSize implements Content.
 func (t *Timeline) SetSize(width, height int) {
 	t.width = width
@@ -47,6 +47,11 @@ func (v *Viewport) Value() any {
 	return nil
 }
 
+// SetScrollLines sets how many lines a mouse wheel step scrolls.
+func (v *Viewport) SetScrollLines(lines int) {
+	v.viewport.MouseWheelDelta = lines
+}
+
 // SetSize implements Content.
 func (v *Viewport) SetSize(width, height int) {
 	v.width = width
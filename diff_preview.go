@@ -0,0 +1,106 @@
+// diff_preview.go
+package tux
+
+import (
+	"os"
+
+	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/theme"
+)
+
+// Parameter names file-editing tools commonly use, in order of preference.
+var (
+	pathParams    = []string{"path", "file_path", "filename", "file"}
+	oldTextParams = []string{"old_string", "old_str", "old_text"}
+	newTextParams = []string{"new_string", "new_str", "new_text"}
+	contentParams = []string{"content", "contents", "file_text"}
+)
+
+// maxPreviewFileSize is the largest file read to preview a write.
+const maxPreviewFileSize = 1 << 20
+
+// changePreview is the file change an approval request would make, and
+// the parameters it shows.
+type changePreview struct {
+	files []content.DiffFile
+	shown []string
+}
+
+// previewChange works out the file change an approval request would make.
+// It uses the event's Diff if set, and otherwise recognises edits (old and
+// new text) and writes (a path and the new content, compared with the file
+// on disk). It returns nil if the request is not a file change. Diffing
+// large files takes a while, so events are previewed before they reach the
+// update loop.
+func previewChange(event Event) *changePreview {
+	if event.Diff != "" {
+		files, err := content.ParseUnifiedDiff(event.Diff)
+		if err != nil {
+			return nil
+		}
+		return &changePreview{files: files}
+	}
+
+	params := event.ToolParams
+	path, _ := stringParam(params, pathParams)
+	if oldText, oldKey := stringParam(params, oldTextParams); oldKey != "" {
+		if newText, newKey := stringParam(params, newTextParams); newKey != "" {
+			return &changePreview{
+				files: []content.DiffFile{content.DiffChange(path, oldText, newText)},
+				shown: []string{oldKey, newKey},
+			}
+		}
+	}
+	if newText, key := stringParam(params, contentParams); key != "" && path != "" {
+		return &changePreview{
+			files: []content.DiffFile{content.DiffChange(path, readForPreview(path), newText)},
+			shown: []string{key},
+		}
+	}
+	return nil
+}
+
+// diffPreview returns a view of the file change an approval request would
+// make, and the parameters it shows, or nil if the request is not a file
+// change. The change is the event's preview, or previewed now if it has
+// none.
+func diffPreview(event Event, th theme.Theme) (*content.Diff, []string) {
+	p := event.preview
+	if p == nil {
+		p = previewChange(event)
+	}
+	if p == nil {
+		return nil, nil
+	}
+	diff := content.NewDiff(th)
+	diff.SetFiles(p.files)
+	if diff.Empty() && event.Diff != "" {
+		return nil, nil
+	}
+	return diff, p.shown
+}
+
+// stringParam returns the first of the named parameters that is a string,
+// and its name; the name is "" if none is.
+func stringParam(params map[string]any, names []string) (string, string) {
+	for _, name := range names {
+		if s, ok := params[name].(string); ok {
+			return s, name
+		}
+	}
+	return "", ""
+}
+
+// readForPreview returns a file's current content, or "" if it does not
+// exist or is too large to preview.
+func readForPreview(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxPreviewFileSize {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
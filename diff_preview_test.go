@@ -0,0 +1,142 @@
+package tux
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDiffPreviewEdit(t *testing.T) {
+	diff, shown := diffPreview(Event{ToolParams: map[string]any{
+		"file_path":  "main.go",
+		"old_string": "x := 1",
+		"new_string": "x := 2",
+	}}, theme.NewDraculaTheme())
+	if diff == nil {
+		t.Fatal("expected a preview for an edit")
+	}
+	if len(shown) != 2 || shown[0] != "old_string" || shown[1] != "new_string" {
+		t.Errorf("expected old and new text shown by the diff, got %v", shown)
+	}
+	if f := diff.Files(); len(f) != 1 || f[0].Name != "main.go" {
+		t.Errorf("expected change to main.go, got %+v", f)
+	}
+}
+
+func TestDiffPreviewWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("keep\nold\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, _ := diffPreview(Event{ToolParams: map[string]any{"path": path, "content": "keep\nnew\n"}}, nil)
+	if diff == nil {
+		t.Fatal("expected a preview for a write")
+	}
+	if added, removed := diff.Files()[0].Stats(); added != 1 || removed != 1 {
+		t.Errorf("expected the file on disk compared, got +%d -%d", added, removed)
+	}
+
+	// A new file is all additions
+	diff, _ = diffPreview(Event{ToolParams: map[string]any{"path": path + ".new", "content": "a\nb\n"}}, nil)
+	if added, removed := diff.Files()[0].Stats(); added != 2 || removed != 0 {
+		t.Errorf("expected a new file added, got +%d -%d", added, removed)
+	}
+}
+
+func TestDiffPreviewUnifiedAndOthers(t *testing.T) {
+	diff, _ := diffPreview(Event{Diff: "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n"}, nil)
+	if diff == nil || diff.Files()[0].Name != "f" {
+		t.Error("expected the event's diff previewed")
+	}
+
+	for _, params := range []map[string]any{
+		{"command": "ls"},
+		{"content": "no path"},
+	} {
+		if diff, _ := diffPreview(Event{ToolParams: params}, nil); diff != nil {
+			t.Errorf("expected no preview for %v", params)
+		}
+	}
+}
+
+func TestApprovalShowsDiff(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	app.processEvent(Event{
+		Type:     EventApproval,
+		ToolID:   "e1",
+		ToolName: "edit",
+		ToolParams: map[string]any{
+			"file_path":  "main.go",
+			"old_string": "x := 1",
+			"new_string": "x := 2",
+		},
+		Response: make(chan ApprovalDecision, 1),
+	})
+
+	view := ansi.Strip(app.approvals.Render(96, 32))
+	for _, want := range []string{"main.go +1 -1", "- x := 1", "+ x := 2", "file_path: main.go"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in approval:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "old_string:") {
+		t.Errorf("expected diffed params left out of the list:\n%s", view)
+	}
+}
+
+func TestApprovalPreviewedBeforeUpdateLoop(t *testing.T) {
+	app := New(&mockAgent{})
+	app.shell.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	startRun(app, 1)
+	app.running = true
+
+	// The run stays open while its approval waits
+	events := make(chan Event)
+	finished := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		app.pumpEvents(app.ctx, 1, events, finished)
+		close(done)
+	}()
+
+	huge := strings.Repeat("line\n", content.MaxDiffLines)
+	events <- Event{
+		Type:       EventApproval,
+		ToolID:     "e1",
+		ToolName:   "edit",
+		ToolParams: map[string]any{"file_path": "big.go", "old_string": huge, "new_string": huge + "more\n"},
+		Response:   make(chan ApprovalDecision, 1),
+	}
+	// Shown after the approval, in the same batch
+	marker := errors.New("marker")
+	events <- Event{Type: EventError, Error: marker}
+	deadline := time.Now().Add(time.Second)
+	for !app.reported(marker) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the events delivered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if app.approvals == nil || app.approvals.events[0].preview == nil {
+		t.Fatal("expected the approval previewed by the pump")
+	}
+	view := ansi.Strip(app.approvals.Render(96, 32))
+	if !strings.Contains(view, "big.go too large to preview") {
+		t.Errorf("expected the large change left undiffed:\n%s", view)
+	}
+
+	app.cancel()
+	finished <- nil
+	<-done
+}
@@ -0,0 +1,212 @@
+// events.go
+package tux
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// eventFrameBudget is how long agent events are collected before they are
+// handed to the UI together, so fast token streams redraw at most once per
+// frame.
+const eventFrameBudget = time.Second / 60
+
+// runDrainTimeout is how long events are still taken after Run returns,
+// for agents that close their channel late or never.
+const runDrainTimeout = 100 * time.Millisecond
+
+// EventMsg delivers an agent event to the App inside the Bubble Tea update
+// loop. Events from Agent.Subscribe are delivered this way; agents that
+// report events some other way can send EventMsg with App.Send.
+type EventMsg struct {
+	Event Event
+}
+
+// eventBatchMsg delivers the events collected during one frame, in order.
+type eventBatchMsg []Event
+
+// runDoneMsg reports that a run has ended and all its events were sent,
+// with the error Run returned.
+type runDoneMsg struct {
+	runID uint64
+	err   error
+}
+
+// sessionSavedMsg reports the result of saving the session off the UI
+// thread.
+type sessionSavedMsg struct {
+	err error
+}
+
+// sessionErrorMsg reports a failure to record to the session store. It is
+// shown but, unlike agent errors, not itself recorded.
+type sessionErrorMsg struct {
+	err error
+}
+
+// Send delivers a message to the App's update loop. It is safe to call
+// from any goroutine. Before Run (or after it returns) there is no loop,
+// so the message is handled immediately on the calling goroutine.
+func (a *App) Send(msg tea.Msg) {
+	if a.shell.Running() {
+		a.shell.Send(msg)
+		return
+	}
+	if handled, _ := a.handleMessage(msg); !handled {
+		a.shell.Update(msg)
+	}
+}
+
+// handleMessage applies App messages to the UI. It is the shell's
+// OnMessage hook, so it runs inside the update loop.
+func (a *App) handleMessage(msg tea.Msg) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case EventMsg:
+		a.processEvent(msg.Event)
+		return true, a.tools.resumeTicks()
+	case eventBatchMsg:
+		for _, event := range msg {
+			a.processEvent(event)
+		}
+		return true, a.tools.resumeTicks()
+	case runDoneMsg:
+		a.runDone(msg.runID, msg.err)
+	case sessionSavedMsg:
+		a.sessionSaved(msg.err)
+	case sessionErrorMsg:
+		a.addError(msg.err)
+	case approvalsDoneMsg:
+		a.closeApprovals(msg.queue)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// pumpEvents forwards the events of one run to the update loop, stamped
+// with the run's ID. Events arriving within one frame are sent together,
+// with consecutive text joined, so the UI keeps up with any token rate.
+// File changes of approval requests are previewed here, off the update
+// loop. Once Run's result arrives on finished, it reports the end of the
+// run and stops when the run's channel is closed, or has been quiet for
+// runDrainTimeout. Once the run is cancelled, its events are read but not
+// shown until Run returns.
+func (a *App) pumpEvents(ctx context.Context, runID uint64, events <-chan Event, finished <-chan error) {
+	var batch []Event
+	var frame <-chan time.Time
+	var result error
+	var drain *time.Timer // Started once Run has returned
+	var drained <-chan time.Time
+
+	end := func() {
+		a.flushEvents(batch)
+		a.Send(runDoneMsg{runID: runID, err: result})
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if drain == nil {
+					select {
+					case result = <-finished:
+					case <-ctx.Done():
+						return
+					}
+				}
+				end()
+				return
+			}
+			if event.RunID == 0 {
+				event.RunID = runID
+			}
+			if event.Type == EventApproval {
+				event.preview = previewChange(event)
+				event.ctx = ctx
+			}
+			batch = appendEvent(batch, event)
+			if frame == nil {
+				frame = time.After(eventFrameBudget)
+			}
+			if drained != nil {
+				drain.Reset(runDrainTimeout)
+			}
+		case result = <-finished:
+			// Wait a little for the events still in flight, but don't
+			// keep the turn open for an agent that never closes its
+			// channel
+			finished = nil
+			drain = time.NewTimer(runDrainTimeout)
+			drained = drain.C
+			defer drain.Stop()
+		case <-drained:
+			end()
+			return
+		case <-frame:
+			a.flushEvents(batch)
+			batch, frame = nil, nil
+		case <-ctx.Done():
+			// Events of a cancelled run are not shown, but are still
+			// taken until Run returns
+			if finished != nil {
+				discardEvents(events, finished)
+			}
+			return
+		}
+	}
+}
+
+// discardEvents takes the events of a cancelled run until Run returns,
+// so an agent still sending them is not blocked. Returns Run's result.
+func discardEvents(events <-chan Event, finished <-chan error) error {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case err := <-finished:
+			return err
+		}
+	}
+}
+
+// flushEvents sends a batch of events to the update loop.
+func (a *App) flushEvents(batch []Event) {
+	if len(batch) > 0 {
+		a.Send(eventBatchMsg(batch))
+	}
+}
+
+// appendEvent adds an event to a batch, joining it onto a preceding text
+// event.
+func appendEvent(batch []Event, event Event) []Event {
+	if n := len(batch); n > 0 && event.Type == EventText && batch[n-1].Type == EventText &&
+		batch[n-1].RunID == event.RunID {
+		batch[n-1].Text += event.Text
+		return batch
+	}
+	return append(batch, event)
+}
+
+// reportError shows an error that happened off the UI thread.
+func (a *App) reportError(err error) {
+	a.Send(EventMsg{Event: Event{Type: EventError, Error: err}})
+}
+
+// sessionSaved shows the result of a save started with the save shortcut.
+func (a *App) sessionSaved(err error) {
+	if err != nil {
+		a.processEvent(Event{Type: EventError, Error: fmt.Errorf("saving session: %w", err)})
+		return
+	}
+
+	a.mu.Lock()
+	message := fmt.Sprintf("Saved session %s", a.sessionID)
+	a.mu.Unlock()
+
+	a.UpdateStatus(func(st *Status) { st.Message = message })
+}
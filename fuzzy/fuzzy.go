@@ -0,0 +1,114 @@
+// Package fuzzy matches typed queries against candidates the way pickers
+// filter them: the query's characters must appear in order, and matches at
+// word boundaries and in runs rank higher.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result is how a candidate matched a query.
+type Result struct {
+	Score     int
+	Positions []int // Indexes of the matched runes in the candidate
+}
+
+// Match scores how well candidate matches query, ignoring case. Matches at
+// the start, at word boundaries (after a separator, or an upper-case letter
+// after a lower-case one) and in runs score higher, as do exact and prefix
+// matches and shorter candidates. An empty query matches everything with a
+// score of 0.
+func Match(query, candidate string) (Result, bool) {
+	if query == "" {
+		return Result{}, true
+	}
+	q := []rune(strings.ToLower(query))
+	c := []rune(candidate)
+
+	positions := make([]int, 0, len(q))
+	score, qi, last := 0, 0, -1
+	for ci := 0; ci < len(c) && qi < len(q); ci++ {
+		if unicode.ToLower(c[ci]) != q[qi] {
+			continue
+		}
+		score += 10
+		switch {
+		case ci == 0:
+			score += 20
+		case last == ci-1:
+			score += 15
+		case isWordStart(c, ci):
+			score += 10
+		}
+		positions = append(positions, ci)
+		last = ci
+		qi++
+	}
+	if qi < len(q) {
+		return Result{}, false
+	}
+
+	lower := strings.ToLower(candidate)
+	switch {
+	case lower == string(q):
+		score += 100
+	case strings.HasPrefix(lower, string(q)):
+		score += 50
+	}
+	return Result{Score: score - (len(c) - len(q)), Positions: positions}, true
+}
+
+// isWordStart reports whether c[i] starts a word: it follows a separator
+// or is an upper-case letter after a lower-case one.
+func isWordStart(c []rune, i int) bool {
+	prev := c[i-1]
+	if strings.ContainsRune("-_. /", prev) {
+		return true
+	}
+	return unicode.IsUpper(c[i]) && unicode.IsLower(prev)
+}
+
+// Highlight renders text in base with the runes at positions in match.
+// Positions are rune indexes, as Match returns them.
+func Highlight(text string, positions []int, base, match lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(text)
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	// Render runs of matched and unmatched runes together
+	var b strings.Builder
+	runes := []rune(text)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && matched[i] == matched[start] {
+			continue
+		}
+		style := base
+		if matched[start] {
+			style = match
+		}
+		b.WriteString(style.Render(string(runes[start:i])))
+		start = i
+	}
+	return b.String()
+}
+
+// Shift returns positions moved by n runes, for a candidate matched without
+// a prefix that is displayed with it.
+func Shift(positions []int, n int) []int {
+	if len(positions) == 0 {
+		return nil
+	}
+	out := make([]int, len(positions))
+	for i, p := range positions {
+		out[i] = p + n
+	}
+	return out
+}
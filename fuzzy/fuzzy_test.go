@@ -0,0 +1,79 @@
+package fuzzy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestMatch(t *testing.T) {
+	if _, ok := Match("xyz", "handler.go"); ok {
+		t.Error("expected no match out of order")
+	}
+	if r, ok := Match("", "anything"); !ok || r.Score != 0 || r.Positions != nil {
+		t.Errorf("expected an empty query to match with no positions, got %+v", r)
+	}
+
+	exact, _ := Match("main.go", "main.go")
+	prefix, _ := Match("ma", "main.go")
+	scattered, _ := Match("mg", "main.go")
+	if exact.Score <= prefix.Score || prefix.Score <= scattered.Score {
+		t.Errorf("expected exact > prefix > scattered, got %d %d %d", exact.Score, prefix.Score, scattered.Score)
+	}
+
+	boundary, _ := Match("ht", "handler_test.go")
+	inner, _ := Match("ht", "hothouse.go")
+	if boundary.Score <= inner.Score {
+		t.Errorf("expected a word start to score higher, got %d vs %d", boundary.Score, inner.Score)
+	}
+
+	camel, _ := Match("gc", "getConfig")
+	plain, _ := Match("gc", "gadgetcase")
+	if camel.Score <= plain.Score {
+		t.Errorf("expected a camelCase hump to score higher, got %d vs %d", camel.Score, plain.Score)
+	}
+}
+
+func TestMatchPositions(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		want             []int
+	}{
+		{"hdl", "handler.go", []int{0, 3, 4}},
+		{"MG", "main.go", []int{0, 5}},
+		{"gc", "getConfig", []int{0, 3}},
+		{"éa", "café au lait", []int{3, 5}},
+	}
+	for _, tt := range tests {
+		r, ok := Match(tt.query, tt.candidate)
+		if !ok || !slices.Equal(r.Positions, tt.want) {
+			t.Errorf("Match(%q, %q) positions = %v, want %v", tt.query, tt.candidate, r.Positions, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	base := lipgloss.NewStyle()
+	match := lipgloss.NewStyle().Transform(strings.ToUpper)
+
+	if got := Highlight("handler.go", []int{0, 3, 4}, base, match); got != "HanDLer.go" {
+		t.Errorf("expected matched runs highlighted, got %q", got)
+	}
+	if got := Highlight("café au", []int{3}, base, match); got != "cafÉ au" {
+		t.Errorf("expected rune positions, got %q", got)
+	}
+	if got := Highlight("plain", nil, base, match); got != "plain" {
+		t.Errorf("expected no highlight without positions, got %q", got)
+	}
+}
+
+func TestShift(t *testing.T) {
+	if got := Shift([]int{0, 2}, 1); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("unexpected shift %v", got)
+	}
+	if got := Shift(nil, 1); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
@@ -0,0 +1,420 @@
+// headless.go
+package tux
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/session"
+	"github.com/2389-research/tux/shell"
+	"github.com/charmbracelet/x/term"
+)
+
+// HeadlessFormat is the output format of a headless run.
+type HeadlessFormat int
+
+const (
+	// HeadlessText prints the answer as plain text, with a tagged line per
+	// tool call, tool result, approval and error.
+	HeadlessText HeadlessFormat = iota
+	// HeadlessJSON prints one JSON object per event.
+	HeadlessJSON
+)
+
+// WithHeadless runs the App without the full-screen UI: Run sends one
+// prompt and prints the agent's output to stdout. Headless mode is also
+// used when stdout is not a terminal, as in CI or a pipe, unless
+// WithProgramOptions is given.
+func WithHeadless() Option {
+	return func(c *appConfig) {
+		c.headless = true
+	}
+}
+
+// WithHeadlessFormat sets the output format of headless runs.
+func WithHeadlessFormat(format HeadlessFormat) Option {
+	return func(c *appConfig) {
+		c.headlessFormat = format
+	}
+}
+
+// WithHeadlessPrompt sets the prompt of a headless run. Without it the
+// prompt is read from stdin: all of it when stdin is a pipe, or a line at a
+// time until EOF when it is a terminal.
+func WithHeadlessPrompt(prompt string) Option {
+	return func(c *appConfig) {
+		c.headlessPrompt = prompt
+	}
+}
+
+// isHeadless reports whether Run should skip the full-screen UI. Stdout is
+// not checked when program options are set, as they may send the UI
+// elsewhere (tea.WithOutput).
+func (a *App) isHeadless() bool {
+	if a.config.headless {
+		return true
+	}
+	return len(a.config.programOptions) == 0 && !term.IsTerminal(os.Stdout.Fd())
+}
+
+// runHeadless runs the App headless on the process's stdio. Interrupts
+// cancel the run.
+func (a *App) runHeadless() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var tty io.Writer
+	if term.IsTerminal(os.Stdin.Fd()) {
+		tty = os.Stderr
+	}
+	return a.headless(ctx, os.Stdin, os.Stdout, tty)
+}
+
+// headless runs prompts read as WithHeadlessPrompt describes, writing
+// output to out. Approvals are asked on tty, answered from in, when tty is
+// set. Returns the first error the agent reported.
+func (a *App) headless(ctx context.Context, in io.Reader, out, tty io.Writer) error {
+	defer a.flushWrites()
+
+	// Low-risk tools run unasked, as no one may be there to ask
+	if ba, ok := a.agent.(*BackendAgent); ok {
+		ba.setDefaultClassifier(agent.RiskBasedClassifier)
+	}
+
+	h := &headlessRun{
+		app:    a,
+		format: a.config.headlessFormat,
+		out:    out,
+		tty:    tty,
+		in:     bufio.NewReader(in),
+		tools:  make(map[string]string),
+	}
+
+	if prompt := a.config.headlessPrompt; prompt != "" {
+		return h.run(ctx, prompt)
+	}
+	if tty == nil {
+		data, err := io.ReadAll(h.in)
+		if err != nil {
+			return fmt.Errorf("reading prompt: %w", err)
+		}
+		prompt := strings.TrimSpace(string(data))
+		if prompt == "" {
+			return errors.New("tux: no prompt given on stdin or with WithHeadlessPrompt")
+		}
+		return h.run(ctx, prompt)
+	}
+
+	// A prompt per line until EOF
+	var failed error
+	for ctx.Err() == nil {
+		fmt.Fprint(tty, "> ")
+		line, err := h.in.ReadString('\n')
+		if prompt := strings.TrimSpace(line); prompt != "" {
+			if err := h.run(ctx, prompt); err != nil && failed == nil {
+				failed = err
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return failed
+}
+
+// headlessRun prints the events of headless runs.
+type headlessRun struct {
+	app    *App
+	format HeadlessFormat
+	out    io.Writer
+	tty    io.Writer // Where approvals are asked; nil to deny them
+	in     *bufio.Reader
+
+	tools   map[string]string // Tool names by call ID
+	midLine bool              // Whether text output ended without a newline
+	text    strings.Builder   // Answer of the current turn, for the transcript
+}
+
+// run sends a prompt to the agent and prints its events until the run
+// ends. Files the prompt mentions over the attachment limit are left out,
+// with an error printed for each. Returns the first error the agent
+// reported, or the run's error.
+func (h *headlessRun) run(ctx context.Context, prompt string) error {
+	a := h.app
+	attachments, tooLarge := a.resolveAttachments(prompt)
+	for _, at := range tooLarge {
+		h.print(Event{Type: EventError, Error: fmt.Errorf("%s (%s) is over the %s attachment limit; not attached",
+			at.Path, shell.FormatSize(at.Size), shell.FormatSize(a.attachmentLimit))})
+	}
+	a.record(session.Record{Type: session.RecordUser, Content: prompt, Attachments: attachmentRecords(attachments)})
+	a.startTurnUsage(prompt)
+	defer a.finishTurnUsage()
+
+	a.mu.Lock()
+	a.runID++
+	id := a.runID
+	a.ctx, a.cancel = context.WithCancel(ContextWithRunID(ctx, id))
+	runCtx, cancel := a.ctx, a.cancel
+	a.running = true
+	a.mu.Unlock()
+	defer cancel()
+
+	events := a.agent.Subscribe()
+	finished := make(chan error, 1)
+	go func() {
+		finished <- a.runAgent(runCtx, prompt, attachments)
+	}()
+
+	var failed error
+	h.text.Reset()
+loop:
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				break loop
+			}
+			if err := h.handle(event); err != nil && failed == nil {
+				failed = err
+			}
+		case <-runCtx.Done():
+			break loop
+		}
+	}
+	err := discardEvents(events, finished)
+
+	a.mu.Lock()
+	a.running = false
+	a.mu.Unlock()
+
+	h.endLine()
+	if failed != nil {
+		return failed
+	}
+	if err == nil && runCtx.Err() != nil {
+		err = runCtx.Err()
+	}
+	if err != nil {
+		h.print(Event{Type: EventError, Error: err})
+	}
+	return err
+}
+
+// handle prints an event and records it in the transcript. Returns the
+// error of an EventError.
+func (h *headlessRun) handle(event Event) error {
+	a := h.app
+	switch event.Type {
+	case EventText:
+		h.text.WriteString(event.Text)
+
+	case EventToolCall:
+		h.tools[event.ToolID] = event.ToolName
+		a.record(session.Record{
+			Type:     session.RecordToolCall,
+			ToolID:   event.ToolID,
+			ToolName: event.ToolName,
+			Params:   event.ToolParams,
+		})
+
+	case EventToolResult:
+		a.record(session.Record{
+			Type:    session.RecordToolResult,
+			ToolID:  event.ToolID,
+			Content: event.ToolOutput,
+			Success: event.Success,
+		})
+
+	case EventUsage:
+		if event.Usage != nil {
+			a.addUsage(*event.Usage)
+		}
+
+	case EventComplete:
+		if h.text.Len() > 0 {
+			a.record(session.Record{Type: session.RecordAssistant, Content: h.text.String()})
+			h.text.Reset()
+		}
+		if event.Usage != nil {
+			a.completeUsage(*event.Usage)
+		}
+
+	case EventError:
+		if event.Error == nil {
+			event.Error = errors.New("unknown error")
+		}
+		a.record(session.Record{Type: session.RecordError, Content: event.Error.Error()})
+
+	case EventApproval:
+		d := h.approve(event)
+		respondApproval(event, ApprovalResponse{Decision: d})
+		a.rememberDecision(event, d)
+		h.printApproval(event, d)
+		return nil
+	}
+
+	h.print(event)
+	if event.Type == EventError {
+		return event.Error
+	}
+	return nil
+}
+
+// approve decides an approval request: by a remembered decision, else by
+// asking on the terminal, else by denying it.
+func (h *headlessRun) approve(event Event) ApprovalDecision {
+	if d, ok := h.app.policyDecision(event); ok {
+		return d
+	}
+	if h.tty == nil {
+		return DecisionDeny
+	}
+
+	h.endLine()
+	fmt.Fprintf(h.tty, "Allow %s? [y/N/always/never] ", strings.TrimSpace(event.ToolName+" "+formatParams(event.ToolParams)))
+	line, _ := h.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return DecisionApprove
+	case "always":
+		return DecisionAlwaysAllow
+	case "never":
+		return DecisionNeverAllow
+	}
+	return DecisionDeny
+}
+
+// headlessLine is an event as printed by HeadlessJSON.
+type headlessLine struct {
+	Type     EventType      `json:"type"`
+	Text     string         `json:"text,omitempty"`
+	ToolID   string         `json:"tool_id,omitempty"`
+	ToolName string         `json:"tool_name,omitempty"`
+	Params   map[string]any `json:"params,omitempty"`
+	Output   string         `json:"output,omitempty"`
+	Success  *bool          `json:"success,omitempty"`
+	Decision string         `json:"decision,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Usage    *headlessUsage `json:"usage,omitempty"`
+}
+
+// headlessUsage is token usage as printed by HeadlessJSON.
+type headlessUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	CacheHits    int `json:"cache_hits,omitempty"`
+}
+
+// print writes an event in the run's format.
+func (h *headlessRun) print(event Event) {
+	if h.format == HeadlessJSON {
+		line := headlessLine{
+			Type:     event.Type,
+			Text:     event.Text,
+			ToolID:   event.ToolID,
+			ToolName: event.ToolName,
+			Params:   event.ToolParams,
+			Output:   event.ToolOutput,
+		}
+		if event.Type == EventToolResult {
+			line.Success = &event.Success
+			if line.ToolName == "" {
+				line.ToolName = h.tools[event.ToolID]
+			}
+		}
+		if event.Error != nil {
+			line.Error = event.Error.Error()
+		}
+		if u := event.Usage; u != nil {
+			line.Usage = &headlessUsage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens, CacheHits: u.CacheHits}
+		}
+		h.writeJSON(line)
+		return
+	}
+
+	switch event.Type {
+	case EventText:
+		if event.Text != "" {
+			io.WriteString(h.out, event.Text)
+			h.midLine = !strings.HasSuffix(event.Text, "\n")
+		}
+	case EventToolCall:
+		h.printLine("[tool] " + strings.TrimSpace(event.ToolName+" "+formatParams(event.ToolParams)))
+	case EventToolResult:
+		status := "ok"
+		if !event.Success {
+			status = "failed"
+		}
+		line := fmt.Sprintf("[result] %s %s", h.tools[event.ToolID], status)
+		if output := firstLine(event.ToolOutput); output != "" {
+			line += ": " + output
+		}
+		h.printLine(line)
+	case EventError:
+		h.printLine("[error] " + event.Error.Error())
+	case EventComplete:
+		h.endLine()
+	}
+}
+
+// printApproval writes an approval request with its decision.
+func (h *headlessRun) printApproval(event Event, d ApprovalDecision) {
+	if h.format == HeadlessJSON {
+		h.writeJSON(headlessLine{
+			Type:     EventApproval,
+			ToolID:   event.ToolID,
+			ToolName: event.ToolName,
+			Params:   event.ToolParams,
+			Decision: decisionName(d),
+		})
+		return
+	}
+	h.printLine(fmt.Sprintf("[approval] %s %s", event.ToolName, decisionName(d)))
+}
+
+// writeJSON writes one JSON line.
+func (h *headlessRun) writeJSON(line headlessLine) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		// Parameters that do not marshal are left out
+		line.Params = nil
+		data, _ = json.Marshal(line)
+	}
+	h.out.Write(append(data, '\n'))
+}
+
+// printLine writes a line of text output, starting it on a line of its own.
+func (h *headlessRun) printLine(line string) {
+	h.endLine()
+	fmt.Fprintln(h.out, line)
+}
+
+// endLine ends text output left without a newline.
+func (h *headlessRun) endLine() {
+	if h.midLine {
+		io.WriteString(h.out, "\n")
+		h.midLine = false
+	}
+}
+
+// decisionName names an approval decision in headless output.
+func decisionName(d ApprovalDecision) string {
+	switch d {
+	case DecisionApprove:
+		return "approved"
+	case DecisionAlwaysAllow:
+		return "always_allowed"
+	case DecisionNeverAllow:
+		return "never_allowed"
+	default:
+		return "denied"
+	}
+}
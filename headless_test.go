@@ -0,0 +1,186 @@
+package tux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/agent"
+)
+
+// runHeadless runs app headless with the given stdin, returning its output.
+func runHeadless(t *testing.T, app *App, stdin string, tty *bytes.Buffer) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	var err error
+	if tty != nil {
+		err = app.headless(context.Background(), strings.NewReader(stdin), &out, tty)
+	} else {
+		err = app.headless(context.Background(), strings.NewReader(stdin), &out, nil)
+	}
+	return out.String(), err
+}
+
+func TestHeadlessText(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{agent.NewTextEvent("Reading"), toolCall("t1", "read")},
+		[]agent.Event{agent.NewTextEvent("Done.")},
+	)
+	app := NewFromBackend(backend, WithHeadless(), WithHeadlessPrompt("go"))
+
+	out, err := runHeadless(t, app, "", nil)
+	if err != nil {
+		t.Fatalf("headless run failed: %v", err)
+	}
+	// Low-risk tools run without asking
+	want := "Reading\n[tool] read arg=t1\n[result] read ok: read ok\nDone.\n"
+	if out != want {
+		t.Errorf("unexpected output\ngot:\n%s\nwant:\n%s", out, want)
+	}
+	if app.records[0].Content != "go" {
+		t.Errorf("expected the prompt in the transcript, got %+v", app.records[0])
+	}
+}
+
+func TestHeadlessJSON(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "bash")},
+		[]agent.Event{agent.NewTextEvent("Skipped.")},
+	)
+	backend.risk["bash"] = agent.RiskHigh
+	app := NewFromBackend(backend, WithHeadless(), WithHeadlessFormat(HeadlessJSON))
+
+	out, err := runHeadless(t, app, "run it\n", nil)
+	if err != nil {
+		t.Fatalf("headless run failed: %v", err)
+	}
+
+	var types []string
+	var approval, result headlessLine
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var l headlessLine
+		if err := json.Unmarshal([]byte(line), &l); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		types = append(types, string(l.Type))
+		switch l.Type {
+		case EventApproval:
+			approval = l
+		case EventToolResult:
+			result = l
+		}
+	}
+	want := "tool_call approval tool_result text complete"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("expected events %q, got %q", want, got)
+	}
+	// Nobody to ask: high-risk tools are denied
+	if approval.Decision != "denied" || approval.ToolName != "bash" {
+		t.Errorf("expected bash denied, got %+v", approval)
+	}
+	if result.Success == nil || *result.Success || result.ToolName != "bash" {
+		t.Errorf("expected a failed bash result, got %+v", result)
+	}
+	if got := backend.executedTools(); len(got) != 0 {
+		t.Errorf("expected no tools run, got %v", got)
+	}
+	if msgs := backend.seen[0]; msgs[0].Content != "run it" {
+		t.Errorf("expected the prompt read from stdin, got %q", msgs[0].Content)
+	}
+}
+
+func TestHeadlessAsksOnTerminal(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{toolCall("t1", "bash")},
+		[]agent.Event{agent.NewTextEvent("Ran it.")},
+	)
+	backend.risk["bash"] = agent.RiskHigh
+	app := NewFromBackend(backend, WithHeadless(), WithHeadlessPrompt("go"))
+
+	var tty bytes.Buffer
+	out, err := runHeadless(t, app, "y\n", &tty)
+	if err != nil {
+		t.Fatalf("headless run failed: %v", err)
+	}
+	if !strings.Contains(tty.String(), "Allow bash arg=t1? [y/N/always/never]") {
+		t.Errorf("expected the approval asked on the terminal, got %q", tty.String())
+	}
+	if !strings.Contains(out, "[approval] bash approved") {
+		t.Errorf("expected the approval in the output:\n%s", out)
+	}
+	if got := backend.executedTools(); len(got) != 1 || got[0] != "bash" {
+		t.Errorf("expected bash run, got %v", got)
+	}
+}
+
+func TestHeadlessReportsErrors(t *testing.T) {
+	backend := newScriptedBackend(
+		[]agent.Event{agent.NewTextEvent("Half"), agent.NewErrorEvent(errors.New("rate limited"))},
+	)
+	app := NewFromBackend(backend, WithHeadless(), WithHeadlessPrompt("go"))
+
+	out, err := runHeadless(t, app, "", nil)
+	if err == nil || err.Error() != "rate limited" {
+		t.Errorf("expected the agent's error returned, got %v", err)
+	}
+	if !strings.Contains(out, "Half\n[error] rate limited\n") {
+		t.Errorf("expected the error on its own line:\n%s", out)
+	}
+}
+
+// lingeringAgent keeps sending events after its run is cancelled, on an
+// unbuffered channel, before returning.
+type lingeringAgent struct {
+	started chan struct{}
+	events  chan Event
+}
+
+func newLingeringAgent() *lingeringAgent {
+	return &lingeringAgent{started: make(chan struct{}, 1), events: make(chan Event)}
+}
+
+func (l *lingeringAgent) Run(ctx context.Context, prompt string) error {
+	l.started <- struct{}{}
+	<-ctx.Done()
+	for range 3 {
+		l.events <- Event{Type: EventText, Text: "late"}
+	}
+	return ctx.Err()
+}
+
+func (l *lingeringAgent) Subscribe() <-chan Event { return l.events }
+
+func (l *lingeringAgent) Cancel() {}
+
+func TestHeadlessDrainsCancelledRun(t *testing.T) {
+	agent := newLingeringAgent()
+	app := New(agent, WithHeadless(), WithHeadlessPrompt("go"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.headless(ctx, strings.NewReader(""), &bytes.Buffer{}, nil)
+	}()
+	<-agent.started
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the run cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the run to end with the agent still sending")
+	}
+}
+
+func TestHeadlessNeedsPrompt(t *testing.T) {
+	app := NewFromBackend(newScriptedBackend(), WithHeadless())
+	if _, err := runHeadless(t, app, "  \n", nil); err == nil {
+		t.Error("expected an error without a prompt")
+	}
+}
@@ -0,0 +1,180 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// blockKind identifies a markdown block.
+type blockKind int
+
+const (
+	kindParagraph blockKind = iota
+	kindHeading
+	kindCode
+	kindList
+	kindQuote
+	kindTable
+	kindRule
+)
+
+// block is a run of source lines that render as one unit.
+type block struct {
+	kind     blockKind
+	lines    []string
+	level    int    // Heading level
+	lang     string // Code fence info string
+	fence    string // Code fence marker, e.g. "```"
+	end      int    // Byte offset just past the block in the source
+	complete bool   // Whether later input can no longer change the block
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+	fenceRe    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)[^`]*$")
+	ruleRe     = regexp.MustCompile(`^ {0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	listItemRe = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])(\s+(.*))?$`)
+	quoteRe    = regexp.MustCompile(`^ {0,3}>`)
+)
+
+// isRule reports whether line is a thematic break (---, ***, ___).
+func isRule(line string) bool {
+	if !ruleRe.MatchString(line) {
+		return false
+	}
+	// All markers must be the same character
+	trimmed := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	return strings.Count(trimmed, trimmed[:1]) == len(trimmed)
+}
+
+// isListItem reports whether line starts a list item.
+func isListItem(line string) bool {
+	m := listItemRe.FindStringSubmatch(line)
+	return m != nil && m[3] != ""
+}
+
+// isFenceClose reports whether line closes a fence opened with marker.
+func isFenceClose(line, marker string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(marker) &&
+		strings.Trim(trimmed, marker[:1]) == "" &&
+		strings.HasPrefix(trimmed, marker)
+}
+
+// splitBlocks splits markdown source into blocks. Only the trailing blocks
+// can be incomplete: an unfinished last line, an unclosed code fence, or a
+// block that the next line might still extend.
+func splitBlocks(src string) []block {
+	var blocks []block
+	var cur *block
+
+	flush := func(end int) {
+		if cur != nil {
+			cur.end = end
+			cur.complete = true
+			blocks = append(blocks, *cur)
+			cur = nil
+		}
+	}
+	start := func(b block) {
+		cur = &b
+	}
+
+	// Blocks emitted while classifying an unfinished line may change
+	// once the line is complete.
+	firstUnstable := -1
+
+	pos := 0
+	for pos < len(src) {
+		var line string
+		next := len(src)
+		full := false
+		if nl := strings.IndexByte(src[pos:], '\n'); nl >= 0 {
+			line = src[pos : pos+nl]
+			next = pos + nl + 1
+			full = true
+		} else {
+			line = src[pos:]
+			firstUnstable = len(blocks)
+		}
+		line = strings.TrimRight(line, "\r")
+
+		// Inside a code fence everything is literal until the closing fence
+		if cur != nil && cur.kind == kindCode {
+			if isFenceClose(line, cur.fence) {
+				flush(next)
+			} else {
+				cur.lines = append(cur.lines, line)
+			}
+			pos = next
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			if full {
+				flush(next)
+			}
+
+		case fenceRe.MatchString(line):
+			m := fenceRe.FindStringSubmatch(line)
+			flush(pos)
+			start(block{kind: kindCode, fence: m[1], lang: strings.ToLower(m[2])})
+
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			flush(pos)
+			start(block{kind: kindHeading, level: len(m[1]), lines: []string{m[2]}})
+			flush(next)
+
+		case isRule(line):
+			flush(pos)
+			start(block{kind: kindRule})
+			flush(next)
+
+		case strings.HasPrefix(strings.TrimSpace(line), "|"):
+			if cur == nil || cur.kind != kindTable {
+				flush(pos)
+				start(block{kind: kindTable})
+			}
+			cur.lines = append(cur.lines, line)
+
+		case isListItem(line):
+			if cur == nil || cur.kind != kindList {
+				flush(pos)
+				start(block{kind: kindList})
+			}
+			cur.lines = append(cur.lines, line)
+
+		case quoteRe.MatchString(line):
+			if cur == nil || cur.kind != kindQuote {
+				flush(pos)
+				start(block{kind: kindQuote})
+			}
+			cur.lines = append(cur.lines, line)
+
+		default:
+			// Lazy continuation of paragraphs, list items and quotes
+			if cur == nil || (cur.kind != kindParagraph && cur.kind != kindList && cur.kind != kindQuote) {
+				flush(pos)
+				start(block{kind: kindParagraph})
+			}
+			cur.lines = append(cur.lines, line)
+		}
+
+		pos = next
+	}
+
+	if cur != nil {
+		cur.end = len(src)
+		blocks = append(blocks, *cur)
+	}
+
+	if firstUnstable >= 0 {
+		for i := firstUnstable; i < len(blocks); i++ {
+			blocks[i].complete = false
+		}
+	}
+
+	return blocks
+}
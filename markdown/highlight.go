@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// highlight syntax-highlights code and returns the styled lines. The lexer
+// is chosen from the fence tag, falling back to content detection.
+func (r *Renderer) highlight(lang, code string) []string {
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		return r.plainCode(code)
+	}
+
+	iter, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return r.plainCode(code)
+	}
+
+	var lines []string
+	for _, tokens := range chroma.SplitTokensIntoLines(iter.Tokens()) {
+		var sb strings.Builder
+		for _, tok := range tokens {
+			value := strings.TrimRight(tok.Value, "\n")
+			if value == "" {
+				continue
+			}
+			sb.WriteString(r.tokenStyle(tok.Type).Render(value))
+		}
+		lines = append(lines, sb.String())
+	}
+
+	// Lexers may add a trailing newline, producing an extra empty line
+	if want := strings.Count(code, "\n") + 1; len(lines) > want {
+		lines = lines[:want]
+	}
+	return lines
+}
+
+// plainCode renders code without highlighting.
+func (r *Renderer) plainCode(code string) []string {
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = r.styles.code.Render(line)
+		}
+	}
+	return lines
+}
+
+// tokenStyle maps a chroma token type to a theme colour.
+func (r *Renderer) tokenStyle(t chroma.TokenType) lipgloss.Style {
+	th := r.theme
+	style := lipgloss.NewStyle()
+	switch {
+	case t.InCategory(chroma.Comment):
+		return style.Foreground(th.Muted()).Italic(true)
+	case t == chroma.KeywordType || t.InSubCategory(chroma.NameBuiltin):
+		return style.Foreground(th.Secondary())
+	case t.InCategory(chroma.Keyword):
+		return style.Foreground(th.Primary())
+	case t.InSubCategory(chroma.LiteralString):
+		return style.Foreground(th.Success())
+	case t.InSubCategory(chroma.LiteralNumber), t == chroma.NameConstant:
+		return style.Foreground(th.Warning())
+	case t == chroma.NameFunction, t == chroma.NameClass, t == chroma.NameDecorator:
+		return style.Foreground(th.Info())
+	case t == chroma.GenericInserted:
+		return style.Foreground(th.Success())
+	case t == chroma.GenericDeleted, t == chroma.Error:
+		return style.Foreground(th.Error())
+	case t == chroma.GenericHeading, t == chroma.GenericSubheading:
+		return style.Foreground(th.Primary()).Bold(true)
+	default:
+		return style.Foreground(th.Foreground())
+	}
+}
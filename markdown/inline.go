@@ -0,0 +1,138 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// inline renders inline markdown (code spans, emphasis, strikethrough and
+// links) with base as the style for plain text. Unclosed markers, common
+// while streaming, are rendered literally.
+func (r *Renderer) inline(s string, base lipgloss.Style) string {
+	var out strings.Builder
+	var plain strings.Builder
+
+	emit := func() {
+		if plain.Len() > 0 {
+			out.WriteString(base.Render(plain.String()))
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			plain.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				emit()
+				out.WriteString(r.styles.code.Render(s[i+1 : i+1+end]))
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "**") || strings.HasPrefix(s[i:], "__"):
+			marker := s[i : i+2]
+			if end := strings.Index(s[i+2:], marker); end > 0 && canOpen(s, i, 2) {
+				emit()
+				out.WriteString(r.inline(s[i+2:i+2+end], base.Bold(true)))
+				i += end + 4
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "~~"):
+			if end := strings.Index(s[i+2:], "~~"); end > 0 {
+				emit()
+				out.WriteString(r.inline(s[i+2:i+2+end], base.Strikethrough(true)))
+				i += end + 4
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if end := strings.IndexByte(s[i+1:], c); end > 0 && canOpen(s, i, 1) && canClose(s, i+1+end) {
+				emit()
+				out.WriteString(r.inline(s[i+1:i+1+end], base.Italic(true)))
+				i += end + 2
+				continue
+			}
+
+		case c == '[':
+			if text, url, n, ok := parseLink(s[i:]); ok {
+				emit()
+				out.WriteString(r.inline(text, r.styles.link))
+				if url != text {
+					out.WriteString(base.Render(" "))
+					out.WriteString(r.styles.url.Render("(" + url + ")"))
+				}
+				i += n
+				continue
+			}
+		}
+
+		plain.WriteByte(c)
+		i++
+	}
+	emit()
+	return out.String()
+}
+
+// canOpen reports whether an emphasis marker of length n at i can open a
+// span: it must be followed by a non-space and, for underscores, not be
+// inside a word (snake_case).
+func canOpen(s string, i, n int) bool {
+	if i+n >= len(s) || s[i+n] == ' ' {
+		return false
+	}
+	if s[i] == '_' && i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		if unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			return false
+		}
+	}
+	return true
+}
+
+// canClose reports whether the single emphasis marker at i can close a span.
+func canClose(s string, i int) bool {
+	if s[i-1] == ' ' {
+		return false
+	}
+	if s[i] == '_' && i+1 < len(s) {
+		next, _ := utf8.DecodeRuneInString(s[i+1:])
+		if unicode.IsLetter(next) || unicode.IsDigit(next) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLink parses "[text](url)" at the start of s, returning the text, the
+// URL and the number of bytes consumed.
+func parseLink(s string) (text, url string, n int, ok bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 0 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeText+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	text = s[1:closeText]
+	url = s[closeText+2 : closeText+2+closeURL]
+	if strings.ContainsAny(url, " \t") {
+		return "", "", 0, false
+	}
+	return text, url, closeText + 3 + closeURL, true
+}
+
+// isASCIIPunct reports whether c can be backslash-escaped.
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && (unicode.IsPunct(rune(c)) || unicode.IsSymbol(rune(c)))
+}
@@ -0,0 +1,217 @@
+// Package markdown renders markdown to styled terminal text.
+//
+// Colours come from a theme.Theme and output is word-wrapped to a width.
+// Fenced code blocks are syntax-highlighted. A Stream renders text that
+// arrives incrementally, re-rendering only the trailing unfinished block.
+package markdown
+
+import (
+	"strings"
+
+	"github.com/2389-research/tux/theme"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Renderer renders markdown using colours from a theme.
+// It is not safe for concurrent use.
+type Renderer struct {
+	theme  theme.Theme
+	width  int
+	styles styles
+}
+
+// styles holds the lipgloss styles derived from the theme.
+type styles struct {
+	text    lipgloss.Style
+	heading lipgloss.Style
+	code    lipgloss.Style
+	link    lipgloss.Style
+	url     lipgloss.Style
+	bullet  lipgloss.Style
+	quote   lipgloss.Style
+	border  lipgloss.Style
+	label   lipgloss.Style
+}
+
+// New creates a renderer for the given theme. Text is not wrapped until a
+// width is set.
+func New(th theme.Theme) *Renderer {
+	if th == nil {
+		panic("markdown.New: nil theme")
+	}
+	r := &Renderer{theme: th}
+	r.styles = styles{
+		text:    lipgloss.NewStyle().Foreground(th.Foreground()),
+		heading: lipgloss.NewStyle().Foreground(th.Primary()).Bold(true),
+		code:    lipgloss.NewStyle().Foreground(th.Secondary()),
+		link:    lipgloss.NewStyle().Foreground(th.Info()).Underline(true),
+		url:     lipgloss.NewStyle().Foreground(th.Muted()),
+		bullet:  lipgloss.NewStyle().Foreground(th.Primary()),
+		quote:   lipgloss.NewStyle().Foreground(th.Muted()).Italic(true),
+		border:  lipgloss.NewStyle().Foreground(th.Border()),
+		label:   lipgloss.NewStyle().Foreground(th.Muted()),
+	}
+	return r
+}
+
+// SetWidth sets the wrap width. Zero or less disables wrapping.
+func (r *Renderer) SetWidth(width int) {
+	r.width = width
+}
+
+// Width returns the wrap width.
+func (r *Renderer) Width() int {
+	return r.width
+}
+
+// SetTextColor sets the colour of plain text (defaults to the theme's
+// foreground).
+func (r *Renderer) SetTextColor(c lipgloss.Color) {
+	r.styles.text = r.styles.text.Foreground(c)
+}
+
+// Render renders a complete markdown document.
+func (r *Renderer) Render(src string) string {
+	blocks := splitBlocks(src)
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		parts = append(parts, r.renderBlock(b))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// renderBlock renders a single block.
+func (r *Renderer) renderBlock(b block) string {
+	switch b.kind {
+	case kindHeading:
+		return r.renderHeading(b)
+	case kindCode:
+		return r.renderCode(b)
+	case kindList:
+		return r.renderList(b)
+	case kindQuote:
+		return r.renderQuote(b)
+	case kindTable:
+		return r.renderTable(b)
+	case kindRule:
+		return r.renderRule()
+	default:
+		return r.wrap(r.inline(joinLines(b.lines), r.styles.text), r.width)
+	}
+}
+
+func (r *Renderer) renderHeading(b block) string {
+	style := r.styles.heading
+	if b.level == 1 {
+		style = style.Underline(true)
+	}
+	return r.wrap(r.inline(b.lines[0], style), r.width)
+}
+
+func (r *Renderer) renderCode(b block) string {
+	// Expand tabs so widths are predictable
+	code := strings.ReplaceAll(strings.Join(b.lines, "\n"), "\t", "    ")
+	lines := r.highlight(b.lang, code)
+	if r.width > 2 {
+		for i, line := range lines {
+			lines[i] = ansi.Hardwrap(line, r.width-2, true)
+		}
+	}
+	var sb strings.Builder
+	if b.lang != "" {
+		sb.WriteString(r.styles.label.Render(b.lang))
+		sb.WriteString("\n")
+	}
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		// Indent every visual line, including wrapped continuations
+		sb.WriteString("  ")
+		sb.WriteString(strings.ReplaceAll(line, "\n", "\n  "))
+	}
+	return sb.String()
+}
+
+func (r *Renderer) renderList(b block) string {
+	type item struct {
+		depth  int
+		marker string
+		text   string
+	}
+	var items []item
+	for _, line := range b.lines {
+		m := listItemRe.FindStringSubmatch(line)
+		if m == nil || m[3] == "" {
+			// Continuation of the previous item
+			if len(items) > 0 {
+				items[len(items)-1].text += " " + strings.TrimSpace(line)
+			}
+			continue
+		}
+		indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+		items = append(items, item{depth: indent / 2, marker: m[2], text: m[4]})
+	}
+
+	lines := make([]string, 0, len(items))
+	for _, it := range items {
+		marker := "•"
+		if it.marker != "-" && it.marker != "*" && it.marker != "+" {
+			marker = it.marker
+		}
+		text := it.text
+		switch {
+		case strings.HasPrefix(text, "[ ] "):
+			marker, text = "☐", text[4:]
+		case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+			marker, text = "☑", text[4:]
+		}
+
+		indent := strings.Repeat("  ", it.depth)
+		prefixWidth := len(indent) + ansi.StringWidth(marker) + 1
+		body := r.wrap(r.inline(text, r.styles.text), r.width-prefixWidth)
+		body = strings.ReplaceAll(body, "\n", "\n"+strings.Repeat(" ", prefixWidth))
+		lines = append(lines, indent+r.styles.bullet.Render(marker)+" "+body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Renderer) renderQuote(b block) string {
+	stripped := make([]string, len(b.lines))
+	for i, line := range b.lines {
+		line = strings.TrimLeft(line, " ")
+		line = strings.TrimPrefix(line, ">")
+		stripped[i] = strings.TrimPrefix(line, " ")
+	}
+	body := r.wrap(r.inline(joinLines(stripped), r.styles.quote), r.width-2)
+	bar := r.styles.border.Render("│") + " "
+	return bar + strings.ReplaceAll(body, "\n", "\n"+bar)
+}
+
+func (r *Renderer) renderRule() string {
+	width := r.width
+	if width <= 0 {
+		width = 40
+	}
+	return r.styles.border.Render(strings.Repeat("─", width))
+}
+
+// wrap word-wraps styled text to width. Zero or less disables wrapping.
+func (r *Renderer) wrap(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return ansi.Wrap(s, width, "")
+}
+
+// joinLines joins soft-wrapped source lines into one paragraph.
+func joinLines(lines []string) string {
+	trimmed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if t := strings.TrimSpace(line); t != "" {
+			trimmed = append(trimmed, t)
+		}
+	}
+	return strings.Join(trimmed, " ")
+}
@@ -0,0 +1,169 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/theme"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const sample = "# Title\n\nSome **bold** and *italic* text with `code` and a [link](https://example.com).\n\n" +
+	"- first\n- second\n  - nested\n- [x] done\n\n" +
+	"1. one\n2. two\n\n" +
+	"> quoted\n> text\n\n" +
+	"| Name | Size |\n|:-----|-----:|\n| a.go | 10 |\n| b.go | 200 |\n\n" +
+	"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+	"---\n\nThe end."
+
+func render(src string, width int) string {
+	r := New(theme.NewDraculaTheme())
+	r.SetWidth(width)
+	return ansi.Strip(r.Render(src))
+}
+
+func TestRenderBlocks(t *testing.T) {
+	out := render(sample, 80)
+
+	for _, want := range []string{
+		"Title",
+		"Some bold and italic text with code and a link (https://example.com).",
+		"• first",
+		"  • nested",
+		"☑ done",
+		"1. one",
+		"│ quoted text",
+		"Name │ Size",
+		"a.go │   10",
+		"  func main() {",
+		"The end.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q\n%s", want, out)
+		}
+	}
+	for _, raw := range []string{"# Title", "**bold**", "```", "|:---"} {
+		if strings.Contains(out, raw) {
+			t.Errorf("expected %q to be rendered, got raw markdown\n%s", raw, out)
+		}
+	}
+}
+
+func TestRenderWrapsToWidth(t *testing.T) {
+	src := strings.Repeat("word ", 40) + "\n\n- " + strings.Repeat("item ", 20)
+	out := render(src, 30)
+
+	for _, line := range strings.Split(out, "\n") {
+		if w := ansi.StringWidth(line); w > 30 {
+			t.Errorf("line exceeds width (%d): %q", w, line)
+		}
+	}
+	// List continuation lines hang under the item text
+	if !strings.Contains(out, "\n  item") {
+		t.Errorf("expected hanging indent for wrapped list item\n%s", out)
+	}
+}
+
+func TestInlineUnclosedMarkersAreLiteral(t *testing.T) {
+	out := render("snake_case and *unclosed and `tick", 0)
+	if out != "snake_case and *unclosed and `tick" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestCodeBlockHighlighting(t *testing.T) {
+	r := New(theme.NewDraculaTheme())
+	lines := r.highlight("go", "package main\n\nfunc main() {}")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), lines)
+	}
+	if ansi.Strip(lines[0]) != "package main" {
+		t.Errorf("unexpected first line: %q", ansi.Strip(lines[0]))
+	}
+
+	// Unknown tags fall back to plain text without panicking
+	if lines := r.highlight("no-such-language", "x := 1"); ansi.Strip(strings.Join(lines, "\n")) != "x := 1" {
+		t.Errorf("unexpected fallback output: %q", lines)
+	}
+}
+
+func TestUnclosedFenceRendersAsCode(t *testing.T) {
+	out := render("```python\nprint('hi')", 80)
+	if !strings.Contains(out, "  print('hi')") || strings.Contains(out, "```") {
+		t.Errorf("expected open fence to render as code\n%s", out)
+	}
+}
+
+func TestSplitBlocksCompleteness(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		complete []bool
+	}{
+		{"trailing paragraph", "para", []bool{false}},
+		{"finished paragraph", "para\n\n", []bool{true}},
+		{"heading line", "# Title\n", []bool{true}},
+		{"unfinished heading", "# Tit", []bool{false}},
+		{"open fence", "```go\nx", []bool{false}},
+		{"closed fence", "```go\nx\n```\n", []bool{true}},
+		{"paragraph then partial line", "para\n---", []bool{false, false}},
+		{"paragraph then heading", "para\n# H\n", []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := splitBlocks(tt.src)
+			if len(blocks) != len(tt.complete) {
+				t.Fatalf("expected %d blocks, got %d", len(tt.complete), len(blocks))
+			}
+			for i, want := range tt.complete {
+				if blocks[i].complete != want {
+					t.Errorf("block %d: complete = %v, want %v", i, blocks[i].complete, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamMatchesFullRender(t *testing.T) {
+	r := New(theme.NewDraculaTheme())
+	r.SetWidth(40)
+	want := r.Render(sample)
+
+	// Feed the sample in awkward chunk sizes, checking every prefix
+	for _, size := range []int{1, 3, 7, 64} {
+		s := r.NewStream()
+		for i := 0; i < len(sample); i += size {
+			s.Append(sample[i:min(i+size, len(sample))])
+			if got, full := s.View(), r.Render(s.Source()); got != full {
+				t.Fatalf("chunk %d at %d: stream diverged from full render\nstream:\n%s\nfull:\n%s", size, i, got, full)
+			}
+		}
+		if s.View() != want {
+			t.Errorf("chunk %d: final stream output differs from full render", size)
+		}
+	}
+}
+
+func TestStreamCachesFinishedBlocks(t *testing.T) {
+	r := New(theme.NewDraculaTheme())
+	s := r.NewStream()
+	s.Append("first paragraph\n\n```go\nx := 1\n```\n\nstreaming")
+
+	if len(s.done) != 2 {
+		t.Errorf("expected 2 cached blocks, got %d", len(s.done))
+	}
+	if len(s.tail) != 1 {
+		t.Errorf("expected 1 unfinished block, got %d", len(s.tail))
+	}
+}
+
+func TestStreamRewrapsOnWidthChange(t *testing.T) {
+	r := New(theme.NewDraculaTheme())
+	s := r.NewStream()
+	s.Append(strings.Repeat("word ", 20) + "\n\nmore")
+
+	r.SetWidth(20)
+	if got, want := s.View(), r.Render(s.Source()); got != want {
+		t.Errorf("expected stream to re-wrap at new width\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
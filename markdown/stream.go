@@ -0,0 +1,92 @@
+package markdown
+
+import "strings"
+
+// Stream renders markdown that arrives in pieces, such as streamed model
+// output. Finished blocks are rendered once and cached; only the trailing
+// unfinished block is re-rendered on each Append. It is not safe for
+// concurrent use.
+type Stream struct {
+	r      *Renderer
+	src    []byte
+	offset int      // Bytes of src covered by done
+	done   []string // Rendered finished blocks
+	tail   []string // Rendered unfinished blocks
+	width  int      // Width done was rendered at
+}
+
+// NewStream creates an empty stream that renders with r.
+func (r *Renderer) NewStream() *Stream {
+	return &Stream{r: r, width: r.width}
+}
+
+// Append adds text and re-renders the unfinished part.
+func (s *Stream) Append(text string) {
+	s.src = append(s.src, text...)
+	s.advance()
+}
+
+// Len returns the length of the source text in bytes.
+func (s *Stream) Len() int {
+	return len(s.src)
+}
+
+// Source returns the markdown source appended so far.
+func (s *Stream) Source() string {
+	return string(s.src)
+}
+
+// View returns the rendered output. It is the same as rendering Source()
+// with the Renderer.
+func (s *Stream) View() string {
+	if s.width != s.r.width {
+		s.rerender()
+	}
+	if len(s.tail) == 0 {
+		return strings.Join(s.done, "\n\n")
+	}
+	parts := make([]string, 0, len(s.done)+len(s.tail))
+	parts = append(parts, s.done...)
+	parts = append(parts, s.tail...)
+	return strings.Join(parts, "\n\n")
+}
+
+// Reset clears the stream.
+func (s *Stream) Reset() {
+	s.src = s.src[:0]
+	s.offset = 0
+	s.done = nil
+	s.tail = nil
+	s.width = s.r.width
+}
+
+// rerender discards cached output, e.g. after the width changed.
+func (s *Stream) rerender() {
+	s.offset = 0
+	s.done = nil
+	s.width = s.r.width
+	s.advance()
+}
+
+// advance renders and caches newly finished blocks and re-renders the tail.
+func (s *Stream) advance() {
+	if s.width != s.r.width {
+		s.rerender()
+		return
+	}
+
+	base := s.offset
+	blocks := splitBlocks(string(s.src[base:]))
+
+	s.tail = s.tail[:0]
+	for i, b := range blocks {
+		if !b.complete {
+			for _, t := range blocks[i:] {
+				s.tail = append(s.tail, s.r.renderBlock(t))
+			}
+			return
+		}
+		s.done = append(s.done, s.r.renderBlock(b))
+		s.offset = base + b.end
+	}
+}
@@ -0,0 +1,185 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// alignment is a table column alignment.
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+var separatorRowRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// minColumnWidth is the narrowest a column is shrunk to fit the width.
+const minColumnWidth = 3
+
+func (r *Renderer) renderTable(b block) string {
+	var header []string
+	var aligns []alignment
+	var rows [][]string
+
+	for i, line := range b.lines {
+		if i == 1 && separatorRowRe.MatchString(line) {
+			aligns = parseAlignments(line)
+			header = rows[0]
+			rows = rows[:0]
+			continue
+		}
+		rows = append(rows, splitRow(line))
+	}
+
+	cols := len(header)
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	// Render cells first so widths account for inline markup
+	render := func(cells []string, header bool) []string {
+		out := make([]string, cols)
+		for i := range out {
+			if i >= len(cells) {
+				continue
+			}
+			style := r.styles.text
+			if header {
+				style = style.Bold(true)
+			}
+			out[i] = r.inline(cells[i], style)
+		}
+		return out
+	}
+
+	var renderedHeader []string
+	if header != nil {
+		renderedHeader = render(header, true)
+	}
+	renderedRows := make([][]string, len(rows))
+	for i, row := range rows {
+		renderedRows[i] = render(row, false)
+	}
+
+	widths := make([]int, cols)
+	for _, row := range append([][]string{renderedHeader}, renderedRows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+	r.fitColumns(widths)
+
+	sep := r.styles.border.Render(" │ ")
+	formatRow := func(cells []string) string {
+		parts := make([]string, cols)
+		for i := range parts {
+			align := alignLeft
+			if i < len(aligns) {
+				align = aligns[i]
+			}
+			parts[i] = pad(ansi.Truncate(cells[i], widths[i], "…"), widths[i], align)
+		}
+		return strings.TrimRight(strings.Join(parts, sep), " ")
+	}
+
+	var lines []string
+	if renderedHeader != nil {
+		lines = append(lines, formatRow(renderedHeader))
+		rule := make([]string, cols)
+		for i, w := range widths {
+			rule[i] = strings.Repeat("─", w)
+		}
+		lines = append(lines, r.styles.border.Render(strings.Join(rule, "─┼─")))
+	}
+	for _, row := range renderedRows {
+		lines = append(lines, formatRow(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitColumns shrinks the widest columns until the table fits the width.
+func (r *Renderer) fitColumns(widths []int) {
+	if r.width <= 0 {
+		return
+	}
+	avail := r.width - 3*(len(widths)-1)
+	for {
+		total, widest := 0, 0
+		for i, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= avail || widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// splitRow splits a table row into trimmed cells, honouring "\|" escapes.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// parseAlignments reads column alignments from a separator row.
+func parseAlignments(line string) []alignment {
+	cells := splitRow(line)
+	aligns := make([]alignment, len(cells))
+	for i, c := range cells {
+		left, right := strings.HasPrefix(c, ":"), strings.HasSuffix(c, ":")
+		switch {
+		case left && right:
+			aligns[i] = alignCenter
+		case right:
+			aligns[i] = alignRight
+		}
+	}
+	return aligns
+}
+
+// pad pads styled text to width with the given alignment.
+func pad(s string, width int, align alignment) string {
+	gap := width - ansi.StringWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + s
+	case alignCenter:
+		left := gap / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", gap-left)
+	default:
+		return s + strings.Repeat(" ", gap)
+	}
+}
@@ -0,0 +1,103 @@
+// permissions.go
+package tux
+
+import (
+	"fmt"
+
+	"github.com/2389-research/tux/policy"
+	"github.com/2389-research/tux/shell"
+)
+
+// Policy returns the approval policy store, or nil if none is configured.
+func (a *App) Policy() *policy.Store {
+	return a.config.policy
+}
+
+// policyDecision returns the remembered decision for an approval event.
+// The bool is false if no policy is configured or no rule matches.
+func (a *App) policyDecision(event Event) (ApprovalDecision, bool) {
+	store := a.config.policy
+	if store == nil {
+		return DecisionDeny, false
+	}
+	d, ok := store.Evaluate(event.ToolName, event.ToolParams)
+	if !ok {
+		return DecisionDeny, false
+	}
+	if d == policy.Allow {
+		return DecisionApprove, true
+	}
+	return DecisionDeny, true
+}
+
+// rememberDecision records "Always Allow" and "Never Allow" decisions in the
+// policy store and saves it in the background. Other decisions are ignored.
+// Reports whether a rule was added.
+func (a *App) rememberDecision(event Event, decision ApprovalDecision) bool {
+	store := a.config.policy
+	if store == nil {
+		return false
+	}
+
+	switch decision {
+	case DecisionAlwaysAllow:
+		store.Remember(event.ToolName, event.ToolParams, policy.Allow)
+	case DecisionNeverAllow:
+		store.Remember(event.ToolName, event.ToolParams, policy.Deny)
+	default:
+		return false
+	}
+
+	a.savePolicy()
+	return true
+}
+
+// savePolicy saves the policy store off the update loop, after any earlier
+// save.
+func (a *App) savePolicy() {
+	store := a.config.policy
+	a.writes.enqueue(func() {
+		if err := store.Save(); err != nil {
+			a.reportError(fmt.Errorf("saving permissions: %w", err))
+		}
+	})
+}
+
+// ShowPermissions opens a list of remembered approval rules.
+// Selecting a rule revokes it, so the user is asked again next time.
+func (a *App) ShowPermissions() {
+	store := a.config.policy
+	if store == nil {
+		return
+	}
+
+	rules := store.Rules()
+	items := make([]shell.ListItem, len(rules))
+	for i, r := range rules {
+		verb := "Always allow"
+		if r.Decision == policy.Deny {
+			verb = "Never allow"
+		}
+		items[i] = shell.ListItem{
+			ID:          fmt.Sprintf("rule-%d", i),
+			Title:       fmt.Sprintf("%s %s", verb, r.Tool),
+			Description: r.Describe(),
+			Value:       r,
+		}
+	}
+
+	modal := shell.NewListModal(shell.ListModalConfig{
+		ID:         "permissions",
+		Title:      "Permissions (enter to revoke)",
+		Items:      items,
+		Filterable: true,
+		OnSelect: func(item shell.ListItem) {
+			rule, ok := item.Value.(policy.Rule)
+			if !ok || !store.Remove(rule) {
+				return
+			}
+			a.savePolicy()
+		},
+	})
+	a.shell.PushModal(modal)
+}
@@ -0,0 +1,164 @@
+// permissions_test.go
+package tux
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/policy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPolicyResolvesApprovalWithoutModal(t *testing.T) {
+	store := policy.NewStore("")
+	store.Add(policy.Rule{Tool: "read", Decision: policy.Allow})
+	store.Add(policy.Rule{Tool: "delete", Decision: policy.Deny})
+
+	app := New(&mockAgent{events: make(chan Event)}, WithPolicy(store))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	cases := map[string]ApprovalDecision{
+		"read":   DecisionApprove,
+		"delete": DecisionDeny,
+	}
+	for tool, want := range cases {
+		response := make(chan ApprovalDecision, 1)
+		app.processEvent(Event{Type: EventApproval, ToolID: tool, ToolName: tool, Response: response})
+
+		if app.shell.HasModal() {
+			t.Fatalf("%s: remembered decision should not show modal", tool)
+		}
+		select {
+		case d := <-response:
+			if d != want {
+				t.Errorf("%s: expected %v, got %v", tool, want, d)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("%s: expected decision on response channel", tool)
+		}
+	}
+}
+
+func TestAlwaysAllowIsRemembered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), policy.FileName)
+	store := policy.NewStore(path)
+
+	app := New(&mockAgent{events: make(chan Event)}, WithPolicy(store))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	response := make(chan ApprovalDecision, 1)
+	app.processEvent(Event{
+		Type:       EventApproval,
+		ToolID:     "t1",
+		ToolName:   "bash",
+		ToolParams: map[string]any{"command": "ls"},
+		Response:   response,
+	})
+
+	// Select "Always Allow" (third option)
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyDown})
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyDown})
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if d := <-response; d != DecisionAlwaysAllow {
+		t.Fatalf("expected DecisionAlwaysAllow, got %v", d)
+	}
+
+	// Saved to disk asynchronously
+	deadline := time.Now().Add(time.Second)
+	for {
+		loaded, err := policy.Load(path)
+		if err == nil && len(loaded.Rules()) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected rule to be saved")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if d, ok := store.Evaluate("bash", map[string]any{"command": "pwd"}); !ok || d != policy.Allow {
+		t.Errorf("expected bash to be allowed, got %q %v", d, ok)
+	}
+}
+
+func TestAlwaysAllowResolvesWaitingApprovals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), policy.FileName)
+	store := policy.NewStore(path)
+
+	app := New(&mockAgent{events: make(chan Event)}, WithPolicy(store))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	responses := make([]chan ApprovalDecision, 3)
+	for i, tool := range []string{"bash", "read", "bash"} {
+		responses[i] = make(chan ApprovalDecision, 1)
+		app.processEvent(Event{
+			Type:       EventApproval,
+			ToolID:     fmt.Sprintf("t%d", i),
+			ToolName:   tool,
+			ToolParams: map[string]any{"command": "ls"},
+			Response:   responses[i],
+		})
+	}
+
+	// Always allow the first bash call
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyDown})
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyDown})
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	<-responses[0]
+
+	// The second bash call is answered by the new rule while read waits
+	select {
+	case d := <-responses[2]:
+		if d != DecisionApprove {
+			t.Errorf("expected the waiting bash call approved, got %v", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting bash call answered by the rule")
+	}
+	if got := app.approvals.events[app.approvals.queue.Current()].ToolName; got != "read" {
+		t.Errorf("expected read shown next, got %s", got)
+	}
+
+	// Answering read closes the queue
+	press(app, tea.KeyMsg{Type: tea.KeyEnter})
+	if d := <-responses[1]; d != DecisionApprove {
+		t.Errorf("expected read approved, got %v", d)
+	}
+	if app.shell.HasModal() {
+		t.Error("expected the queue closed")
+	}
+
+	app.flushWrites()
+	if loaded, err := policy.Load(path); err != nil || len(loaded.Rules()) != 1 {
+		t.Errorf("expected the rule saved, got %v", err)
+	}
+}
+
+func TestShowPermissionsRevokesRule(t *testing.T) {
+	store := policy.NewStore("")
+	store.Add(policy.Rule{Tool: "bash", Decision: policy.Allow})
+
+	app := New(&mockAgent{events: make(chan Event)}, WithPolicy(store))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	app.ShowPermissions()
+	if !app.shell.HasModal() {
+		t.Fatal("expected permissions modal")
+	}
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if len(store.Rules()) != 0 {
+		t.Error("expected rule to be revoked")
+	}
+}
+
+func TestShowPermissionsWithoutPolicy(t *testing.T) {
+	app := New(&mockAgent{events: make(chan Event)})
+	app.ShowPermissions()
+	if app.shell.HasModal() {
+		t.Error("expected no modal without a policy store")
+	}
+}
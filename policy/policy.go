@@ -0,0 +1,173 @@
+// Package policy remembers "Always Allow" and "Never Allow" tool approval
+// decisions so users are not asked again.
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Decision is the remembered outcome for matching tool calls.
+type Decision string
+
+const (
+	// Allow approves matching tool calls without asking.
+	Allow Decision = "allow"
+	// Deny rejects matching tool calls without asking.
+	Deny Decision = "deny"
+)
+
+// MatchKind selects how a rule's pattern is compared to a parameter value.
+type MatchKind string
+
+const (
+	// MatchExact requires the parameter value to equal the pattern.
+	MatchExact MatchKind = "exact"
+	// MatchPrefix requires the parameter value to start with the pattern
+	// and to hold a single command, without shell metacharacters.
+	MatchPrefix MatchKind = "prefix"
+	// MatchGlob matches the parameter value against a filepath glob.
+	MatchGlob MatchKind = "glob"
+)
+
+// Rule is a remembered decision for a tool, optionally narrowed to calls
+// whose parameter matches a pattern.
+type Rule struct {
+	Tool     string    `toml:"tool"`
+	Param    string    `toml:"param,omitempty"`
+	Match    MatchKind `toml:"match,omitempty"`
+	Pattern  string    `toml:"pattern,omitempty"`
+	Decision Decision  `toml:"decision"`
+	Created  time.Time `toml:"created"`
+}
+
+// Matches returns true if the rule applies to the given tool call.
+// A rule without a Param applies to every call of its tool.
+func (r Rule) Matches(tool string, params map[string]any) bool {
+	if r.Tool != tool {
+		return false
+	}
+	if r.Param == "" {
+		return true
+	}
+
+	raw, ok := params[r.Param]
+	if !ok {
+		return false
+	}
+	value := fmt.Sprint(raw)
+
+	switch r.Match {
+	case MatchPrefix:
+		// "git " also covers a bare "git"
+		prefixed := strings.HasPrefix(value, r.Pattern) || value == strings.TrimSuffix(r.Pattern, " ")
+		return prefixed && !chainsCommands(value)
+	case MatchGlob:
+		// "src/.." would match "src/*" while naming its parent
+		value = filepath.Clean(value)
+		if slices.Contains(strings.Split(filepath.ToSlash(value), "/"), "..") {
+			return false
+		}
+		matched, err := filepath.Match(r.Pattern, value)
+		return err == nil && matched
+	default:
+		return value == r.Pattern
+	}
+}
+
+// shellMetachars chain, nest, background or redirect shell commands, so a
+// command containing one may do more than its first word suggests.
+const shellMetachars = ";&|<>()`$\n\r"
+
+// chainsCommands reports whether a command contains a shell metacharacter.
+func chainsCommands(command string) bool {
+	return strings.ContainsAny(command, shellMetachars)
+}
+
+// Describe returns a short human-readable summary of what the rule covers.
+func (r Rule) Describe() string {
+	if r.Param == "" {
+		return "all calls"
+	}
+	switch r.Match {
+	case MatchPrefix:
+		return fmt.Sprintf("%s starting with %q", r.Param, r.Pattern)
+	case MatchGlob:
+		return fmt.Sprintf("%s matching %q", r.Param, r.Pattern)
+	default:
+		return fmt.Sprintf("%s = %q", r.Param, r.Pattern)
+	}
+}
+
+// sameScope returns true if two rules cover the same calls.
+func (r Rule) sameScope(other Rule) bool {
+	return r.Tool == other.Tool &&
+		r.Param == other.Param &&
+		r.Match == other.Match &&
+		r.Pattern == other.Pattern
+}
+
+// Scope builds the rule recorded when the user picks "Always Allow" or
+// "Never Allow" for a tool call. Only Tool, Param, Match and Pattern are used.
+type Scope func(tool string, params map[string]any) Rule
+
+// ToolScope records decisions for every call of the tool.
+func ToolScope(tool string, params map[string]any) Rule {
+	return Rule{Tool: tool}
+}
+
+// CommandPrefixScope records decisions for calls whose param starts with the
+// same first word, e.g. "git status" covers every "git ..." command.
+// Commands with shell metacharacters are recorded exactly.
+// Falls back to ToolScope if the param is missing or empty.
+func CommandPrefixScope(param string) Scope {
+	return func(tool string, params map[string]any) Rule {
+		raw, ok := params[param]
+		if !ok {
+			return ToolScope(tool, params)
+		}
+		command := fmt.Sprint(raw)
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return ToolScope(tool, params)
+		}
+		if chainsCommands(command) {
+			return Rule{Tool: tool, Param: param, Match: MatchExact, Pattern: command}
+		}
+		return Rule{Tool: tool, Param: param, Match: MatchPrefix, Pattern: fields[0] + " "}
+	}
+}
+
+// DirScope records decisions for calls whose path param is in the same
+// directory, e.g. "src/main.go" covers "src/*".
+// Falls back to ToolScope if the param is missing or empty.
+func DirScope(param string) Scope {
+	return func(tool string, params map[string]any) Rule {
+		raw, ok := params[param]
+		if !ok || fmt.Sprint(raw) == "" {
+			return ToolScope(tool, params)
+		}
+		dir := filepath.Dir(fmt.Sprint(raw))
+		return Rule{Tool: tool, Param: param, Match: MatchGlob, Pattern: filepath.Join(escapeGlob(dir), "*")}
+	}
+}
+
+// escapeGlob quotes the characters filepath.Match treats specially, so a
+// path matches only itself.
+func escapeGlob(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch {
+		case r == '*' || r == '?' || r == '[':
+			b.WriteString("[" + string(r) + "]")
+		case r == '\\' && filepath.Separator != '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
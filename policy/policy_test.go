@@ -0,0 +1,265 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		tool   string
+		params map[string]any
+		want   bool
+	}{
+		{"tool-wide", Rule{Tool: "bash"}, "bash", nil, true},
+		{"other tool", Rule{Tool: "bash"}, "read", nil, false},
+		{"exact", Rule{Tool: "read", Param: "path", Pattern: "a.txt"}, "read", map[string]any{"path": "a.txt"}, true},
+		{"exact miss", Rule{Tool: "read", Param: "path", Pattern: "a.txt"}, "read", map[string]any{"path": "b.txt"}, false},
+		{"prefix", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status"}, true},
+		{"prefix bare command", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "ls "}, "bash", map[string]any{"command": "ls"}, true},
+		{"prefix longer word", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "ls "}, "bash", map[string]any{"command": "lsof"}, false},
+		{"prefix miss", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "rm -rf /"}, false},
+		{"prefix chained", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status; rm -rf /"}, false},
+		{"prefix and", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status && rm -rf /"}, false},
+		{"prefix piped", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git log | sh"}, false},
+		{"prefix substitution", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git commit -m $(rm -rf /)"}, false},
+		{"prefix backticks", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git commit -m `rm -rf /`"}, false},
+		{"prefix newline", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status\nrm -rf /"}, false},
+		{"prefix background", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status & rm -rf ~"}, false},
+		{"prefix redirect out", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git log > ~/.bashrc"}, false},
+		{"prefix redirect in", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git apply < /tmp/patch"}, false},
+		{"prefix process substitution", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git apply <(curl x)"}, false},
+		{"prefix subshell", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status (rm -rf ~)"}, false},
+		{"prefix carriage return", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git status\rrm -rf ~"}, false},
+		{"prefix variable", Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}, "bash", map[string]any{"command": "git log $HOME"}, false},
+		{"glob", Rule{Tool: "write", Param: "path", Match: MatchGlob, Pattern: "src/*.go"}, "write", map[string]any{"path": "src/main.go"}, true},
+		{"glob miss", Rule{Tool: "write", Param: "path", Match: MatchGlob, Pattern: "src/*.go"}, "write", map[string]any{"path": "etc/passwd"}, false},
+		{"missing param", Rule{Tool: "write", Param: "path", Pattern: "x"}, "write", map[string]any{}, false},
+		{"non-string param", Rule{Tool: "sleep", Param: "seconds", Pattern: "5"}, "sleep", map[string]any{"seconds": 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.tool, tt.params); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreEvaluateDenyWins(t *testing.T) {
+	s := NewStore("")
+	s.Add(Rule{Tool: "bash", Decision: Allow})
+	s.Add(Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "rm ", Decision: Deny})
+
+	if d, ok := s.Evaluate("bash", map[string]any{"command": "ls"}); !ok || d != Allow {
+		t.Errorf("expected allow for ls, got %q %v", d, ok)
+	}
+	if d, ok := s.Evaluate("bash", map[string]any{"command": "rm -rf /"}); !ok || d != Deny {
+		t.Errorf("expected deny for rm, got %q %v", d, ok)
+	}
+	if _, ok := s.Evaluate("read", nil); ok {
+		t.Error("expected no decision for unknown tool")
+	}
+}
+
+func TestStoreRememberReplacesSameScope(t *testing.T) {
+	s := NewStore("")
+	s.Remember("bash", nil, Allow)
+	s.Remember("bash", nil, Deny)
+
+	rules := s.Rules()
+	if len(rules) != 1 {
+		t.Fatalf("expected 1 rule, got %d", len(rules))
+	}
+	if rules[0].Decision != Deny {
+		t.Errorf("expected later decision to win, got %q", rules[0].Decision)
+	}
+	if rules[0].Created.IsZero() {
+		t.Error("expected Created to be set")
+	}
+}
+
+func TestStoreRememberUsesScope(t *testing.T) {
+	s := NewStore("")
+	s.SetScope("bash", CommandPrefixScope("command"))
+	s.SetScope("write", DirScope("path"))
+
+	s.Remember("bash", map[string]any{"command": "git status"}, Allow)
+	s.Remember("write", map[string]any{"path": "src/main.go"}, Allow)
+
+	if _, ok := s.Evaluate("bash", map[string]any{"command": "git push"}); !ok {
+		t.Error("expected git prefix rule to cover git push")
+	}
+	if _, ok := s.Evaluate("bash", map[string]any{"command": "make"}); ok {
+		t.Error("expected git prefix rule not to cover make")
+	}
+	if _, ok := s.Evaluate("write", map[string]any{"path": "src/util.go"}); !ok {
+		t.Error("expected dir rule to cover sibling file")
+	}
+	if _, ok := s.Evaluate("write", map[string]any{"path": "docs/a.md"}); ok {
+		t.Error("expected dir rule not to cover other directory")
+	}
+}
+
+func TestCommandPrefixScopeKeepsChainedCommandsExact(t *testing.T) {
+	s := NewStore("")
+	s.SetScope("bash", CommandPrefixScope("command"))
+	s.Remember("bash", map[string]any{"command": "make && make test"}, Allow)
+
+	if r := s.Rules()[0]; r.Match != MatchExact || r.Pattern != "make && make test" {
+		t.Errorf("expected the exact command recorded, got %+v", r)
+	}
+	if _, ok := s.Evaluate("bash", map[string]any{"command": "make && make test"}); !ok {
+		t.Error("expected the same command covered")
+	}
+	if _, ok := s.Evaluate("bash", map[string]any{"command": "make && rm -rf /"}); ok {
+		t.Error("expected other chained commands not covered")
+	}
+}
+
+func TestCommandPrefixScopeCoversBareCommand(t *testing.T) {
+	s := NewStore("")
+	s.SetScope("bash", CommandPrefixScope("command"))
+	s.Remember("bash", map[string]any{"command": "ls"}, Allow)
+
+	for _, command := range []string{"ls", "ls -la"} {
+		if _, ok := s.Evaluate("bash", map[string]any{"command": command}); !ok {
+			t.Errorf("expected %q covered by the rule for ls", command)
+		}
+	}
+}
+
+func TestDirScopeCoversOnlyItsDirectory(t *testing.T) {
+	s := NewStore("")
+	s.SetScope("write", DirScope("path"))
+	s.Remember("write", map[string]any{"path": "src/main.go"}, Allow)
+	s.Remember("write", map[string]any{"path": "a[b]*/x.go"}, Allow)
+
+	tests := map[string]bool{
+		"src/util.go":       true,
+		"src/./util.go":     true,
+		"src/..":            false,
+		"src/../etc/passwd": false,
+		"a[b]*/y.go":        true,
+		"ab*/y.go":          false,
+		"aX*/y.go":          false,
+		"a[b]yz/y.go":       false,
+	}
+	for path, want := range tests {
+		if _, ok := s.Evaluate("write", map[string]any{"path": path}); ok != want {
+			t.Errorf("%s: covered = %v, want %v", path, ok, want)
+		}
+	}
+}
+
+func TestScopeFallsBackToTool(t *testing.T) {
+	r := CommandPrefixScope("command")("bash", map[string]any{})
+	if r.Param != "" {
+		t.Errorf("expected tool-wide rule, got %+v", r)
+	}
+	r = DirScope("path")("write", map[string]any{"path": ""})
+	if r.Param != "" {
+		t.Errorf("expected tool-wide rule, got %+v", r)
+	}
+}
+
+func TestStoreRemove(t *testing.T) {
+	s := NewStore("")
+	rule := s.Remember("bash", nil, Allow)
+
+	if !s.Remove(rule) {
+		t.Error("expected rule to be removed")
+	}
+	if s.Remove(rule) {
+		t.Error("expected second remove to report false")
+	}
+	if _, ok := s.Evaluate("bash", nil); ok {
+		t.Error("expected no decision after removal")
+	}
+}
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+
+	s := NewStore(path)
+	s.Add(Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git ", Decision: Allow})
+	s.Add(Rule{Tool: "delete", Decision: Deny})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	rules := loaded.Rules()
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if rules[0].Pattern != "git " || rules[0].Match != MatchPrefix {
+		t.Errorf("unexpected first rule: %+v", rules[0])
+	}
+	if d, ok := loaded.Evaluate("delete", nil); !ok || d != Deny {
+		t.Errorf("expected deny for delete, got %q %v", d, ok)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Rules()) != 0 {
+		t.Error("expected empty store")
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("not [valid toml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for invalid file")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// No config file: XDG dir
+	want := filepath.Join(dir, "policytest", FileName)
+	if got := DefaultPath("policytest"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// Config file from env var: same directory
+	custom := filepath.Join(dir, "custom")
+	if err := os.MkdirAll(custom, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(custom, "ui.toml")
+	if err := os.WriteFile(cfgPath, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("POLICYTEST_UI_CONFIG", cfgPath)
+	want = filepath.Join(custom, FileName)
+	if got := DefaultPath("policytest"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRuleDescribe(t *testing.T) {
+	if got := (Rule{Tool: "bash"}).Describe(); got != "all calls" {
+		t.Errorf("unexpected description: %s", got)
+	}
+	r := Rule{Tool: "bash", Param: "command", Match: MatchPrefix, Pattern: "git "}
+	if got := r.Describe(); got != `command starting with "git "` {
+		t.Errorf("unexpected description: %s", got)
+	}
+}
@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/2389-research/tux/config"
+	"github.com/BurntSushi/toml"
+)
+
+// FileName is the name of the permissions file written next to the UI config.
+const FileName = "permissions.toml"
+
+// Store holds approval rules and persists them as TOML.
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	rules  []Rule
+	scopes map[string]Scope
+}
+
+// file is the on-disk layout of the permissions file.
+type file struct {
+	Rules []Rule `toml:"rules"`
+}
+
+// NewStore creates an empty store that saves to path.
+// An empty path keeps the rules in memory only.
+func NewStore(path string) *Store {
+	return &Store{
+		path:   path,
+		scopes: make(map[string]Scope),
+	}
+}
+
+// Load reads the rules at path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := NewStore(path)
+
+	var f file
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return s, err
+	}
+	s.rules = f.Rules
+	return s, nil
+}
+
+// DefaultPath returns where the permissions file for appName lives:
+// next to the app's UI config file, or in its XDG config directory if the
+// app has no config file (or only a legacy ~/.appnamerc).
+func DefaultPath(appName string) string {
+	if p := config.Path(appName); p != "" && filepath.Base(p) != "."+appName+"rc" {
+		return filepath.Join(filepath.Dir(p), FileName)
+	}
+	return filepath.Join(config.Dir(appName), FileName)
+}
+
+// Path returns the file the store saves to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SetScope sets how "Always"/"Never" decisions for a tool are keyed.
+// Tools without a scope use ToolScope.
+func (s *Store) SetScope(tool string, scope Scope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes[tool] = scope
+}
+
+// Evaluate returns the remembered decision for a tool call.
+// Deny rules take precedence over allow rules. The bool is false if no
+// rule matches.
+func (s *Store) Evaluate(tool string, params map[string]any) (Decision, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for _, r := range s.rules {
+		if !r.Matches(tool, params) {
+			continue
+		}
+		if r.Decision == Deny {
+			return Deny, true
+		}
+		found = true
+	}
+	if found {
+		return Allow, true
+	}
+	return "", false
+}
+
+// Remember records a decision for a tool call using the tool's scope,
+// replacing any rule with the same scope.
+func (s *Store) Remember(tool string, params map[string]any, decision Decision) Rule {
+	s.mu.Lock()
+	scope, ok := s.scopes[tool]
+	s.mu.Unlock()
+	if !ok {
+		scope = ToolScope
+	}
+
+	rule := scope(tool, params)
+	rule.Tool = tool
+	rule.Decision = decision
+	s.Add(rule)
+	return rule
+}
+
+// Add adds a rule, replacing any rule with the same scope.
+func (s *Store) Add(rule Rule) {
+	if rule.Created.IsZero() {
+		rule.Created = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.rules {
+		if s.rules[i].sameScope(rule) {
+			s.rules[i] = rule
+			return
+		}
+	}
+	s.rules = append(s.rules, rule)
+}
+
+// Remove removes the rule with the same scope as rule.
+// Returns false if no such rule exists.
+func (s *Store) Remove(rule Rule) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.rules {
+		if s.rules[i].sameScope(rule) {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns a copy of all rules in insertion order.
+func (s *Store) Rules() []Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Rule, len(s.rules))
+	copy(result, s.rules)
+	return result
+}
+
+// Save writes the rules to the store's path, creating the directory if
+// needed. It is a no-op for in-memory stores.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	f := file{Rules: make([]Rule, len(s.rules))}
+	copy(f.Rules, s.rules)
+	s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a crash never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".permissions-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := toml.NewEncoder(tmp).Encode(f); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
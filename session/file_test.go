@@ -0,0 +1,130 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreAppendAndLoad(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "sessions"))
+	now := time.Now().UTC().Truncate(time.Second)
+
+	recs := []Record{
+		{Type: RecordUser, Time: now, Content: "hello"},
+		{Type: RecordToolCall, Time: now, ToolID: "t1", ToolName: "read", Params: map[string]any{"path": "a.txt"}},
+		{Type: RecordToolResult, Time: now, ToolID: "t1", Content: "contents", Success: true},
+		{Type: RecordAssistant, Time: now, Content: "done"},
+	}
+	for _, rec := range recs {
+		if err := s.Append("s1", rec); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	loaded, err := s.Load("s1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != len(recs) {
+		t.Fatalf("expected %d records, got %d", len(recs), len(loaded))
+	}
+	if loaded[1].Params["path"] != "a.txt" {
+		t.Errorf("expected params to round-trip, got %v", loaded[1].Params)
+	}
+	if !loaded[2].Success || !loaded[0].Time.Equal(now) {
+		t.Errorf("unexpected records: %+v", loaded)
+	}
+}
+
+func TestFileStoreLoadMissing(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	recs, err := s.Load("nope")
+	if err != nil || len(recs) != 0 {
+		t.Errorf("expected no records and no error, got %v %v", recs, err)
+	}
+}
+
+func TestFileStoreSnapshotReplaces(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	if err := s.Append("s1", Record{Type: RecordUser, Content: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Snapshot("s1", []Record{{Type: RecordUser, Content: "new"}}); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	loaded, err := s.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Content != "new" {
+		t.Errorf("expected snapshot to replace contents, got %+v", loaded)
+	}
+}
+
+func TestFileStoreRejectsInvalidID(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	for _, id := range []string{"", "..", "../escape", `a\b`} {
+		if err := s.Append(id, Record{Type: RecordUser}); err == nil {
+			t.Errorf("expected error for id %q", id)
+		}
+	}
+}
+
+func TestFileStoreLoadInvalidLine(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.jsonl"), []byte("{\"type\":\"user\"}\nnot json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := NewFileStore(dir).Load("bad")
+	if err == nil {
+		t.Error("expected error for invalid line")
+	}
+	if len(recs) != 1 {
+		t.Errorf("expected records before the bad line, got %d", len(recs))
+	}
+}
+
+func TestFileStoreList(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStore(dir)
+	for _, id := range []string{"older", "newer"} {
+		if err := s.Append(id, Record{Type: RecordUser}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "older.jsonl"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "newer" || ids[1] != "older" {
+		t.Errorf("expected [newer older], got %v", ids)
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	want := filepath.Join(dir, "myapp", "sessions")
+	if got := DefaultDir("myapp"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestNewIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		id := NewID()
+		if seen[id] {
+			t.Fatalf("expected distinct IDs, got %s twice", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,93 @@
+// Package session persists conversation transcripts so they can be resumed.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RecordType identifies the kind of transcript record.
+type RecordType string
+
+const (
+	// RecordUser is a prompt submitted by the user.
+	RecordUser RecordType = "user"
+	// RecordAssistant is a completed assistant message.
+	RecordAssistant RecordType = "assistant"
+	// RecordToolCall is a tool invocation.
+	RecordToolCall RecordType = "tool_call"
+	// RecordToolResult is the result of a tool invocation.
+	RecordToolResult RecordType = "tool_result"
+	// RecordError is an error reported during a run.
+	RecordError RecordType = "error"
+	// RecordUsage is the token usage and cost of a finished turn.
+	RecordUsage RecordType = "usage"
+)
+
+// Record is a single entry in a session transcript.
+type Record struct {
+	Type        RecordType     `json:"type"`
+	Time        time.Time      `json:"time"`
+	Content     string         `json:"content,omitempty"`     // Message text, tool output or error text
+	Attachments []Attachment   `json:"attachments,omitempty"` // Files attached to a RecordUser
+	Thinking    string         `json:"thinking,omitempty"`    // Reasoning behind a RecordAssistant
+	ToolID      string         `json:"tool_id,omitempty"`     // For tool records
+	ToolName    string         `json:"tool_name,omitempty"`   // For RecordToolCall
+	Params      map[string]any `json:"params,omitempty"`      // For RecordToolCall
+	Success     bool           `json:"success,omitempty"`     // For RecordToolResult
+	Model       string         `json:"model,omitempty"`       // For RecordUsage
+	Usage       *Usage         `json:"usage,omitempty"`       // For RecordUsage
+}
+
+// Attachment references a file attached to a prompt. Its content is not
+// kept.
+type Attachment struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// Usage is the token usage and cost of a turn.
+type Usage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CacheHits    int     `json:"cache_hits,omitempty"` // Input tokens read from cache
+	Cost         float64 `json:"cost,omitempty"`       // USD
+}
+
+// Store persists session transcripts by ID.
+type Store interface {
+	// Append adds a record to the end of the session, creating it if needed.
+	Append(id string, rec Record) error
+
+	// Load returns all records of a session in order.
+	// A session that does not exist yields no records and no error.
+	Load(id string) ([]Record, error)
+
+	// Snapshot replaces the session's contents with the given records.
+	Snapshot(id string, recs []Record) error
+}
+
+// DefaultDir returns the directory where sessions for appName are kept:
+// $XDG_DATA_HOME/appname/sessions, falling back to
+// ~/.local/share/appname/sessions. The directory is not created.
+func DefaultDir(appName string) string {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		home, _ := os.UserHomeDir()
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataDir, appName, "sessions")
+}
+
+// NewID returns a session ID derived from the current time, with a random
+// suffix so sessions started in the same second get distinct IDs,
+// e.g. "20260117-153042-9f2c41".
+func NewID() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,191 @@
+// sessions.go
+package tux
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/session"
+)
+
+// SessionStore is a re-export of session.Store for API convenience.
+type SessionStore = session.Store
+
+// SessionRecord is a re-export of session.Record for API convenience.
+type SessionRecord = session.Record
+
+// NewFileSessionStore creates a JSONL session store rooted at dir.
+// Use session.DefaultDir(appName) for the standard XDG location.
+func NewFileSessionStore(dir string) *session.FileStore {
+	return session.NewFileStore(dir)
+}
+
+// SessionID returns the ID of the current session, or "" if the
+// conversation is not being recorded yet.
+func (a *App) SessionID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sessionID
+}
+
+// SaveSession writes the full transcript to the session store, replacing
+// whatever was stored for the session. If no session ID is set, a new one is
+// generated and subsequent events are recorded under it.
+func (a *App) SaveSession() error {
+	store := a.config.sessionStore
+	if store == nil {
+		return errors.New("no session store configured")
+	}
+
+	// Queued under the lock, so records added after the copy are appended
+	// after the snapshot replaces the file
+	done := make(chan error, 1)
+	a.mu.Lock()
+	if a.sessionID == "" {
+		a.sessionID = session.NewID()
+	}
+	id := a.sessionID
+	recs := make([]session.Record, len(a.records))
+	copy(recs, a.records)
+	a.writes.enqueue(func() { done <- store.Snapshot(id, recs) })
+	a.mu.Unlock()
+
+	return <-done
+}
+
+// saveSessionFromShortcut is the default Ctrl+S handler when a session
+// store is configured.
+func (a *App) saveSessionFromShortcut() {
+	a.Send(sessionSavedMsg{err: a.SaveSession()})
+}
+
+// record appends a transcript record and, if a session is active, persists
+// it in the background. Store failures are shown as errors but are not
+// themselves recorded.
+func (a *App) record(rec session.Record) {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	store := a.config.sessionStore
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = append(a.records, rec)
+	if store == nil || a.sessionID == "" {
+		return
+	}
+	id := a.sessionID
+	a.writes.enqueue(func() {
+		if err := store.Append(id, rec); err != nil {
+			a.Send(sessionErrorMsg{err: fmt.Errorf("saving session: %w", err)})
+		}
+	})
+}
+
+// restoreSession loads the configured session into the Chat and Tools tabs,
+// and into the conversation of a BackendAgent. Input history follows from
+// the restored user messages. Other agents keep their own history; only
+// the display is restored for them.
+func (a *App) restoreSession() {
+	store := a.config.sessionStore
+	if store == nil || a.sessionID == "" {
+		return
+	}
+
+	recs, err := store.Load(a.sessionID)
+	if err != nil {
+		a.addError(fmt.Errorf("loading session: %w", err))
+	}
+
+	var prompt string
+	for _, rec := range recs {
+		switch rec.Type {
+		case session.RecordUser:
+			prompt = rec.Content
+			a.chat.addUserMessage(rec.Content, attachmentRefs(rec.Attachments))
+		case session.RecordAssistant:
+			a.chat.addAssistantMessage(rec.Content, rec.Thinking)
+		case session.RecordToolCall:
+			a.tools.addToolCallAt(rec.ToolID, rec.ToolName, rec.Params, rec.Time)
+		case session.RecordToolResult:
+			a.tools.addToolResultAt(rec.ToolID, rec.Content, rec.Success, rec.Time)
+		case session.RecordUsage:
+			a.restoreUsage(rec, prompt)
+		}
+		// Errors are kept in the transcript but belong to past runs,
+		// so they are not shown again.
+	}
+
+	if ba, ok := a.agent.(*BackendAgent); ok {
+		ba.SetMessages(historyFromRecords(recs))
+	}
+
+	a.mu.Lock()
+	a.records = recs
+	a.mu.Unlock()
+	a.refreshUsageStatus()
+}
+
+// historyFromRecords rebuilds a conversation from a transcript. Each run's
+// tool calls become an assistant message followed by their results, ahead
+// of the run's answer; calls without a recorded result are answered with
+// an error, so every tool_use has its tool_result.
+func historyFromRecords(recs []session.Record) []agent.Message {
+	var msgs []agent.Message
+	var uses []agent.ContentBlock
+	results := make(map[string]agent.ToolResult)
+
+	flush := func() {
+		if len(uses) == 0 {
+			return
+		}
+		blocks := make([]agent.ContentBlock, len(uses))
+		for i, use := range uses {
+			result, ok := results[use.ToolUse.ID]
+			if !ok {
+				result = agent.ToolResult{ToolUseID: use.ToolUse.ID, Content: "no result recorded", IsError: true}
+			}
+			blocks[i] = agent.ContentBlock{Type: "tool_result", ToolResult: &result}
+		}
+		msgs = append(msgs,
+			agent.Message{Role: agent.RoleAssistant, ContentBlocks: uses},
+			agent.Message{Role: agent.RoleTool, ContentBlocks: blocks})
+		uses = nil
+		clear(results)
+	}
+
+	for _, rec := range recs {
+		switch rec.Type {
+		case session.RecordUser:
+			flush()
+			msgs = append(msgs, agent.Message{Role: agent.RoleUser, Content: rec.Content, Timestamp: rec.Time})
+		case session.RecordAssistant:
+			flush()
+			if rec.Content != "" {
+				msgs = append(msgs, agent.Message{
+					Role:          agent.RoleAssistant,
+					Content:       rec.Content,
+					ContentBlocks: []agent.ContentBlock{{Type: "text", Text: rec.Content}},
+					Timestamp:     rec.Time,
+				})
+			}
+		case session.RecordToolCall:
+			// A call after results starts the next turn's calls
+			if len(results) > 0 {
+				flush()
+			}
+			uses = append(uses, agent.ContentBlock{Type: "tool_use", ToolUse: &agent.ToolUse{
+				ID: rec.ToolID, Name: rec.ToolName, Input: rec.Params,
+			}})
+		case session.RecordToolResult:
+			if slices.ContainsFunc(uses, func(b agent.ContentBlock) bool { return b.ToolUse.ID == rec.ToolID }) {
+				results[rec.ToolID] = agent.ToolResult{ToolUseID: rec.ToolID, Content: rec.Content, IsError: !rec.Success}
+			}
+		}
+	}
+	flush()
+	return msgs
+}
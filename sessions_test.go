@@ -0,0 +1,228 @@
+// sessions_test.go
+package tux
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/session"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSessionRecordsAndRehydrates(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+
+	app := New(&mockAgent{events: make(chan Event)}, WithSessionStore(store), WithSession("s1"))
+	app.submitInput("list files")
+	app.processEvent(Event{Type: EventToolCall, ToolID: "t1", ToolName: "ls", ToolParams: map[string]any{"dir": "."}})
+	app.processEvent(Event{Type: EventToolResult, ToolID: "t1", ToolOutput: "a.go", Success: true})
+	app.processEvent(Event{Type: EventText, Text: "Found a.go"})
+	app.processEvent(Event{Type: EventError, Error: errors.New("boom")})
+	app.processEvent(Event{Type: EventComplete})
+
+	app.flushWrites()
+	recs, err := store.Load("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []session.RecordType{
+		session.RecordUser,
+		session.RecordToolCall,
+		session.RecordToolResult,
+		session.RecordError,
+		session.RecordAssistant,
+	}
+	if len(recs) != len(want) {
+		t.Fatalf("expected %d records, got %d: %+v", len(want), len(recs), recs)
+	}
+	for i, typ := range want {
+		if recs[i].Type != typ {
+			t.Errorf("record %d: expected %s, got %s", i, typ, recs[i].Type)
+		}
+	}
+
+	// A new App with the same session starts where the last one left off
+	resumed := New(&mockAgent{events: make(chan Event)}, WithSessionStore(store), WithSession("s1"))
+
+	msgs := resumed.chat.Value().([]chatMessage)
+	if len(msgs) != 2 || msgs[0].content != "list files" || msgs[1].content != "Found a.go" {
+		t.Errorf("unexpected chat messages: %+v", msgs)
+	}
+	items := resumed.tools.Value().([]toolItem)
+	if len(items) != 1 || !items[0].completed || items[0].output != "a.go" {
+		t.Errorf("unexpected tool items: %+v", items)
+	}
+	if !items[0].timestamp.Equal(recs[1].Time) {
+		t.Error("expected tool timestamp to be restored")
+	}
+	if h := resumed.chat.UserMessages(); len(h) != 1 || h[0] != "list files" {
+		t.Errorf("expected input history to be restored, got %v", h)
+	}
+	if len(resumed.errors) != 0 {
+		t.Errorf("expected past errors not to be shown, got %v", resumed.errors)
+	}
+}
+
+func TestSessionRestoresBackendHistory(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	for _, rec := range []session.Record{
+		{Type: session.RecordUser, Content: "list files"},
+		{Type: session.RecordToolCall, ToolID: "t1", ToolName: "ls", Params: map[string]any{"dir": "."}},
+		{Type: session.RecordToolCall, ToolID: "t2", ToolName: "cat"},
+		{Type: session.RecordToolResult, ToolID: "t1", Content: "a.go", Success: true},
+		{Type: session.RecordAssistant, Content: "Found a.go"},
+	} {
+		if err := store.Append("s1", rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := NewFromBackend(newScriptedBackend(), WithSessionStore(store), WithSession("s1"))
+	msgs := app.agent.(*BackendAgent).Messages()
+	roles := []agent.Role{agent.RoleUser, agent.RoleAssistant, agent.RoleTool, agent.RoleAssistant}
+	if len(msgs) != len(roles) {
+		t.Fatalf("expected %d messages, got %d: %+v", len(roles), len(msgs), msgs)
+	}
+	for i, role := range roles {
+		if msgs[i].Role != role {
+			t.Errorf("message %d: expected %s, got %s", i, role, msgs[i].Role)
+		}
+	}
+	if uses := msgs[1].ContentBlocks; len(uses) != 2 || uses[0].ToolUse.ID != "t1" || uses[1].ToolUse.ID != "t2" {
+		t.Errorf("expected both calls restored, got %+v", uses)
+	}
+	results := msgs[2].ContentBlocks
+	if len(results) != 2 || results[0].ToolResult.Content != "a.go" || results[0].ToolResult.IsError {
+		t.Fatalf("expected t1's result restored, got %+v", results)
+	}
+	if r := results[1].ToolResult; r.ToolUseID != "t2" || !r.IsError {
+		t.Errorf("expected the unanswered call closed with an error, got %+v", r)
+	}
+	if msgs[3].Content != "Found a.go" {
+		t.Errorf("expected the answer restored, got %q", msgs[3].Content)
+	}
+}
+
+func TestSaveSessionDefaultShortcut(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+
+	app := New(&mockAgent{events: make(chan Event)}, WithSessionStore(store))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	app.submitInput("hello")
+
+	if app.SessionID() != "" {
+		t.Fatal("expected no session before saving")
+	}
+
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+
+	deadline := time.Now().Add(time.Second)
+	for app.SessionID() == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("expected ctrl+s to save the session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	id := app.SessionID()
+	var recs []session.Record
+	for {
+		recs, _ = store.Load(id)
+		if len(recs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected snapshot with 1 record, got %d", len(recs))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+}
+
+func TestSaveSessionStartsRecording(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+
+	app := New(&mockAgent{events: make(chan Event)}, WithSessionStore(store))
+	app.submitInput("hello")
+	if err := app.SaveSession(); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	// Later events are appended to the saved session
+	app.processEvent(Event{Type: EventText, Text: "hi"})
+	app.processEvent(Event{Type: EventComplete})
+	app.flushWrites()
+	if recs, _ := store.Load(app.SessionID()); len(recs) != 2 {
+		t.Errorf("expected 2 records after completion, got %d", len(recs))
+	}
+}
+
+func TestSaveSessionKeepsConcurrentRecords(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	app := New(&mockAgent{events: make(chan Event)}, WithSessionStore(store), WithSession("s1"))
+
+	saved := make(chan error)
+	go func() { saved <- app.SaveSession() }()
+	for i := range 50 {
+		app.record(session.Record{Type: session.RecordAssistant, Content: fmt.Sprint(i)})
+	}
+	if err := <-saved; err != nil {
+		t.Fatal(err)
+	}
+	app.flushWrites()
+
+	if recs, _ := store.Load("s1"); len(recs) != 50 {
+		t.Errorf("expected every record kept across the snapshot, got %d", len(recs))
+	}
+}
+
+func TestWithSaveOverridesSessionDefault(t *testing.T) {
+	called := false
+	app := New(&mockAgent{events: make(chan Event)},
+		WithSessionStore(session.NewFileStore(t.TempDir())),
+		WithSave(func() { called = true }),
+	)
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+
+	if !called {
+		t.Error("expected custom save callback")
+	}
+}
+
+func TestSaveSessionWithoutStore(t *testing.T) {
+	app := New(&mockAgent{events: make(chan Event)})
+	if err := app.SaveSession(); err == nil {
+		t.Error("expected error without a session store")
+	}
+}
+
+func TestSessionKeepsThinking(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	app := New(&mockAgent{}, WithSessionStore(store), WithSession("s1"))
+
+	app.processEvent(Event{Type: EventThinking, Text: "weigh options"})
+	if !app.shell.Streaming().IsThinking() {
+		t.Error("expected reasoning to show the thinking spinner")
+	}
+	app.processEvent(Event{Type: EventText, Text: "Pick B."})
+	if app.shell.Streaming().IsThinking() {
+		t.Error("expected the answer to stop the thinking spinner")
+	}
+	app.processEvent(Event{Type: EventComplete})
+
+	app.flushWrites()
+	recs, _ := store.Load("s1")
+	if len(recs) != 1 || recs[0].Content != "Pick B." || recs[0].Thinking != "weigh options" {
+		t.Fatalf("expected answer recorded with its reasoning, got %+v", recs)
+	}
+
+	resumed := New(&mockAgent{}, WithSessionStore(store), WithSession("s1"))
+	msgs := resumed.chat.Value().([]chatMessage)
+	if len(msgs) != 1 || msgs[0].thinking != "weigh options" {
+		t.Errorf("expected reasoning restored, got %+v", msgs)
+	}
+}
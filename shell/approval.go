@@ -2,7 +2,9 @@ package shell
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/2389-research/tux/content"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -19,6 +21,8 @@ const (
 	DecisionAlwaysAllow
 	// DecisionNeverAllow remembers to never allow this tool.
 	DecisionNeverAllow
+	// DecisionEdit asks to edit the tool's parameters before running it.
+	DecisionEdit
 )
 
 // RiskLevel indicates the risk level of a tool.
@@ -39,6 +43,7 @@ type ToolInfo struct {
 	Name    string
 	Params  map[string]any
 	Preview string
+	Diff    *content.Diff // File change shown in a scrollable view instead of Preview
 	Risk    RiskLevel
 }
 
@@ -57,6 +62,9 @@ var DefaultApprovalOptions = []ApprovalOption{
 	{Label: "Never Allow", Decision: DecisionNeverAllow, Hint: "Block permanently"},
 }
 
+// EditApprovalOption is the option added by ApprovalModalConfig.Editable.
+var EditApprovalOption = ApprovalOption{Label: "Edit", Decision: DecisionEdit, Hint: "Change parameters, then run"}
+
 // ApprovalModal presents a tool approval request to the user.
 type ApprovalModal struct {
 	id         string
@@ -64,9 +72,12 @@ type ApprovalModal struct {
 	options    []ApprovalOption
 	selected   int
 	queueHint  string
+	footer     string
 	onDecision func(decision ApprovalDecision)
 	width      int
 	height     int
+	rows       rowMap // Option index of each rendered row
+	diffHeight int    // Rows given to the diff when last rendered
 
 	// Styles
 	boxStyle      lipgloss.Style
@@ -87,6 +98,8 @@ type ApprovalModalConfig struct {
 	Tool       ToolInfo
 	Options    []ApprovalOption
 	QueueHint  string
+	Footer     string // Extra key hints shown below the options
+	Editable   bool   // Offer EditApprovalOption, also chosen with "e"
 	OnDecision func(decision ApprovalDecision)
 }
 
@@ -96,11 +109,15 @@ func NewApprovalModal(cfg ApprovalModalConfig) *ApprovalModal {
 	if len(options) == 0 {
 		options = DefaultApprovalOptions
 	}
+	if cfg.Editable && !slices.ContainsFunc(options, func(o ApprovalOption) bool { return o.Decision == DecisionEdit }) {
+		options = append(slices.Clip(options), EditApprovalOption)
+	}
 	return &ApprovalModal{
 		id:         "approval-" + cfg.Tool.ID,
 		tool:       cfg.Tool,
 		options:    options,
 		queueHint:  cfg.QueueHint,
+		footer:     cfg.Footer,
 		onDecision: cfg.OnDecision,
 		boxStyle: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -138,8 +155,13 @@ func (m *ApprovalModal) ID() string { return m.id }
 // Title implements Modal.
 func (m *ApprovalModal) Title() string { return "Tool Approval" }
 
-// Size implements Modal.
-func (m *ApprovalModal) Size() Size { return SizeMedium }
+// Size implements Modal. Modals with a diff are large to show more of it.
+func (m *ApprovalModal) Size() Size {
+	if m.tool.Diff != nil {
+		return SizeLarge
+	}
+	return SizeMedium
+}
 
 // OnPush implements Modal.
 func (m *ApprovalModal) OnPush(width, height int) {
@@ -164,10 +186,21 @@ func (m *ApprovalModal) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
 		}
 		return true, nil
 	case tea.KeyEnter:
-		if m.onDecision != nil && m.selected >= 0 && m.selected < len(m.options) {
-			m.onDecision(m.options[m.selected].Decision)
+		return true, m.decide()
+	}
+
+	if diff := m.tool.Diff; diff != nil {
+		switch key.String() {
+		case "pgup", "shift+up":
+			diff.ScrollUp(m.diffStep(key))
+			return true, nil
+		case "pgdown", "shift+down":
+			diff.ScrollDown(m.diffStep(key))
+			return true, nil
+		case "s":
+			diff.ToggleLayout()
+			return true, nil
 		}
-		return true, func() tea.Msg { return PopMsg{} }
 	}
 
 	switch key.String() {
@@ -193,11 +226,66 @@ func (m *ApprovalModal) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
 			m.onDecision(DecisionDeny)
 		}
 		return true, func() tea.Msg { return PopMsg{} }
+	case "e":
+		for i, opt := range m.options {
+			if opt.Decision == DecisionEdit {
+				m.selected = i
+				return true, m.decide()
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// HandleMouse implements MouseHandler. Hovering highlights an option,
+// clicking decides with it and the wheel moves the selection.
+func (m *ApprovalModal) HandleMouse(msg tea.MouseMsg) (bool, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if m.selected > 0 {
+			m.selected--
+		}
+		return true, nil
+	case tea.MouseButtonWheelDown:
+		if m.selected < len(m.options)-1 {
+			m.selected++
+		}
+		return true, nil
 	}
 
+	option := m.rows.at(msg.Y - m.boxStyle.GetBorderTopSize() - m.boxStyle.GetPaddingTop())
+	if option < 0 {
+		return false, nil
+	}
+	switch {
+	case msg.Action == tea.MouseActionMotion:
+		m.selected = option
+		return true, nil
+	case isLeftClick(msg):
+		m.selected = option
+		return true, m.decide()
+	}
 	return false, nil
 }
 
+// diffStep is how far a key scrolls the diff: a line for shift+arrows, half
+// the view for page keys.
+func (m *ApprovalModal) diffStep(key tea.KeyMsg) int {
+	if key.Type == tea.KeyShiftUp || key.Type == tea.KeyShiftDown {
+		return 1
+	}
+	return max(m.diffHeight/2, 1)
+}
+
+// decide reports the selected option's decision and closes the modal.
+func (m *ApprovalModal) decide() tea.Cmd {
+	if m.onDecision != nil && m.selected >= 0 && m.selected < len(m.options) {
+		m.onDecision(m.options[m.selected].Decision)
+	}
+	return func() tea.Msg { return PopMsg{} }
+}
+
 // Render implements Modal.
 func (m *ApprovalModal) Render(width, height int) string {
 	var parts []string
@@ -237,7 +325,14 @@ func (m *ApprovalModal) Render(width, height int) string {
 	}
 
 	// Preview
-	if m.tool.Preview != "" {
+	if diff := m.tool.Diff; diff != nil {
+		// The diff gets the height the rest of the modal leaves
+		fixed := len(parts) + len(m.options) + 6 + m.boxStyle.GetVerticalFrameSize()
+		m.diffHeight = min(max(height-fixed, 5), diff.LineCount())
+		diff.SetSize(width-4-m.boxStyle.GetHorizontalFrameSize(), m.diffHeight)
+		parts = append(parts, "", diff.View(),
+			m.hintStyle.Render("pgup/pgdn scroll · s toggle side by side"))
+	} else if m.tool.Preview != "" {
 		parts = append(parts, "")
 		parts = append(parts, m.previewStyle.Render(m.tool.Preview))
 	}
@@ -259,6 +354,22 @@ func (m *ApprovalModal) Render(width, height int) string {
 		parts = append(parts, line)
 	}
 
+	// Record which rows hold the options for mouse hit-testing
+	textWidth := width - 4 - m.boxStyle.GetHorizontalPadding()
+	m.rows = m.rows[:0]
+	firstOption := len(parts) - len(m.options)
+	for i, part := range parts {
+		option := -1
+		if i >= firstOption {
+			option = i - firstOption
+		}
+		m.rows.add(part, textWidth, option)
+	}
+
+	if m.footer != "" {
+		parts = append(parts, "", m.hintStyle.Render(m.footer))
+	}
+
 	content := lipgloss.JoinVertical(lipgloss.Left, parts...)
 	return m.boxStyle.Width(width - 4).Render(content)
 }
@@ -273,6 +384,11 @@ func (m *ApprovalModal) SetQueueHint(hint string) {
 	m.queueHint = hint
 }
 
+// SetFooter sets the key hints shown below the options.
+func (m *ApprovalModal) SetFooter(footer string) {
+	m.footer = footer
+}
+
 // Selected returns the currently selected option index.
 func (m *ApprovalModal) Selected() int {
 	return m.selected
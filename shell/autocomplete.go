@@ -1,9 +1,14 @@
 package shell
 
 import (
+	"context"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/fuzzy"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,17 +19,27 @@ type CompletionProvider interface {
 	GetCompletions(input string) []Completion
 }
 
+// AsyncCompletionProvider generates completions off the update loop, for
+// providers that read the disk, ask a language server or call the agent
+// backend. ctx is cancelled once the query is superseded.
+type AsyncCompletionProvider interface {
+	// GetCompletionsAsync returns suggestions for the given input.
+	GetCompletionsAsync(ctx context.Context, input string) ([]Completion, error)
+}
+
 // Completion represents a single autocomplete suggestion.
 type Completion struct {
 	Value       string // What gets inserted
 	Display     string // What shows in the dropdown
 	Description string // Context about the completion
 	Score       int    // For ranking (higher = better)
+	Positions   []int  // Runes of Display matching the input, highlighted
 }
 
 // Autocomplete provides input completion with pluggable providers.
 type Autocomplete struct {
 	providers      map[string]CompletionProvider
+	asyncProviders map[string]AsyncCompletionProvider
 	completions    []Completion
 	selectedIndex  int
 	maxCompletions int
@@ -32,17 +47,30 @@ type Autocomplete struct {
 	input          string
 	activeProvider string
 
+	// Asynchronous queries
+	loading bool               // Whether the active query's results are awaited
+	seq     uint64             // Latest query or keystroke; older results are dropped
+	cancel  context.CancelFunc // Cancels the running query
+
+	// Settings from config.AutocompleteConfig
+	disabled bool
+	live     bool // Complete as the user types, not only on Tab
+	minChars int
+	delay    time.Duration
+
 	// Styles
-	dropdownStyle lipgloss.Style
-	itemStyle     lipgloss.Style
-	selectedStyle lipgloss.Style
-	descStyle     lipgloss.Style
+	dropdownStyle  lipgloss.Style
+	itemStyle      lipgloss.Style
+	selectedStyle  lipgloss.Style
+	descStyle      lipgloss.Style
+	highlightStyle lipgloss.Style
 }
 
 // NewAutocomplete creates a new autocomplete component.
 func NewAutocomplete() *Autocomplete {
 	return &Autocomplete{
 		providers:      make(map[string]CompletionProvider),
+		asyncProviders: make(map[string]AsyncCompletionProvider),
 		maxCompletions: 10,
 		dropdownStyle: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -56,17 +84,48 @@ func NewAutocomplete() *Autocomplete {
 			Bold(true),
 		descStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#6272a4")),
+		highlightStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#bd93f9")).
+			Bold(true),
 	}
 }
 
 // RegisterProvider adds a completion provider with the given name.
 func (ac *Autocomplete) RegisterProvider(name string, provider CompletionProvider) {
+	delete(ac.asyncProviders, name)
 	ac.providers[name] = provider
 }
 
+// RegisterAsyncProvider adds an asynchronous completion provider with the
+// given name. Its completions are shown through Query.
+func (ac *Autocomplete) RegisterAsyncProvider(name string, provider AsyncCompletionProvider) {
+	delete(ac.providers, name)
+	ac.asyncProviders[name] = provider
+}
+
 // UnregisterProvider removes a completion provider.
 func (ac *Autocomplete) UnregisterProvider(name string) {
 	delete(ac.providers, name)
+	delete(ac.asyncProviders, name)
+}
+
+// hasProvider reports whether a provider is registered under name.
+func (ac *Autocomplete) hasProvider(name string) bool {
+	_, ok := ac.providers[name]
+	_, async := ac.asyncProviders[name]
+	return ok || async
+}
+
+// ApplyConfig applies user autocomplete settings: when enabled,
+// completions are shown as the user types, once the word before the cursor
+// has MinChars characters and typing has paused for DelayMs. When
+// disabled, Tab does not complete either.
+func (ac *Autocomplete) ApplyConfig(cfg config.AutocompleteConfig) {
+	ac.disabled = !cfg.Enabled
+	ac.live = cfg.Enabled
+	ac.minChars = cfg.MinChars
+	ac.delay = time.Duration(cfg.DelayMs) * time.Millisecond
+	ac.SetMaxCompletions(cfg.MaxSuggestions)
 }
 
 // SetMaxCompletions sets the maximum number of completions to show.
@@ -84,6 +143,12 @@ func (ac *Autocomplete) SetStyles(dropdown, item, selected, desc lipgloss.Style)
 	ac.descStyle = desc
 }
 
+// SetHighlightStyle sets the style of the characters matching the input.
+// On the selected completion they are underlined instead.
+func (ac *Autocomplete) SetHighlightStyle(style lipgloss.Style) {
+	ac.highlightStyle = style
+}
+
 // Show activates autocomplete for the given input using a specific provider.
 func (ac *Autocomplete) Show(input string, providerName string) {
 	provider, ok := ac.providers[providerName]
@@ -91,12 +156,18 @@ func (ac *Autocomplete) Show(input string, providerName string) {
 		return
 	}
 
+	ac.cancelQuery()
 	ac.input = input
 	ac.activeProvider = providerName
-	ac.completions = provider.GetCompletions(input)
+	ac.setCompletions(provider.GetCompletions(input))
+}
+
+// setCompletions shows completions, best first.
+func (ac *Autocomplete) setCompletions(completions []Completion) {
+	ac.completions = completions
 
-	// Sort by score (highest first)
-	sort.Slice(ac.completions, func(i, j int) bool {
+	// Sort by score (highest first), keeping the provider's order for ties
+	sort.SliceStable(ac.completions, func(i, j int) bool {
 		return ac.completions[i].Score > ac.completions[j].Score
 	})
 
@@ -117,13 +188,119 @@ func (ac *Autocomplete) ShowAuto(input string) {
 	}
 }
 
-// Hide deactivates autocomplete.
+// Query shows completions for input from the detected provider, as Tab
+// does. Synchronous providers are shown at once; for asynchronous ones the
+// returned command runs the query and the dropdown shows a loading state
+// until its results arrive. A query cancels the one before it.
+func (ac *Autocomplete) Query(input string) tea.Cmd {
+	if ac.disabled {
+		ac.Hide()
+		return nil
+	}
+	name := ac.DetectProvider(input)
+	provider, ok := ac.asyncProviders[name]
+	if !ok {
+		ac.Show(input, name)
+		return nil
+	}
+
+	ac.cancelQuery()
+	ac.seq++
+	seq := ac.seq
+	ctx, cancel := context.WithCancel(context.Background())
+	ac.cancel = cancel
+	ac.input = input
+	ac.activeProvider = name
+	ac.completions = nil
+	ac.selectedIndex = 0
+	ac.loading, ac.active = true, true
+	return func() tea.Msg {
+		completions, err := provider.GetCompletionsAsync(ctx, input)
+		return completionsMsg{seq: seq, completions: completions, err: err}
+	}
+}
+
+// Debounce schedules a query for input once typing pauses, when completing
+// as the user types. Each call supersedes the last. Words shorter than the
+// configured minimum, and history, which would match most prompts, are not
+// completed live.
+func (ac *Autocomplete) Debounce(input string) tea.Cmd {
+	if !ac.live {
+		return nil
+	}
+	ac.Hide()
+	ac.seq++
+	if utf8.RuneCountInString(lastWord(input)) < max(ac.minChars, 1) {
+		return nil
+	}
+	if name := ac.DetectProvider(input); name == "" || name == "history" {
+		return nil
+	}
+	seq := ac.seq
+	return tea.Tick(ac.delay, func(time.Time) tea.Msg {
+		return completionDebounceMsg{seq: seq, input: input}
+	})
+}
+
+// completionDebounceMsg is sent when typing has paused after a keystroke.
+type completionDebounceMsg struct {
+	seq   uint64
+	input string
+}
+
+// completionsMsg carries the results of an asynchronous query.
+type completionsMsg struct {
+	seq         uint64
+	completions []Completion
+	err         error
+}
+
+// handleDebounce runs the query scheduled by Debounce, unless typing went
+// on since.
+func (ac *Autocomplete) handleDebounce(msg completionDebounceMsg) tea.Cmd {
+	if msg.seq != ac.seq {
+		return nil
+	}
+	return ac.Query(msg.input)
+}
+
+// handleResults shows the results of the latest query. Results of
+// superseded and failed queries are dropped.
+func (ac *Autocomplete) handleResults(msg completionsMsg) {
+	if msg.seq != ac.seq || !ac.loading {
+		return
+	}
+	ac.cancel = nil
+	ac.loading = false
+	if msg.err != nil {
+		ac.Hide()
+		return
+	}
+	ac.setCompletions(msg.completions)
+}
+
+// cancelQuery cancels the running asynchronous query, if any.
+func (ac *Autocomplete) cancelQuery() {
+	if ac.cancel != nil {
+		ac.cancel()
+		ac.cancel = nil
+	}
+	ac.loading = false
+}
+
+// Hide deactivates autocomplete, cancelling any query still running.
 func (ac *Autocomplete) Hide() {
+	ac.cancelQuery()
 	ac.active = false
 	ac.completions = nil
 	ac.selectedIndex = 0
 }
 
+// Loading reports whether the dropdown is waiting on an asynchronous query.
+func (ac *Autocomplete) Loading() bool {
+	return ac.loading
+}
+
 // Active returns whether autocomplete is currently showing.
 func (ac *Autocomplete) Active() bool {
 	return ac.active
@@ -168,27 +345,41 @@ func (ac *Autocomplete) SelectedIndex() int {
 
 // DetectProvider determines the appropriate provider based on input context.
 // "/" prefix → "command"
-// "./" or "~/" or "/" at start → "file"
+// Last word starting "@" → "mention"
+// Last word starting "./", "../", "~/" or "/" → "file"
 // Otherwise → "history"
 func (ac *Autocomplete) DetectProvider(input string) string {
-	if strings.HasPrefix(input, "/") {
+	if strings.HasPrefix(input, "/") && !strings.Contains(input, " ") {
 		// Check if "command" provider exists, otherwise try "file"
-		if _, ok := ac.providers["command"]; ok {
+		if ac.hasProvider("command") {
 			return "command"
 		}
 	}
-	if strings.HasPrefix(input, "./") || strings.HasPrefix(input, "~/") || strings.HasPrefix(input, "/") {
-		if _, ok := ac.providers["file"]; ok {
+	if strings.HasPrefix(lastWord(input), "@") {
+		if ac.hasProvider("mention") {
+			return "mention"
+		}
+	}
+	if looksLikePath(lastWord(input)) {
+		if ac.hasProvider("file") {
 			return "file"
 		}
 	}
-	if _, ok := ac.providers["history"]; ok {
+	if strings.HasPrefix(input, "/") {
+		if ac.hasProvider("command") {
+			return "command"
+		}
+	}
+	if ac.hasProvider("history") {
 		return "history"
 	}
 	// Return first available provider
 	for name := range ac.providers {
 		return name
 	}
+	for name := range ac.asyncProviders {
+		return name
+	}
 	return ""
 }
 
@@ -215,24 +406,28 @@ func (ac *Autocomplete) Update(msg tea.Msg) (*Autocomplete, tea.Cmd) {
 
 // View renders the autocomplete dropdown.
 func (ac *Autocomplete) View() string {
+	if ac.active && ac.loading {
+		return ac.dropdownStyle.Render(ac.descStyle.Render("Loading…"))
+	}
 	if !ac.active || len(ac.completions) == 0 {
 		return ""
 	}
 
 	var lines []string
 	for i, c := range ac.completions {
-		var line string
-		if c.Description != "" {
-			line = c.Display + " " + ac.descStyle.Render(c.Description)
-		} else {
-			line = c.Display
+		base, match, desc := ac.itemStyle.Inline(true), ac.highlightStyle.Inline(true), ac.descStyle
+		if i == ac.selectedIndex {
+			// The highlight colour is the selection's background
+			base = ac.selectedStyle.Inline(true)
+			match = base.Underline(true)
+			desc = desc.Background(ac.selectedStyle.GetBackground())
 		}
 
-		if i == ac.selectedIndex {
-			lines = append(lines, ac.selectedStyle.Render(line))
-		} else {
-			lines = append(lines, ac.itemStyle.Render(line))
+		line := fuzzy.Highlight(c.Display, c.Positions, base, match)
+		if c.Description != "" {
+			line += base.Render(" ") + desc.Render(c.Description)
 		}
+		lines = append(lines, line)
 	}
 
 	return ac.dropdownStyle.Render(strings.Join(lines, "\n"))
@@ -248,37 +443,40 @@ func NewCommandProvider(commands []Completion) *CommandProvider {
 	return &CommandProvider{commands: commands}
 }
 
-// GetCompletions returns commands matching the input.
+// GetCompletions returns commands whose names fuzzily match the input.
+// Exact and prefix matches score highest.
 func (p *CommandProvider) GetCompletions(input string) []Completion {
 	if !strings.HasPrefix(input, "/") {
 		return nil
 	}
 
-	query := strings.ToLower(strings.TrimPrefix(input, "/"))
+	query := strings.TrimPrefix(input, "/")
 	var matches []Completion
 
 	for _, cmd := range p.commands {
-		cmdName := strings.ToLower(strings.TrimPrefix(cmd.Value, "/"))
-		if strings.HasPrefix(cmdName, query) {
-			// Higher score for exact prefix match
-			score := cmd.Score
-			if cmdName == query {
-				score += 100
-			} else if strings.HasPrefix(cmdName, query) {
-				score += 50
-			}
-			matches = append(matches, Completion{
-				Value:       cmd.Value,
-				Display:     cmd.Display,
-				Description: cmd.Description,
-				Score:       score,
-			})
+		r, ok := fuzzy.Match(query, strings.TrimPrefix(cmd.Value, "/"))
+		if !ok {
+			continue
 		}
+		matches = append(matches, Completion{
+			Value:       cmd.Value,
+			Display:     cmd.Display,
+			Description: cmd.Description,
+			Score:       cmd.Score + r.Score,
+			Positions:   matchPositions(query, cmd.Display),
+		})
 	}
 
 	return matches
 }
 
+// matchPositions returns the runes of display matching query, to highlight
+// a completion matched on something other than its display text.
+func matchPositions(query, display string) []int {
+	r, _ := fuzzy.Match(query, display)
+	return r.Positions
+}
+
 // HistoryProvider provides completions from command history.
 type HistoryProvider struct {
 	history []string
@@ -298,30 +496,28 @@ func (p *HistoryProvider) AddHistory(entry string) {
 	p.history = append(p.history, entry)
 }
 
-// GetCompletions returns history entries matching the input.
+// GetCompletions returns history entries fuzzily matching the input.
 func (p *HistoryProvider) GetCompletions(input string) []Completion {
 	if input == "" {
 		return nil
 	}
 
-	query := strings.ToLower(input)
 	var matches []Completion
 
 	// Search from most recent
 	for i := len(p.history) - 1; i >= 0; i-- {
 		entry := p.history[i]
-		if strings.Contains(strings.ToLower(entry), query) {
-			// Score based on recency and match quality
-			score := i // More recent = higher base index
-			if strings.HasPrefix(strings.ToLower(entry), query) {
-				score += 100
-			}
-			matches = append(matches, Completion{
-				Value:   entry,
-				Display: entry,
-				Score:   score,
-			})
+		r, ok := fuzzy.Match(input, entry)
+		if !ok {
+			continue
 		}
+		// Score based on match quality and recency
+		matches = append(matches, Completion{
+			Value:     entry,
+			Display:   entry,
+			Score:     r.Score + i, // More recent = higher base index
+			Positions: r.Positions,
+		})
 	}
 
 	return matches
@@ -1,9 +1,13 @@
 package shell
 
 import (
+	"context"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -243,6 +247,7 @@ func TestAutocompleteDetectProvider(t *testing.T) {
 	ac.RegisterProvider("command", NewCommandProvider(nil))
 	ac.RegisterProvider("file", &mockFileProvider{})
 	ac.RegisterProvider("history", NewHistoryProvider(nil))
+	ac.RegisterProvider("mention", &mockFileProvider{})
 
 	tests := []struct {
 		input    string
@@ -253,6 +258,13 @@ func TestAutocompleteDetectProvider(t *testing.T) {
 		{"~/path", "file"},
 		{"/path/to", "command"}, // "/" prefix matches command first
 		{"hello", "history"},
+		{"fix ./src/ma", "file"},
+		{"/open ../x", "file"},
+		{"/theme no", "command"},
+		{"see a/b", "history"},
+		{"explain @src/ma", "mention"},
+		{"/open @x", "mention"},
+		{"mail a@b", "history"},
 	}
 
 	for _, tt := range tests {
@@ -408,6 +420,44 @@ func TestCommandProvider(t *testing.T) {
 	}
 }
 
+func TestCommandProviderFuzzy(t *testing.T) {
+	provider := NewCommandProvider([]Completion{
+		{Value: "/model", Display: "/model"},
+		{Value: "/mcp-list", Display: "/mcp-list"},
+		{Value: "/help", Display: "/help"},
+	})
+
+	ac := NewAutocomplete()
+	ac.RegisterProvider("command", provider)
+	ac.Show("/ml", "command")
+	got := ac.Completions()
+	if len(got) != 2 || got[0].Value != "/mcp-list" || got[1].Value != "/model" {
+		t.Fatalf("expected the word-start match first, got %+v", got)
+	}
+	if !slices.Equal(got[0].Positions, []int{1, 5}) || !slices.Equal(got[1].Positions, []int{1, 5}) {
+		t.Errorf("expected positions in the display text, got %v and %v", got[0].Positions, got[1].Positions)
+	}
+}
+
+func TestAutocompleteViewHighlightsMatches(t *testing.T) {
+	ac := NewAutocomplete()
+	ac.SetHighlightStyle(lipgloss.NewStyle().Transform(strings.ToUpper))
+	ac.RegisterProvider("history", NewHistoryProvider([]string{"run the tests", "rerun tests"}))
+	ac.Show("rt", "history")
+
+	// The selected row is underlined instead, so check the second
+	lines := strings.Split(ac.View(), "\n")
+	if len(lines) < 4 || !strings.Contains(lines[2], "Run The tests") {
+		t.Errorf("expected matched characters highlighted, got:\n%s", ac.View())
+	}
+
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetAutocomplete(ac)
+	if got := ac.highlightStyle.GetForeground(); got != theme.NewDraculaTheme().Primary() {
+		t.Errorf("expected the theme's primary colour, got %v", got)
+	}
+}
+
 // HistoryProvider tests
 
 func TestHistoryProvider(t *testing.T) {
@@ -505,3 +555,155 @@ func TestCompletionSorting(t *testing.T) {
 		}
 	}
 }
+
+// blockingProvider answers queries once released, recording cancellations.
+type blockingProvider struct {
+	release   chan struct{}
+	cancelled chan string
+}
+
+func newBlockingProvider() *blockingProvider {
+	return &blockingProvider{release: make(chan struct{}), cancelled: make(chan string, 4)}
+}
+
+func (p *blockingProvider) GetCompletionsAsync(ctx context.Context, input string) ([]Completion, error) {
+	select {
+	case <-p.release:
+		return []Completion{{Value: input + "-done", Display: input + "-done"}}, nil
+	case <-ctx.Done():
+		p.cancelled <- input
+		return nil, ctx.Err()
+	}
+}
+
+func TestAutocompleteAsyncQuery(t *testing.T) {
+	provider := newBlockingProvider()
+	ac := NewAutocomplete()
+	ac.RegisterAsyncProvider("lsp", provider)
+
+	first := ac.Query("fo")
+	if first == nil || !ac.Active() || !ac.Loading() {
+		t.Fatal("expected a query running with the dropdown loading")
+	}
+	if !strings.Contains(ac.View(), "Loading…") {
+		t.Errorf("expected a loading state, got %q", ac.View())
+	}
+
+	// A new query cancels the old one, whose results are dropped
+	second := ac.Query("foo")
+	msg := first()
+	if got := <-provider.cancelled; got != "fo" {
+		t.Errorf("expected the first query cancelled, got %q", got)
+	}
+	ac.handleResults(msg.(completionsMsg))
+	if !ac.Loading() {
+		t.Fatal("expected superseded results dropped")
+	}
+
+	close(provider.release)
+	ac.handleResults(second().(completionsMsg))
+	if ac.Loading() || ac.GetSelected() == nil || ac.GetSelected().Value != "foo-done" {
+		t.Errorf("expected the latest results shown, got %+v", ac.Completions())
+	}
+}
+
+func TestAutocompleteHideCancelsQuery(t *testing.T) {
+	provider := newBlockingProvider()
+	ac := NewAutocomplete()
+	ac.RegisterAsyncProvider("lsp", provider)
+
+	cmd := ac.Query("fo")
+	ac.Hide()
+	ac.handleResults(cmd().(completionsMsg))
+	if ac.Active() || ac.Loading() {
+		t.Error("expected results of a hidden query dropped")
+	}
+}
+
+func TestAutocompleteDebounce(t *testing.T) {
+	ac := NewAutocomplete()
+	ac.RegisterProvider("command", NewCommandProvider([]Completion{
+		{Value: "/help", Display: "/help"}, {Value: "/hello", Display: "/hello"}, {Value: "/hex", Display: "/hex"},
+	}))
+	ac.RegisterProvider("history", NewHistoryProvider([]string{"hello world"}))
+
+	if ac.Debounce("/he") != nil {
+		t.Error("expected no live completion without config")
+	}
+	ac.ApplyConfig(config.AutocompleteConfig{Enabled: true, MinChars: 2, MaxSuggestions: 2})
+
+	if ac.Debounce("/") != nil {
+		t.Error("expected words under min_chars skipped")
+	}
+	if ac.Debounce("hello") != nil {
+		t.Error("expected history left to Tab")
+	}
+
+	stale := ac.Debounce("/h")
+	cmd := ac.Debounce("/he")
+	if cmd == nil {
+		t.Fatal("expected a debounced query")
+	}
+	ac.handleDebounce(stale().(completionDebounceMsg))
+	if ac.Active() {
+		t.Fatal("expected a superseded keystroke ignored")
+	}
+	ac.handleDebounce(cmd().(completionDebounceMsg))
+	if !ac.Active() || len(ac.Completions()) != 2 {
+		t.Errorf("expected max_suggestions completions, got %+v", ac.Completions())
+	}
+
+	ac.ApplyConfig(config.AutocompleteConfig{Enabled: false})
+	if ac.Query("/he") != nil || ac.Debounce("/he") != nil || ac.Active() {
+		t.Error("expected no completion when disabled")
+	}
+}
+
+func TestInputCompletesLive(t *testing.T) {
+	ac := NewAutocomplete()
+	ac.RegisterProvider("command", NewCommandProvider([]Completion{{Value: "/help", Display: "/help"}}))
+	ac.ApplyConfig(config.AutocompleteConfig{Enabled: true, MinChars: 1})
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetAutocomplete(ac)
+
+	var cmd tea.Cmd
+	for _, r := range "/help" {
+		_, cmd = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if ac.Active() {
+		t.Fatal("expected completion to wait for typing to pause")
+	}
+	input.Update(cmd())
+	if !ac.Active() {
+		t.Fatal("expected completions once typing paused")
+	}
+
+	// Enter submits when the completion is already typed
+	if _, cmd := input.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Fatal("expected a submit")
+	} else if msg, ok := cmd().(InputSubmitMsg); !ok || msg.Value != "/help" {
+		t.Errorf("expected /help submitted, got %#v", msg)
+	}
+}
+
+func TestShellRoutesAsyncCompletions(t *testing.T) {
+	provider := newBlockingProvider()
+	close(provider.release)
+	ac := NewAutocomplete()
+	ac.RegisterAsyncProvider("lsp", provider)
+
+	cfg := DefaultConfig()
+	cfg.Autocomplete = ac
+	cfg.Completion = &config.AutocompleteConfig{Enabled: true, MinChars: 1, MaxSuggestions: 5}
+	s := New(nil, cfg)
+
+	s.input.SetValue("ab")
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if !ac.Loading() {
+		t.Fatal("expected Tab to start the query")
+	}
+	s.Update(cmd())
+	if sel := ac.GetSelected(); sel == nil || sel.Value != "ab-done" {
+		t.Errorf("expected the results shown, got %+v", ac.Completions())
+	}
+}
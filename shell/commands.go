@@ -0,0 +1,471 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/2389-research/tux/fuzzy"
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ArgType is the type a command argument is parsed as.
+type ArgType int
+
+const (
+	// ArgString is text, stored in Values as a string.
+	ArgString ArgType = iota
+	// ArgInt is a whole number, stored in Values as an int.
+	ArgInt
+	// ArgBool is true/false, yes/no or on/off, stored in Values as a bool.
+	ArgBool
+)
+
+// CommandArg describes an argument of a slash command.
+type CommandArg struct {
+	Name        string
+	Description string
+	Type        ArgType
+	Required    bool
+	// Rest takes the rest of the line, spaces included. Only the last
+	// argument can.
+	Rest bool
+	// Choices are the values allowed, offered as completions.
+	Choices []string
+	// Complete returns completions for the argument as typed so far, when
+	// there are no Choices. Completion values are the argument only.
+	Complete func(prefix string) []Completion
+}
+
+// Command is a slash command: typing "/name args" in the input runs its
+// Handler instead of submitting the text.
+type Command struct {
+	Name        string // Without the slash
+	Aliases     []string
+	Description string
+	Args        []CommandArg
+	// Handler runs the command inside the update loop with the parsed
+	// arguments, keyed by name. Optional arguments not given are absent.
+	Handler func(args Values) tea.Cmd
+}
+
+// Usage returns the command as typed, e.g. "/theme <name> [mode]".
+func (c Command) Usage() string {
+	parts := []string{"/" + c.Name}
+	for _, arg := range c.Args {
+		name := arg.Name
+		if arg.Rest {
+			name += "..."
+		}
+		if arg.Required {
+			parts = append(parts, "<"+name+">")
+		} else {
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Commands is a registry of slash commands. Set as Config.Commands, it
+// completes commands and their arguments in the input, lists them in the
+// help overlay and runs them when submitted.
+type Commands struct {
+	commands []Command
+}
+
+// NewCommands creates a registry holding the given commands.
+func NewCommands(commands ...Command) *Commands {
+	c := &Commands{}
+	for _, cmd := range commands {
+		c.Register(cmd)
+	}
+	return c
+}
+
+// Register adds a command, replacing any with the same name.
+func (c *Commands) Register(cmd Command) {
+	if cmd.Name == "" {
+		panic("shell: command without a name")
+	}
+	cmd.Name = strings.TrimPrefix(cmd.Name, "/")
+	c.Unregister(cmd.Name)
+	c.commands = append(c.commands, cmd)
+	slices.SortFunc(c.commands, func(a, b Command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// Unregister removes the command with the given name.
+func (c *Commands) Unregister(name string) {
+	c.commands = slices.DeleteFunc(c.commands, func(cmd Command) bool {
+		return cmd.Name == name
+	})
+}
+
+// Lookup returns the command with the given name or alias.
+func (c *Commands) Lookup(name string) (Command, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "/"))
+	for _, cmd := range c.commands {
+		if strings.ToLower(cmd.Name) == name {
+			return cmd, true
+		}
+	}
+	for _, cmd := range c.commands {
+		for _, alias := range cmd.Aliases {
+			if strings.ToLower(alias) == name {
+				return cmd, true
+			}
+		}
+	}
+	return Command{}, false
+}
+
+// List returns the commands in name order.
+func (c *Commands) List() []Command {
+	return slices.Clone(c.commands)
+}
+
+// HelpCategory lists the commands for the help overlay.
+func (c *Commands) HelpCategory() Category {
+	cat := Category{Title: "Commands"}
+	for _, cmd := range c.commands {
+		desc := cmd.Description
+		if len(cmd.Aliases) > 0 {
+			desc += " (also /" + strings.Join(cmd.Aliases, ", /") + ")"
+		}
+		cat.Bindings = append(cat.Bindings, Binding{Key: cmd.Usage(), Description: desc})
+	}
+	return cat
+}
+
+// Parse parses a line starting with "/" into its command and arguments.
+func (c *Commands) Parse(line string) (Command, Values, error) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "/"), " ")
+	cmd, ok := c.Lookup(name)
+	if !ok {
+		return Command{}, nil, fmt.Errorf("unknown command /%s", name)
+	}
+	tokens, err := splitArgs(rest)
+	if err != nil {
+		return cmd, nil, fmt.Errorf("/%s: %w", cmd.Name, err)
+	}
+
+	values := Values{}
+	for i, arg := range cmd.Args {
+		if i >= len(tokens) {
+			if arg.Required {
+				return cmd, nil, fmt.Errorf("/%s: missing %s (usage: %s)", cmd.Name, arg.Name, cmd.Usage())
+			}
+			continue
+		}
+		text := tokens[i].text
+		if arg.Rest {
+			text = strings.TrimSpace(rest[tokens[i].start:])
+			tokens = tokens[:i+1]
+		}
+		v, err := arg.parse(text)
+		if err != nil {
+			return cmd, nil, fmt.Errorf("/%s: %s %w", cmd.Name, arg.Name, err)
+		}
+		values[arg.Name] = v
+	}
+	if len(tokens) > len(cmd.Args) {
+		return cmd, nil, fmt.Errorf("/%s: too many arguments (usage: %s)", cmd.Name, cmd.Usage())
+	}
+	return cmd, values, nil
+}
+
+// parse converts an argument as typed into its type.
+func (a CommandArg) parse(text string) (any, error) {
+	if len(a.Choices) > 0 && !slices.Contains(a.Choices, text) {
+		return nil, fmt.Errorf("must be one of %s", strings.Join(a.Choices, ", "))
+	}
+	switch a.Type {
+	case ArgInt:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, errors.New("must be a whole number")
+		}
+		return n, nil
+	case ArgBool:
+		switch strings.ToLower(text) {
+		case "true", "yes", "on":
+			return true, nil
+		case "false", "no", "off":
+			return false, nil
+		}
+		return nil, errors.New("must be on or off")
+	}
+	return text, nil
+}
+
+// GetCompletions implements CompletionProvider: command names while the
+// name is typed, then the current argument's completions. Values are the
+// whole input with the completion applied.
+func (c *Commands) GetCompletions(input string) []Completion {
+	if !strings.HasPrefix(input, "/") {
+		return nil
+	}
+	name, rest, hasArgs := strings.Cut(input[1:], " ")
+	if !hasArgs {
+		return c.completeName(name)
+	}
+
+	cmd, ok := c.Lookup(name)
+	if !ok {
+		return nil
+	}
+	tokens, _ := splitArgs(rest)
+	prefix := ""
+	index := len(tokens)
+	if len(tokens) > 0 && !strings.HasSuffix(rest, " ") {
+		prefix = tokens[len(tokens)-1].text
+		index--
+	}
+	if index >= len(cmd.Args) {
+		return nil
+	}
+	arg := cmd.Args[index]
+	head := strings.TrimSuffix(input, prefix)
+
+	var matches []Completion
+	for _, comp := range arg.completions(prefix) {
+		value := comp.Value
+		if strings.Contains(value, " ") && !arg.Rest {
+			value = strconv.Quote(value)
+		}
+		if comp.Display == "" {
+			comp.Display = comp.Value
+		}
+		comp.Value = head + value
+		matches = append(matches, comp)
+	}
+	return matches
+}
+
+// completeName completes a command name or alias. Exact matches rank
+// first, then prefixes, then names matching fuzzily.
+func (c *Commands) completeName(typed string) []Completion {
+	typed = strings.ToLower(typed)
+	var matches []Completion
+	for _, cmd := range c.commands {
+		score := -1
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			name = strings.ToLower(name)
+			switch {
+			case name == typed:
+				score = max(score, 100)
+			case strings.HasPrefix(name, typed):
+				score = max(score, 50)
+			default:
+				if _, ok := fuzzy.Match(typed, name); ok {
+					score = max(score, 0)
+				}
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		value := "/" + cmd.Name
+		if len(cmd.Args) > 0 {
+			value += " "
+		}
+		var positions []int
+		if r, ok := fuzzy.Match(typed, cmd.Name); ok {
+			positions = fuzzy.Shift(r.Positions, 1) // After the slash
+		}
+		matches = append(matches, Completion{
+			Value:       value,
+			Display:     cmd.Usage(),
+			Description: cmd.Description,
+			Score:       score,
+			Positions:   positions,
+		})
+	}
+	return matches
+}
+
+// completions returns the argument's completions for what is typed so far.
+func (a CommandArg) completions(prefix string) []Completion {
+	if len(a.Choices) == 0 && a.Type == ArgBool {
+		a.Choices = []string{"on", "off"}
+	}
+	if len(a.Choices) == 0 {
+		if a.Complete == nil {
+			return nil
+		}
+		return a.Complete(prefix)
+	}
+	var matches []Completion
+	for _, choice := range a.Choices {
+		if strings.HasPrefix(strings.ToLower(choice), strings.ToLower(prefix)) {
+			matches = append(matches, Completion{Value: choice, Display: choice, Positions: matchPositions(prefix, choice)})
+		}
+	}
+	return matches
+}
+
+// argToken is an argument as typed, with where it starts in the line.
+type argToken struct {
+	text  string
+	start int
+}
+
+// splitArgs splits arguments at spaces. Double quotes group text with
+// spaces into one argument.
+func splitArgs(s string) ([]argToken, error) {
+	var tokens []argToken
+	var b strings.Builder
+	start, inToken, quoted := 0, false, false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			if !inToken {
+				start, inToken = i, true
+			}
+		case r == ' ' && !quoted:
+			if inToken {
+				tokens = append(tokens, argToken{text: b.String(), start: start})
+				b.Reset()
+				inToken = false
+			}
+		default:
+			if !inToken {
+				start, inToken = i, true
+			}
+			b.WriteRune(r)
+		}
+	}
+	if inToken {
+		tokens = append(tokens, argToken{text: b.String(), start: start})
+	}
+	if quoted {
+		return tokens, errors.New("unclosed quote")
+	}
+	return tokens, nil
+}
+
+// noticeDuration is how long a command error stays in the status bar.
+const noticeDuration = 4 * time.Second
+
+// noticeExpiredMsg clears a notice once it has been shown long enough.
+type noticeExpiredMsg struct {
+	notice string
+}
+
+// submit runs a submitted line as a command when it starts with "/", or
+// passes it to OnInputSubmit. A leading "//" submits the line with one
+// slash. Lines that do not parse are put back in the input, with the error
+// shown in the status bar.
+func (s *Shell) submit(value string) tea.Cmd {
+	commands := s.config.Commands
+	switch {
+	case commands == nil || !strings.HasPrefix(value, "/"):
+	case strings.HasPrefix(value, "//"):
+		value = value[1:]
+	default:
+		cmd, args, err := commands.Parse(value)
+		if err != nil {
+			s.input.SetValue(value)
+			return s.showNotice(err.Error())
+		}
+		if cmd.Handler == nil {
+			return nil
+		}
+		return cmd.Handler(args)
+	}
+
+	if s.config.OnInputSubmit != nil {
+		s.config.OnInputSubmit(value)
+	}
+	return nil
+}
+
+// showNotice shows a notice in the status bar for noticeDuration.
+func (s *Shell) showNotice(notice string) tea.Cmd {
+	s.statusBar.SetNotice(notice)
+	return tea.Tick(noticeDuration, func(time.Time) tea.Msg {
+		return noticeExpiredMsg{notice: notice}
+	})
+}
+
+// addBuiltinCommands registers the built-in commands not already
+// registered: /clear, /save and /errors when their hooks are set, and
+// /help, /theme and /quit.
+func (s *Shell) addBuiltinCommands(commands *Commands) {
+	builtins := []Command{{
+		Name:        "help",
+		Description: "Show keys and commands",
+		Handler: func(Values) tea.Cmd {
+			s.ShowHelp()
+			return nil
+		},
+	}, {
+		Name:        "theme",
+		Description: "Change the theme",
+		Args: []CommandArg{{
+			Name:     "name",
+			Required: true,
+			Choices:  themeNames(),
+		}},
+		Handler: func(args Values) tea.Cmd {
+			s.SetTheme(theme.Get(args.String("name")))
+			return nil
+		},
+	}, {
+		Name:        "quit",
+		Aliases:     []string{"exit"},
+		Description: "Quit",
+		Handler: func(Values) tea.Cmd {
+			return tea.Quit
+		},
+	}}
+	if s.config.OnClearChat != nil {
+		builtins = append(builtins, Command{
+			Name:        "clear",
+			Description: "Clear the conversation",
+			Handler: func(Values) tea.Cmd {
+				s.config.OnClearChat()
+				return nil
+			},
+		})
+	}
+	if s.config.OnSave != nil {
+		builtins = append(builtins, Command{
+			Name:        "save",
+			Description: "Save the session",
+			Handler: func(Values) tea.Cmd {
+				s.config.OnSave()
+				return nil
+			},
+		})
+	}
+	if s.config.OnShowErrors != nil {
+		builtins = append(builtins, Command{
+			Name:        "errors",
+			Description: "Show errors",
+			Handler: func(Values) tea.Cmd {
+				s.config.OnShowErrors()
+				return nil
+			},
+		})
+	}
+
+	for _, cmd := range builtins {
+		if _, ok := commands.Lookup(cmd.Name); !ok {
+			commands.Register(cmd)
+		}
+	}
+}
+
+// themeNames returns the registered theme names in order.
+func themeNames() []string {
+	names := theme.Available()
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,234 @@
+package shell
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testCommands() *Commands {
+	return NewCommands(Command{
+		Name:        "model",
+		Aliases:     []string{"m"},
+		Description: "Switch model",
+		Args: []CommandArg{
+			{Name: "name", Required: true, Choices: []string{"opus", "sonnet", "haiku"}},
+			{Name: "temperature", Type: ArgInt},
+		},
+	}, Command{
+		Name:        "note",
+		Description: "Add a note",
+		Args:        []CommandArg{{Name: "text", Required: true, Rest: true}},
+	}, Command{
+		Name: "verbose",
+		Args: []CommandArg{{Name: "on", Type: ArgBool}},
+	})
+}
+
+func TestCommandsParse(t *testing.T) {
+	c := testCommands()
+
+	cmd, args, err := c.Parse("/m sonnet 7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd.Name != "model" || args.String("name") != "sonnet" || args.Int("temperature") != 7 {
+		t.Errorf("unexpected parse: %s %v", cmd.Name, args)
+	}
+
+	_, args, err = c.Parse(`/note  fix the "tests" later `)
+	if err != nil || args.String("text") != `fix the "tests" later` {
+		t.Errorf("expected the rest of the line, got %q (%v)", args.String("text"), err)
+	}
+
+	_, args, err = c.Parse("/verbose off")
+	if err != nil || args.Bool("on") {
+		t.Errorf("expected off, got %v (%v)", args, err)
+	}
+	if _, args, _ := c.Parse("/verbose"); len(args) != 0 {
+		t.Errorf("expected optional args absent, got %v", args)
+	}
+
+	errs := map[string]string{
+		"/nope":                 "unknown command /nope",
+		"/model":                "missing name",
+		"/model gpt":            "must be one of opus, sonnet, haiku",
+		"/model opus hot":       "temperature must be a whole number",
+		"/model opus 1 2":       "too many arguments",
+		`/model "opus`:          "unclosed quote",
+		"/verbose sometimes":    "must be on or off",
+		"/note":                 "usage: /note <text...>",
+		"/model haiku 1 2 3 4 ": "usage: /model <name> [temperature]",
+	}
+	for line, want := range errs {
+		if _, _, err := c.Parse(line); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", line, want, err)
+		}
+	}
+}
+
+func TestCommandsCompletesNames(t *testing.T) {
+	c := testCommands()
+
+	got := c.GetCompletions("/m")
+	if len(got) != 1 || got[0].Value != "/model " || got[0].Display != "/model <name> [temperature]" {
+		t.Fatalf("unexpected completions %+v", got)
+	}
+	if got[0].Score != 100 {
+		t.Errorf("expected the alias matched exactly, got score %d", got[0].Score)
+	}
+	if got := c.GetCompletions("/v"); len(got) != 1 || got[0].Value != "/verbose " {
+		t.Errorf("unexpected completions %+v", got)
+	}
+	if got := c.GetCompletions("/vb"); len(got) != 1 || got[0].Value != "/verbose " || !slices.Equal(got[0].Positions, []int{1, 4}) {
+		t.Errorf("expected a fuzzy match highlighted after the slash, got %+v", got)
+	}
+	if got := c.GetCompletions("/"); len(got) != 3 {
+		t.Errorf("expected every command, got %d", len(got))
+	}
+	if got := c.GetCompletions("model"); got != nil {
+		t.Errorf("expected nothing without a slash, got %+v", got)
+	}
+}
+
+func TestCommandsCompletesArgs(t *testing.T) {
+	c := testCommands()
+	c.Register(Command{
+		Name: "open",
+		Args: []CommandArg{{Name: "file", Complete: func(prefix string) []Completion {
+			return []Completion{{Value: prefix + "main.go"}, {Value: "my file.go"}}
+		}}},
+	})
+
+	values := func(cs []Completion) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.Value)
+		}
+		return out
+	}
+
+	if got := values(c.GetCompletions("/model ")); strings.Join(got, "|") != "/model opus|/model sonnet|/model haiku" {
+		t.Errorf("unexpected choices %q", got)
+	}
+	if got := values(c.GetCompletions("/model S")); len(got) != 1 || got[0] != "/model sonnet" {
+		t.Errorf("expected choices filtered by prefix, got %q", got)
+	}
+	if got := c.GetCompletions("/model opus "); got != nil {
+		t.Errorf("expected no completions for a plain argument, got %+v", got)
+	}
+	if got := values(c.GetCompletions("/verbose ")); strings.Join(got, "|") != "/verbose on|/verbose off" {
+		t.Errorf("expected on/off for a bool, got %q", got)
+	}
+	if got := values(c.GetCompletions("/open src/")); strings.Join(got, "|") != `/open src/main.go|/open "my file.go"` {
+		t.Errorf("unexpected file completions %q", got)
+	}
+	if got := c.GetCompletions("/nope "); got != nil {
+		t.Errorf("expected nothing for unknown commands, got %+v", got)
+	}
+}
+
+func TestCommandsRegister(t *testing.T) {
+	c := testCommands()
+	c.Register(Command{Name: "/model", Description: "Replaced"})
+	if cmd, _ := c.Lookup("model"); cmd.Description != "Replaced" {
+		t.Errorf("expected the command replaced, got %+v", cmd)
+	}
+	c.Unregister("note")
+	if _, ok := c.Lookup("note"); ok {
+		t.Error("expected note removed")
+	}
+	var names []string
+	for _, cmd := range c.List() {
+		names = append(names, cmd.Name)
+	}
+	if strings.Join(names, " ") != "model verbose" {
+		t.Errorf("expected commands in name order, got %v", names)
+	}
+}
+
+func TestShellRunsCommands(t *testing.T) {
+	var submitted []string
+	var ran Values
+	commands := testCommands()
+	commands.Register(Command{Name: "go", Args: []CommandArg{{Name: "n", Type: ArgInt}}, Handler: func(args Values) tea.Cmd {
+		ran = args
+		return nil
+	}})
+
+	cfg := DefaultConfig()
+	cfg.Commands = commands
+	cfg.OnInputSubmit = func(v string) { submitted = append(submitted, v) }
+	s := New(nil, cfg)
+
+	s.Update(InputSubmitMsg{Value: "/go 3"})
+	if ran.Int("n") != 3 || len(submitted) != 0 {
+		t.Errorf("expected the handler run instead of submitting, ran %v, submitted %v", ran, submitted)
+	}
+
+	s.Update(InputSubmitMsg{Value: "//go 3"})
+	s.Update(InputSubmitMsg{Value: "hello /go"})
+	if strings.Join(submitted, "|") != "/go 3|hello /go" {
+		t.Errorf("unexpected submissions %q", submitted)
+	}
+
+	// Errors keep the line for fixing
+	_, cmd := s.Update(InputSubmitMsg{Value: "/go many"})
+	if s.input.Value() != "/go many" || !strings.Contains(s.statusBar.notice, "n must be a whole number") {
+		t.Errorf("expected the error shown and the line kept, got %q / %q", s.input.Value(), s.statusBar.notice)
+	}
+	if cmd == nil {
+		t.Fatal("expected the notice to expire")
+	}
+	s.Update(noticeExpiredMsg{notice: s.statusBar.notice})
+	if s.statusBar.notice != "" {
+		t.Error("expected the notice cleared")
+	}
+}
+
+func TestShellBuiltinCommands(t *testing.T) {
+	cleared := false
+	cfg := DefaultConfig()
+	cfg.Commands = NewCommands(Command{Name: "help", Description: "Custom help"})
+	cfg.OnClearChat = func() { cleared = true }
+	s := New(nil, cfg)
+
+	for _, name := range []string{"clear", "theme", "quit", "exit"} {
+		if _, ok := cfg.Commands.Lookup(name); !ok {
+			t.Errorf("expected built-in /%s", name)
+		}
+	}
+	for _, name := range []string{"save", "errors"} {
+		if _, ok := cfg.Commands.Lookup(name); ok {
+			t.Errorf("expected no /%s without its hook", name)
+		}
+	}
+	if cmd, _ := cfg.Commands.Lookup("help"); cmd.Description != "Custom help" {
+		t.Error("expected registered commands to win over built-ins")
+	}
+
+	s.Update(InputSubmitMsg{Value: "/clear"})
+	if !cleared {
+		t.Error("expected /clear to call OnClearChat")
+	}
+	s.Update(InputSubmitMsg{Value: "/theme nord"})
+	if s.Theme().Name() != "nord" || s.input.theme.Name() != "nord" {
+		t.Errorf("expected the nord theme, got %s", s.Theme().Name())
+	}
+	if _, cmd := s.Update(InputSubmitMsg{Value: "/exit"}); cmd == nil {
+		t.Error("expected /exit to quit")
+	}
+
+	// Completed through the input's autocomplete, listed in help
+	s.input.SetValue("/the")
+	s.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if sel := s.input.autocomplete.GetSelected(); sel == nil || sel.Value != "/theme " {
+		t.Errorf("expected /theme completed, got %+v", sel)
+	}
+	cat := cfg.Commands.HelpCategory()
+	if len(cat.Bindings) == 0 || cat.Bindings[0].Key != "/clear" {
+		t.Errorf("unexpected help category %+v", cat)
+	}
+}
@@ -0,0 +1,129 @@
+package shell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// resetSGR ends any styling left open by a spliced segment.
+const resetSGR = "\x1b[0m"
+
+// fitLines returns s as exactly height lines, each exactly width cells,
+// padding with spaces and truncating as needed.
+func fitLines(s string, width, height int) []string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		w := ansi.StringWidth(line)
+		switch {
+		case w > width:
+			lines[i] = ansi.Truncate(line, width, "")
+		case w < width:
+			lines[i] = line + strings.Repeat(" ", width-w)
+		}
+	}
+	return lines
+}
+
+// overlay draws top over base with its top-left corner at column x, row y.
+// Both may contain ANSI escape codes; base cells outside top keep their
+// content and styling. Rows and columns of top beyond base are clipped.
+func overlay(base []string, top string, x, y int) []string {
+	out := make([]string, len(base))
+	copy(out, base)
+
+	for i, line := range strings.Split(top, "\n") {
+		row := y + i
+		if row < 0 || row >= len(out) {
+			continue
+		}
+		out[row] = spliceLine(out[row], line, x)
+	}
+	return out
+}
+
+// spliceLine replaces the cells of base starting at column x with top.
+func spliceLine(base, top string, x int) string {
+	baseWidth := ansi.StringWidth(base)
+	if x >= baseWidth {
+		return base
+	}
+	if x < 0 {
+		top = ansi.TruncateLeft(top, -x, "")
+		x = 0
+	}
+	topWidth := ansi.StringWidth(top)
+	if x+topWidth > baseWidth {
+		top = ansi.Truncate(top, baseWidth-x, "")
+		topWidth = ansi.StringWidth(top)
+	}
+
+	var b strings.Builder
+
+	// A wide character cut at the left edge leaves a gap to fill
+	left := ansi.Truncate(base, x, "")
+	b.WriteString(left)
+	b.WriteString(strings.Repeat(" ", x-ansi.StringWidth(left)))
+	b.WriteString(resetSGR)
+
+	b.WriteString(top)
+	b.WriteString(resetSGR)
+
+	// A wide character cut at the right edge is replaced by a space
+	end := x + topWidth
+	right := ansi.TruncateLeft(base, end, "")
+	if ansi.StringWidth(right) > baseWidth-end {
+		right = " " + ansi.TruncateLeft(base, end+1, "")
+	}
+	b.WriteString(right)
+
+	return b.String()
+}
+
+// dimLines redraws lines as a backdrop: the text is kept but its styling
+// is replaced by style.
+func dimLines(lines []string, style lipgloss.Style) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = style.Render(ansi.Strip(line))
+	}
+	return out
+}
+
+// blendColors mixes from towards to by t (0 = from, 1 = to). It returns
+// false if either colour is not a #RRGGBB hex colour.
+func blendColors(from, to lipgloss.Color, t float64) (lipgloss.Color, bool) {
+	fr, fg, fb, ok := parseHex(string(from))
+	if !ok {
+		return "", false
+	}
+	tr, tg, tb, ok := parseHex(string(to))
+	if !ok {
+		return "", false
+	}
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", mix(fr, tr), mix(fg, tg), mix(fb, tb))), true
+}
+
+// parseHex parses a #RRGGBB colour.
+func parseHex(s string) (r, g, b uint8, ok bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
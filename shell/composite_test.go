@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestFitLines(t *testing.T) {
+	lines := fitLines("ab\ncdefgh", 4, 3)
+	want := []string{"ab  ", "cdef", "    "}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(lines))
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+}
+
+func TestOverlayKeepsBaseAroundTop(t *testing.T) {
+	base := []string{"..........", "..........", ".........."}
+	out := overlay(base, "AB\nCD", 3, 1)
+
+	want := []string{"..........", "...AB.....", "...CD....."}
+	for i := range want {
+		if got := ansi.Strip(out[i]); got != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+	if base[1] != ".........." {
+		t.Error("overlay should not modify base")
+	}
+}
+
+func TestOverlayPreservesBaseStyling(t *testing.T) {
+	styled := "\x1b[31m" + "abcdefgh" + "\x1b[0m"
+
+	out := overlay([]string{styled}, "XY", 2, 0)
+	if got := ansi.Strip(out[0]); got != "abXYefgh" {
+		t.Fatalf("expected abXYefgh, got %q", got)
+	}
+	// The right-hand base cells keep their colour
+	right := out[0][strings.Index(out[0], "XY"):]
+	if !strings.Contains(right, "\x1b[31m") {
+		t.Errorf("expected base styling to carry over after the top: %q", out[0])
+	}
+}
+
+func TestOverlayClipsAndHandlesWideChars(t *testing.T) {
+	out := overlay([]string{"日本語テキスト"}, "ab", 3, 0)
+	got := ansi.Strip(out[0])
+	if w := ansi.StringWidth(got); w != 14 {
+		t.Errorf("expected width 14, got %d (%q)", w, got)
+	}
+	if !strings.Contains(got, "ab") {
+		t.Errorf("expected top to be drawn: %q", got)
+	}
+
+	out = overlay([]string{"....."}, "ABCDEFG", 3, 0)
+	if got := ansi.Strip(out[0]); got != "...AB" {
+		t.Errorf("expected clipping at the right edge, got %q", got)
+	}
+	out = overlay([]string{"....."}, "AB", 0, 5)
+	if got := ansi.Strip(out[0]); got != "....." {
+		t.Errorf("expected rows past the base to be clipped, got %q", got)
+	}
+}
+
+func TestBlendColors(t *testing.T) {
+	c, ok := blendColors("#000000", "#ffffff", 0.5)
+	if !ok || c != "#808080" {
+		t.Errorf("expected #808080, got %q %v", c, ok)
+	}
+	if _, ok := blendColors("12", "#ffffff", 0.5); ok {
+		t.Error("expected non-hex colour to fail")
+	}
+}
@@ -0,0 +1,189 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/2389-research/tux/fuzzy"
+)
+
+// DefaultFileLimit is the most entries a FileProvider reads from a
+// directory.
+const DefaultFileLimit = 2000
+
+// FileProvider completes filesystem paths. It completes the last word of
+// the input, so a path can follow other text, one directory level at a
+// time: choosing a directory completes to its name and a slash, and the
+// next completion lists its contents. Names are matched fuzzily against
+// what is typed after the last slash.
+//
+// Hidden files are left out unless shown or typed with a leading dot, and
+// inside a git repository files ignored by .gitignore are left out too. At
+// most DefaultFileLimit entries (see WithLimit) are read per directory, so
+// huge directories do not hold up the UI.
+type FileProvider struct {
+	root       string
+	showHidden bool
+	gitignore  bool
+	limit      int
+}
+
+// NewFileProvider creates a file provider resolving relative paths against
+// root, or the working directory if root is "".
+func NewFileProvider(root string) *FileProvider {
+	return &FileProvider{
+		root:      root,
+		gitignore: true,
+		limit:     DefaultFileLimit,
+	}
+}
+
+// WithHidden sets whether hidden files are offered without a leading dot
+// being typed.
+func (p *FileProvider) WithHidden(show bool) *FileProvider {
+	p.showHidden = show
+	return p
+}
+
+// WithGitignore sets whether files ignored by .gitignore are left out.
+func (p *FileProvider) WithGitignore(respect bool) *FileProvider {
+	p.gitignore = respect
+	return p
+}
+
+// WithLimit sets the most entries read from a directory.
+func (p *FileProvider) WithLimit(n int) *FileProvider {
+	if n > 0 {
+		p.limit = n
+	}
+	return p
+}
+
+// GetCompletions implements CompletionProvider. Values are the input with
+// its last word completed; descriptions give each file's size, or "dir". A
+// last word starting with @ is completed as a mention, keeping the @.
+func (p *FileProvider) GetCompletions(input string) []Completion {
+	word := lastWord(input)
+	head := input[:len(input)-len(word)]
+	if strings.HasPrefix(word, "@") {
+		head, word = head+"@", word[1:]
+	}
+
+	// Complete names in the directory typed so far
+	typedDir, query := "", word
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		typedDir, query = word[:i+1], word[i+1:]
+	}
+	dir := p.Resolve(typedDir)
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	entries, err := f.ReadDir(p.limit)
+	f.Close()
+	if err != nil && err != io.EOF {
+		return nil
+	}
+
+	var ignore ignoreChain
+	if p.gitignore {
+		ignore = loadIgnoreChain(dir)
+	}
+
+	var matches []Completion
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" || (strings.HasPrefix(name, ".") && !p.showHidden && !strings.HasPrefix(query, ".")) {
+			continue
+		}
+		match, ok := fuzzy.Match(query, name)
+		if !ok {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		info, err := entry.Info()
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			// Follow links to directories
+			if target, err := os.Stat(path); err == nil {
+				info, isDir = target, target.IsDir()
+			}
+		}
+		if ignore.ignored(path, isDir) {
+			continue
+		}
+
+		c := Completion{Value: head + typedDir + name, Display: name, Score: match.Score, Positions: match.Positions}
+		switch {
+		case isDir:
+			c.Value += "/"
+			c.Display += "/"
+			c.Description = "dir"
+		case err == nil:
+			c.Description = FormatSize(info.Size())
+		}
+		matches = append(matches, c)
+	}
+	slices.SortStableFunc(matches, func(a, b Completion) int {
+		return strings.Compare(a.Display, b.Display)
+	})
+	return matches
+}
+
+// Resolve returns the file a typed path names: "~" is expanded and relative
+// paths are joined to the root.
+func (p *FileProvider) Resolve(typed string) string {
+	if typed == "~/" || strings.HasPrefix(typed, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, typed[2:])
+		}
+	}
+	if filepath.IsAbs(typed) {
+		return typed
+	}
+	root := p.root
+	if root == "" {
+		root = "."
+	}
+	return filepath.Join(root, typed)
+}
+
+// lastWord returns the text after the last space.
+func lastWord(s string) string {
+	return s[strings.LastIndexAny(s, " \t\n")+1:]
+}
+
+// looksLikePath reports whether a word starts like a filesystem path.
+func looksLikePath(word string) bool {
+	for _, prefix := range []string{"./", "../", "~/", "/"} {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return word == "~" || word == "." || word == ".."
+}
+
+// FormatSize formats a size in bytes compactly: 512 B, 1.5 KB, 12 MB.
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	value := float64(n) / float64(div)
+	suffix := []string{"KB", "MB", "GB", "TB", "PB", "EB"}[exp]
+	if value >= 10 {
+		return fmt.Sprintf("%.0f %s", value, suffix)
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,207 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// writeTree creates files under dir; names ending in "/" are directories.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func completionValues(cs []Completion) []string {
+	var out []string
+	for _, c := range cs {
+		out = append(out, c.Value)
+	}
+	return out
+}
+
+func TestFileProviderCompletesLastWord(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"main.go":         "package main\n",
+		"src/handler.go":  strings.Repeat("x", 2048),
+		"src/helpers.go":  "",
+		"README.md":       "",
+		".env":            "",
+		"docs/":           "",
+		"src/deep/a.txt":  "",
+		"src/other/b.txt": "",
+	})
+	p := NewFileProvider(dir)
+
+	got := p.GetCompletions("look at ./")
+	want := []string{"look at ./README.md", "look at ./docs/", "look at ./main.go", "look at ./src/"}
+	if strings.Join(completionValues(got), "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected completions\ngot  %q\nwant %q", completionValues(got), want)
+	}
+	for _, c := range got {
+		switch c.Display {
+		case "src/":
+			if c.Description != "dir" {
+				t.Errorf("expected src marked as a directory, got %q", c.Description)
+			}
+		case "main.go":
+			if c.Description != "13 B" {
+				t.Errorf("expected main.go's size, got %q", c.Description)
+			}
+		}
+	}
+
+	// Descends one directory at a time, matching fuzzily
+	got = p.GetCompletions("./src/h")
+	if strings.Join(completionValues(got), "|") != "./src/handler.go|./src/helpers.go|./src/other/" {
+		t.Errorf("unexpected completions %q", completionValues(got))
+	}
+	if got[0].Description != "2.0 KB" {
+		t.Errorf("expected a size in KB, got %q", got[0].Description)
+	}
+
+	// Hidden files need a leading dot, or WithHidden
+	if got := p.GetCompletions("./.e"); len(got) != 1 || got[0].Value != "./.env" {
+		t.Errorf("expected .env for a leading dot, got %q", completionValues(got))
+	}
+	if got := p.WithHidden(true).GetCompletions("./"); len(got) != 5 {
+		t.Errorf("expected hidden files shown, got %q", completionValues(got))
+	}
+
+	if got := p.GetCompletions("./missing/"); got != nil {
+		t.Errorf("expected nothing for a missing directory, got %q", completionValues(got))
+	}
+}
+
+func TestFileProviderFuzzyRanking(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"handler_test.go": "",
+		"handler.go":      "",
+		"hand.go":         "",
+		"thread_log.go":   "",
+	})
+
+	ac := NewAutocomplete()
+	ac.RegisterProvider("file", NewFileProvider(dir))
+	ac.ShowAuto("./hdl")
+
+	got := completionValues(ac.Completions())
+	if strings.Join(got, "|") != "./handler.go|./handler_test.go|./thread_log.go" {
+		t.Errorf("unexpected ranking %q", got)
+	}
+}
+
+func TestFileProviderGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".git/":            "",
+		".gitignore":       "*.log\nbuild/\n!keep.log\n",
+		"app.log":          "",
+		"keep.log":         "",
+		"build/out":        "",
+		"main.go":          "",
+		"sub/.gitignore":   "local.txt\n",
+		"sub/local.txt":    "",
+		"sub/shared.txt":   "",
+		"sub/nested.log":   "",
+		"sub/build/x.txt":  "",
+		"sub/keep/one.txt": "",
+	})
+	p := NewFileProvider(dir)
+
+	if got := completionValues(p.GetCompletions("./")); strings.Join(got, "|") != "./keep.log|./main.go|./sub/" {
+		t.Errorf("expected ignored files left out, got %q", got)
+	}
+	if got := completionValues(p.GetCompletions("./sub/")); strings.Join(got, "|") != "./sub/keep/|./sub/shared.txt" {
+		t.Errorf("expected nested rules applied, got %q", got)
+	}
+	if got := p.WithGitignore(false).GetCompletions("./"); len(got) != 5 {
+		t.Errorf("expected ignored files shown, got %q", completionValues(got))
+	}
+}
+
+func TestFileProviderLimit(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		files[name] = ""
+	}
+	writeTree(t, dir, files)
+
+	if got := NewFileProvider(dir).WithLimit(2).GetCompletions("./"); len(got) != 2 {
+		t.Errorf("expected 2 entries read, got %d", len(got))
+	}
+}
+
+func TestFileProviderHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeTree(t, home, map[string]string{"notes.txt": ""})
+
+	if got := NewFileProvider("").GetCompletions("~/no"); len(got) != 1 || got[0].Value != "~/notes.txt" {
+		t.Errorf("expected ~ expanded and kept, got %q", completionValues(got))
+	}
+}
+
+func TestInputCompletesAtCursor(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"main.go": ""})
+	ac := NewAutocomplete()
+	ac.RegisterProvider("file", NewFileProvider(dir))
+
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetAutocomplete(ac)
+	input.SetValue("fix ./ma please")
+	for range len(" please") {
+		input.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	}
+
+	input.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if !ac.Active() {
+		t.Fatal("expected completions for the word before the cursor")
+	}
+	input.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := input.Value(); got != "fix ./main.go please" {
+		t.Errorf("expected the word completed in place, got %q", got)
+	}
+	input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if got := input.Value(); got != "fix ./main.go! please" {
+		t.Errorf("expected the cursor after the completion, got %q", got)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		1536:          "1.5 KB",
+		20 * 1024:     "20 KB",
+		3 << 20:       "3.0 MB",
+		5<<30 + 1<<29: "5.5 GB",
+	}
+	for n, want := range tests {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package shell
 
 import (
+	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -38,6 +39,7 @@ type Form struct {
 	currentGroup int
 	focusedIndex int
 	state        State
+	err          error // Validation error blocking the last enter
 	theme        theme.Theme
 	onSubmit     func(Values)
 	onCancel     func()
@@ -129,6 +131,12 @@ func (f *Form) Values() Values {
 	return v
 }
 
+// Err returns the validation error that stopped the form leaving its
+// current group, or nil.
+func (f *Form) Err() error {
+	return f.err
+}
+
 // FocusedIndex returns the index of the focused field in the current group.
 func (f *Form) FocusedIndex() int {
 	return f.focusedIndex
@@ -158,6 +166,7 @@ func (f *Form) HandleKey(key tea.KeyMsg) bool {
 	}
 
 	fields := f.currentFields()
+	f.err = nil
 
 	switch key.Type {
 	case tea.KeyEscape:
@@ -189,6 +198,9 @@ func (f *Form) HandleKey(key tea.KeyMsg) bool {
 	case tea.KeyEnter:
 		// On last field, submit or go to next group
 		if f.focusedIndex == len(fields)-1 {
+			if !f.validateGroup() {
+				return true
+			}
 			if f.currentGroup == len(f.groups)-1 {
 				// Last group - submit
 				f.state = StateSubmitted
@@ -223,6 +235,27 @@ func (f *Form) HandleKey(key tea.KeyMsg) bool {
 	return false
 }
 
+// validateGroup validates the current group's fields. On failure it focuses
+// the first invalid field and keeps its error for Render.
+func (f *Form) validateGroup() bool {
+	fields := f.currentFields()
+	for i, field := range fields {
+		err := field.Validate()
+		if err == nil {
+			continue
+		}
+		if label := field.Label(); label != "" {
+			err = fmt.Errorf("%s: %w", label, err)
+		}
+		f.err = err
+		fields[f.focusedIndex].Blur()
+		f.focusedIndex = i
+		field.Focus()
+		return false
+	}
+	return true
+}
+
 // Render renders the form.
 func (f *Form) Render(width, height int) string {
 	if f.theme == nil {
@@ -244,6 +277,10 @@ func (f *Form) Render(width, height int) string {
 		parts = append(parts, field.Render(width-4, f.theme, focused))
 	}
 
+	if f.err != nil {
+		parts = append(parts, "", f.theme.Styles().Error.Render(f.err.Error()))
+	}
+
 	// Page indicator for multi-group forms
 	if len(f.groups) > 1 {
 		indicator := f.theme.Styles().Muted.Render(
@@ -2,6 +2,7 @@
 package shell
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -282,3 +283,29 @@ func TestFormDelegatesKeyToField(t *testing.T) {
 	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}
 	f.HandleKey(key)
 }
+
+func TestFormValidatesBeforeSubmit(t *testing.T) {
+	submitted := false
+	name := NewInputField().WithID("name").WithLabel("Name").WithValidators(Required())
+	f := NewForm(name, NewConfirm().WithID("ok").WithLabel("OK")).
+		OnSubmit(func(Values) { submitted = true })
+	f.Init()
+
+	f.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	f.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if submitted || f.State() != StateActive {
+		t.Fatal("expected an invalid form not to submit")
+	}
+	if f.Err() == nil || f.FocusedIndex() != 0 {
+		t.Errorf("expected the invalid field focused with an error, got %v at %d", f.Err(), f.FocusedIndex())
+	}
+	if !strings.Contains(f.Render(80, 20), "Name: required") {
+		t.Error("expected the error rendered")
+	}
+
+	// The error clears on the next key
+	f.HandleKey(tea.KeyMsg{Type: tea.KeyTab})
+	if f.Err() != nil {
+		t.Errorf("expected error cleared, got %v", f.Err())
+	}
+}
@@ -0,0 +1,272 @@
+// shell/form_params.go
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// paramKind is how a tool parameter is edited in a params form.
+type paramKind int
+
+const (
+	paramFixed paramKind = iota // Shown but not editable
+	paramString
+	paramInt
+	paramFloat
+	paramBool
+	paramList
+)
+
+// kindOf returns how a parameter value is edited. Strings spanning lines,
+// lists with items that would not survive comma splitting, and nested
+// values are not editable.
+func kindOf(v any) paramKind {
+	switch v := v.(type) {
+	case string:
+		if strings.Contains(v, "\n") {
+			return paramFixed
+		}
+		return paramString
+	case int, int32, int64:
+		return paramInt
+	case float32, float64:
+		return paramFloat
+	case bool:
+		return paramBool
+	case []string:
+		for _, s := range v {
+			if !listItemOK(s) {
+				return paramFixed
+			}
+		}
+		return paramList
+	case []any:
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				if !listItemOK(item) {
+					return paramFixed
+				}
+			case int, int32, int64, float32, float64:
+			default:
+				return paramFixed
+			}
+		}
+		return paramList
+	}
+	return paramFixed
+}
+
+// listItemOK reports whether a list item survives being joined with commas
+// and split again.
+func listItemOK(s string) bool {
+	return s != "" && s == strings.TrimSpace(s) && !strings.ContainsAny(s, ",\n")
+}
+
+// CanEditParams reports whether a params form for the parameters would have
+// at least one editable field.
+func CanEditParams(params map[string]any) bool {
+	for _, v := range params {
+		if kindOf(v) != paramFixed {
+			return true
+		}
+	}
+	return false
+}
+
+// NewParamsForm returns a form for editing tool parameters, one field per
+// parameter in name order: an input for strings and numbers, a confirm for
+// booleans and an input of comma-separated items for lists. Other values,
+// and strings spanning lines, are shown but cannot be edited. Use
+// ParamsFromValues to turn the submitted values back into parameters.
+func NewParamsForm(params map[string]any) *Form {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var fields []any
+	for _, name := range names {
+		v := params[name]
+		switch kindOf(v) {
+		case paramString:
+			f := NewInputField().WithID(name).WithLabel(name)
+			f.SetValue(v)
+			fields = append(fields, f)
+		case paramInt:
+			f := NewInputField().WithID(name).WithLabel(name).
+				WithValidators(Required(), Pattern(`^\s*-?\d+\s*$`, "must be a whole number"))
+			f.SetValue(fmt.Sprint(v))
+			fields = append(fields, f)
+		case paramFloat:
+			f := NewInputField().WithID(name).WithLabel(name).
+				WithValidators(Required(), Number())
+			f.SetValue(formatFloat(v))
+			fields = append(fields, f)
+		case paramBool:
+			f := NewConfirm().WithID(name).WithLabel(name)
+			f.SetValue(v)
+			fields = append(fields, f)
+		case paramList:
+			f := NewInputField().WithID(name).WithLabel(name).
+				WithPlaceholder("comma-separated")
+			if numericList(v) {
+				f.WithValidators(numberList)
+			}
+			f.SetValue(formatList(v))
+			fields = append(fields, f)
+		default:
+			fields = append(fields, NewNote().WithTitle(name).WithContent(fixedSummary(v)))
+		}
+	}
+	return NewForm(fields...)
+}
+
+// ParamsFromValues converts the values submitted from a NewParamsForm back
+// into parameters of their original types. Parameters the form could not
+// edit keep their value.
+func ParamsFromValues(params map[string]any, values Values) (map[string]any, error) {
+	out := make(map[string]any, len(params))
+	for name, v := range params {
+		out[name] = v
+		if _, ok := values[name]; !ok {
+			continue
+		}
+		s := strings.TrimSpace(values.String(name))
+
+		switch orig := v.(type) {
+		case string:
+			out[name] = values.String(name)
+		case bool:
+			out[name] = values.Bool(name)
+		case int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: must be a whole number", name)
+			}
+			out[name] = n
+		case int32:
+			n, err := strconv.ParseInt(s, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: must be a whole number", name)
+			}
+			out[name] = int32(n)
+		case int64:
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: must be a whole number", name)
+			}
+			out[name] = n
+		case float32:
+			f, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: must be a number", name)
+			}
+			out[name] = float32(f)
+		case float64:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: must be a number", name)
+			}
+			out[name] = f
+		case []string:
+			out[name] = splitList(s)
+		case []any:
+			items := splitList(s)
+			list := make([]any, len(items))
+			for i, item := range items {
+				list[i] = item
+				if numericList(orig) {
+					f, err := strconv.ParseFloat(item, 64)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %q is not a number", name, item)
+					}
+					list[i] = f
+				}
+			}
+			out[name] = list
+		}
+	}
+	return out, nil
+}
+
+// numberList validates a comma-separated list of numbers.
+func numberList(value any) error {
+	s, _ := value.(string)
+	for _, item := range splitList(s) {
+		if _, err := strconv.ParseFloat(item, 64); err != nil {
+			return errors.New("items must be numbers")
+		}
+	}
+	return nil
+}
+
+// numericList reports whether a list parameter holds numbers: it is a []any
+// whose items are all numbers, and not empty.
+func numericList(v any) bool {
+	list, ok := v.([]any)
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(string); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// formatList joins a list parameter's items with commas.
+func formatList(v any) string {
+	var items []string
+	switch v := v.(type) {
+	case []string:
+		items = v
+	case []any:
+		for _, item := range v {
+			if f, ok := item.(float64); ok {
+				items = append(items, formatFloat(f))
+			} else {
+				items = append(items, fmt.Sprint(item))
+			}
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// splitList splits a comma-separated list, dropping blank items.
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// formatFloat formats a float without trailing zeros.
+func formatFloat(v any) string {
+	switch v := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// fixedSummary describes a parameter the form cannot edit.
+func fixedSummary(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%d lines · not editable", strings.Count(s, "\n")+1)
+	}
+	return ansi.Truncate(fmt.Sprint(v), 60, "…") + " · not editable"
+}
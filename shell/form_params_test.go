@@ -0,0 +1,112 @@
+// shell/form_params_test.go
+package shell
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCanEditParams(t *testing.T) {
+	tests := []struct {
+		params map[string]any
+		want   bool
+	}{
+		{nil, false},
+		{map[string]any{"content": "a\nb"}, false},
+		{map[string]any{"opts": map[string]any{"x": 1}}, false},
+		{map[string]any{"items": []any{"a,b"}}, false},
+		{map[string]any{"path": "a.txt"}, true},
+		{map[string]any{"n": 3.0}, true},
+		{map[string]any{"force": true}, true},
+		{map[string]any{"files": []any{"a", "b"}}, true},
+	}
+	for _, tt := range tests {
+		if got := CanEditParams(tt.params); got != tt.want {
+			t.Errorf("CanEditParams(%v) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestParamsFormRoundTrip(t *testing.T) {
+	params := map[string]any{
+		"path":    "a.txt",
+		"limit":   10.0,
+		"count":   3,
+		"force":   true,
+		"files":   []any{"a", "b"},
+		"ids":     []any{1.0, 2.5},
+		"tags":    []string{"x"},
+		"content": "line 1\nline 2",
+	}
+	var values Values
+	f := NewParamsForm(params).OnSubmit(func(v Values) { values = v })
+	f.Init()
+
+	// Submit unchanged: every field in turn, then enter on the last
+	for f.State() == StateActive {
+		f.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if f.Err() != nil {
+		t.Fatalf("unexpected validation error: %v", f.Err())
+	}
+
+	got, err := ParamsFromValues(params, values)
+	if err != nil {
+		t.Fatalf("ParamsFromValues: %v", err)
+	}
+	if !reflect.DeepEqual(got, params) {
+		t.Errorf("expected unchanged params back\ngot  %#v\nwant %#v", got, params)
+	}
+
+	view := f.Render(80, 40)
+	if !strings.Contains(view, "2 lines · not editable") {
+		t.Errorf("expected multi-line content shown as not editable:\n%s", view)
+	}
+}
+
+func TestParamsFromValuesConverts(t *testing.T) {
+	params := map[string]any{
+		"limit": 10.0,
+		"count": 3,
+		"files": []any{"a"},
+		"ids":   []any{1.0},
+		"keep":  map[string]any{"x": 1},
+	}
+	got, err := ParamsFromValues(params, Values{
+		"limit": " 2.5 ",
+		"count": "7",
+		"files": "a, b, ,c",
+		"ids":   "4, 5",
+	})
+	if err != nil {
+		t.Fatalf("ParamsFromValues: %v", err)
+	}
+	want := map[string]any{
+		"limit": 2.5,
+		"count": 7,
+		"files": []any{"a", "b", "c"},
+		"ids":   []any{4.0, 5.0},
+		"keep":  map[string]any{"x": 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	if _, err := ParamsFromValues(params, Values{"count": "many"}); err == nil {
+		t.Error("expected an error for a non-numeric count")
+	}
+}
+
+func TestParamsFormValidatesNumbers(t *testing.T) {
+	count := NewParamsForm(map[string]any{"count": 3})
+	count.Init()
+	fields := count.currentFields()
+	fields[0].SetValue("3.5")
+	count.HandleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if count.State() != StateActive || count.Err() == nil {
+		t.Error("expected a fractional count to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -72,6 +73,21 @@ func Email() Validator {
 	return Pattern(`^[^@\s]+@[^@\s]+\.[^@\s]+$`, "invalid email format")
 }
 
+// Number validates that a string is a number. Empty strings pass; combine
+// with Required to reject them.
+func Number() Validator {
+	return func(value any) error {
+		s, ok := value.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return nil
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			return errors.New("must be a number")
+		}
+		return nil
+	}
+}
+
 // MinSelected validates minimum selections for multi-select.
 func MinSelected(n int) Validator {
 	return func(value any) error {
@@ -173,3 +173,19 @@ func TestMaxSelectedNonArray(t *testing.T) {
 		t.Error("int should pass MaxSelected")
 	}
 }
+
+func TestNumber(t *testing.T) {
+	v := Number()
+
+	for _, s := range []string{"42", "-1.5", " 3 ", ""} {
+		if v(s) != nil {
+			t.Errorf("%q should pass", s)
+		}
+	}
+	if v("abc") == nil {
+		t.Error("'abc' should fail")
+	}
+	if v(7) != nil {
+		t.Error("non-string should pass")
+	}
+}
@@ -0,0 +1,169 @@
+package shell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is a compiled .gitignore pattern.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitignore holds the rules of one .gitignore file, matched against paths
+// relative to the directory it is in.
+type gitignore struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// loadGitignore reads dir/.gitignore, returning nil if there is none.
+func loadGitignore(dir string) *gitignore {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	g := &gitignore{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	return g
+}
+
+// parseIgnoreRule compiles a .gitignore line. Blank lines and comments
+// report false.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Patterns with a slash are anchored to the .gitignore's directory,
+	// others match a name at any depth
+	prefix := "(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = ""
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(line):
+			i++
+			b.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re, err := regexp.Compile("^" + prefix + b.String() + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// match reports whether the file's ignored state is decided by these rules,
+// and if so whether it is ignored. The last matching rule wins.
+func (g *gitignore) match(path string, isDir bool) (matched, ignored bool) {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			matched, ignored = true, !rule.negate
+		}
+	}
+	return matched, ignored
+}
+
+// ignoreChain is the .gitignore files that apply to a directory: those from
+// the repository root down to it.
+type ignoreChain []*gitignore
+
+// loadIgnoreChain loads the .gitignore files applying to dir. Outside a git
+// repository there are none.
+func loadIgnoreChain(dir string) ignoreChain {
+	var dirs []string
+	for d := dir; ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			break
+		}
+		if filepath.Dir(d) == d {
+			return nil // Not in a repository
+		}
+	}
+
+	var chain ignoreChain
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if g := loadGitignore(dirs[i]); g != nil {
+			chain = append(chain, g)
+		}
+	}
+	return chain
+}
+
+// ignored reports whether a path is ignored. Deeper files override
+// shallower ones.
+func (c ignoreChain) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, g := range c {
+		if m, ig := g.match(path, isDir); m {
+			ignored = ig
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,64 @@
+package shell
+
+import "testing"
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "app.log", false, true},
+		{"*.log", "logs/app.log", false, true},
+		{"*.log", "app.go", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"/root.txt", "root.txt", false, true},
+		{"/root.txt", "sub/root.txt", false, false},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "docs/x/a.md", false, false},
+		{"**/gen", "a/b/gen", true, true},
+		{"out/**", "out/a/b", false, true},
+		{"a/**/z", "a/z", false, true},
+		{"a/**/z", "a/b/c/z", false, true},
+		{"file?.txt", "file1.txt", false, true},
+		{"file[0-9].txt", "file7.txt", false, true},
+		{"file[!0-9].txt", "file7.txt", false, false},
+		{`\#hash`, "#hash", false, true},
+	}
+	for _, tt := range tests {
+		rule, ok := parseIgnoreRule(tt.pattern)
+		if !ok {
+			t.Errorf("%q: failed to parse", tt.pattern)
+			continue
+		}
+		got := rule.re.MatchString(tt.path) && (!rule.dirOnly || tt.isDir)
+		if got != tt.want {
+			t.Errorf("%q matching %q (dir %v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok := parseIgnoreRule(line); ok {
+			t.Errorf("%q: expected no rule", line)
+		}
+	}
+}
+
+func TestGitignoreNegation(t *testing.T) {
+	g := &gitignore{dir: "/repo"}
+	for _, line := range []string{"*.log", "!keep.log"} {
+		rule, _ := parseIgnoreRule(line)
+		g.rules = append(g.rules, rule)
+	}
+	if _, ignored := g.match("/repo/app.log", false); !ignored {
+		t.Error("expected app.log ignored")
+	}
+	if matched, ignored := g.match("/repo/keep.log", false); !matched || ignored {
+		t.Error("expected keep.log re-included")
+	}
+	if matched, _ := g.match("/elsewhere/app.log", false); matched {
+		t.Error("expected paths outside the directory left alone")
+	}
+}
@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultInlineHeight is the height of the live region in inline mode when
+// the display config does not set one.
+const DefaultInlineHeight = 12
+
+// Inline reports whether the shell draws inline, below the terminal's
+// scrollback, instead of on the alternate screen.
+func (s *Shell) Inline() bool {
+	return s.config.Display != nil && s.config.Display.Inline
+}
+
+// liveHeight is the number of rows the shell draws. Inline, it is the live
+// region's height, capped by the window.
+func (s *Shell) liveHeight() int {
+	if !s.Inline() {
+		return s.height
+	}
+	h := s.config.Display.InlineHeight
+	if h <= 0 {
+		h = DefaultInlineHeight
+	}
+	return min(h, s.height)
+}
+
+// Println prints text permanently above the live region, into the
+// terminal's scrollback. Text printed before Run is printed when it starts.
+// Safe to call from any goroutine, including inside Update. It does
+// nothing unless the shell is inline.
+func (s *Shell) Println(text string) {
+	if !s.Inline() {
+		return
+	}
+	s.printMu.Lock()
+	s.prints = append(s.prints, text)
+	s.printMu.Unlock()
+
+	select {
+	case s.printReady <- struct{}{}:
+	default:
+	}
+}
+
+// takePrints returns and clears the text waiting to be printed.
+func (s *Shell) takePrints() string {
+	s.printMu.Lock()
+	defer s.printMu.Unlock()
+	text := strings.Join(s.prints, "\n")
+	s.prints = nil
+	return text
+}
+
+// runPrinter prints text passed to Println through p, in order, until done
+// is closed. It runs outside the update loop, which Send would block.
+func (s *Shell) runPrinter(p *tea.Program, done <-chan struct{}) {
+	for {
+		select {
+		case <-s.printReady:
+			if text := s.takePrints(); text != "" {
+				p.Send(tea.Println(text)())
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// inlineModalCanvas places the live region at the bottom of a screen-high
+// canvas, so modals opened inline get the whole window.
+func (s *Shell) inlineModalCanvas(live string) string {
+	rows := strings.Count(live, "\n") + 1
+	if rows >= s.height {
+		return live
+	}
+	return strings.Repeat("\n", s.height-rows) + live
+}
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newInlineShell(height int) *Shell {
+	cfg := DefaultConfig()
+	cfg.Display = &config.DisplayConfig{Inline: true, InlineHeight: height}
+	s := New(nil, cfg)
+	s.AddTab(Tab{ID: "chat", Label: "Chat"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	return s
+}
+
+func TestInlineLiveHeight(t *testing.T) {
+	s := newInlineShell(10)
+	if !s.Inline() {
+		t.Fatal("expected the shell inline")
+	}
+	if got := strings.Count(s.View(), "\n") + 1; got != 10 {
+		t.Errorf("expected a live region of 10 rows, got %d", got)
+	}
+
+	// Capped by the window
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 6})
+	if got := s.liveHeight(); got != 6 {
+		t.Errorf("expected the live region capped at 6 rows, got %d", got)
+	}
+
+	// Default height
+	s = newInlineShell(0)
+	if got := s.liveHeight(); got != DefaultInlineHeight {
+		t.Errorf("expected the default height %d, got %d", DefaultInlineHeight, got)
+	}
+}
+
+func TestInlineModalGetsWindow(t *testing.T) {
+	s := newInlineShell(10)
+	s.PushModal(NewYesNoModal("Delete", "Delete it?", func(bool) {}))
+
+	view := s.View()
+	if got := strings.Count(view, "\n") + 1; got != 40 {
+		t.Errorf("expected the modal drawn over the whole window, got %d rows", got)
+	}
+	if !strings.Contains(view, "Delete it?") {
+		t.Error("expected the modal in the view")
+	}
+}
+
+func TestInlinePrintln(t *testing.T) {
+	s := newInlineShell(10)
+	s.Println("one")
+	s.Println("two")
+
+	select {
+	case <-s.printReady:
+	default:
+		t.Fatal("expected the printer signalled")
+	}
+	if got := s.takePrints(); got != "one\ntwo" {
+		t.Errorf("expected both lines queued in order, got %q", got)
+	}
+	if got := s.takePrints(); got != "" {
+		t.Errorf("expected the queue emptied, got %q", got)
+	}
+}
+
+func TestPrintlnNotInline(t *testing.T) {
+	s := New(nil, DefaultConfig())
+	s.Println("ignored")
+	if s.Inline() || s.takePrints() != "" {
+		t.Error("expected Println to do nothing on the alternate screen")
+	}
+}
+
+func TestInlineIgnoresMouse(t *testing.T) {
+	cfg := mouseConfig()
+	cfg.Display = &config.DisplayConfig{Inline: true}
+	s := New(nil, cfg)
+	s.AddTab(Tab{ID: "one", Label: "One"})
+	s.AddTab(Tab{ID: "two", Label: "Two"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.Update(click(6, 0))
+	if s.tabs.ActiveTab().ID != "one" {
+		t.Error("expected clicks ignored inline")
+	}
+}
@@ -1,14 +1,22 @@
 package shell
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/theme"
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
-// Input is the text input component.
+// Input is the prompt editor. By default it holds a single line, growing
+// only by wrapping; in multiline mode the newline keys insert line breaks.
 type Input struct {
-	model           textinput.Model
+	model           textarea.Model
 	theme           theme.Theme
 	prefix          string
 	placeholder     string
@@ -21,37 +29,133 @@ type Input struct {
 
 	// Suggestions
 	suggestions *Suggestions
+
+	// Keymap decides which keys submit; nil means Enter
+	keymap *Keymap
+
+	// Editing options
+	multiline bool
+	maxHeight int // Most rows the editor grows to
+	showCount bool
+	maxChars  int // 0 means no limit
+
+	// Collapsed pastes, expanded in Value
+	pastes []pastedText
+
+	// Files mentioned as @path, shown as chips; nil mentions means none
+	mentions *FileProvider
+	chips    []string
+	chipsFor string // Value the chips were found in
+}
+
+// pastedText is a multi-line paste shown as a short label in the editor.
+type pastedText struct {
+	label string
+	text  string
 }
 
 // NewInput creates a new input component.
 func NewInput(th theme.Theme, prefix, placeholder string) *Input {
-	ti := textinput.New()
-	ti.Prompt = prefix
-	ti.Placeholder = placeholder
-	ti.Focus()
-
-	styles := th.Styles()
-	ti.PromptStyle = styles.Muted
-	ti.TextStyle = styles.Body
+	ta := textarea.New()
+	ta.Placeholder = placeholder
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
+	// Newlines are inserted by the Input so the keymap decides the keys
+	ta.KeyMap.InsertNewline.SetEnabled(false)
+
+	// The prefix starts the first line; later lines are indented to match
+	promptWidth := lipgloss.Width(prefix)
+	ta.SetPromptFunc(promptWidth, func(line int) string {
+		if line == 0 {
+			return prefix
+		}
+		return strings.Repeat(" ", promptWidth)
+	})
+	ta.SetHeight(1)
+	ta.Focus()
 
-	return &Input{
-		model:        ti,
-		theme:        th,
+	i := &Input{
+		model:        ta,
 		prefix:       prefix,
 		placeholder:  placeholder,
 		historyIndex: -1,
+		maxHeight:    1,
+	}
+	i.SetTheme(th)
+	return i
+}
+
+// SetTheme sets the theme the input is drawn with.
+func (i *Input) SetTheme(th theme.Theme) {
+	i.theme = th
+	styles := th.Styles()
+	style := textarea.Style{
+		Base:        lipgloss.NewStyle(),
+		CursorLine:  lipgloss.NewStyle(),
+		EndOfBuffer: lipgloss.NewStyle(),
+		Placeholder: styles.Muted,
+		Prompt:      styles.Muted,
+		Text:        styles.Body,
+	}
+	i.model.FocusedStyle = style
+	i.model.BlurredStyle = style
+	i.themeAutocomplete()
+}
+
+// themeAutocomplete highlights completion matches in the theme's primary
+// colour.
+func (i *Input) themeAutocomplete() {
+	if i.autocomplete != nil {
+		i.autocomplete.SetHighlightStyle(lipgloss.NewStyle().Foreground(i.theme.Primary()).Bold(true))
 	}
 }
 
+// ApplyConfig applies user input settings: multiline mode, the most rows
+// the editor grows to, the character counter and the character limit.
+func (i *Input) ApplyConfig(cfg config.InputConfig) {
+	i.multiline = cfg.Multiline
+	i.maxHeight = max(cfg.MaxHeight, 1)
+	i.showCount = cfg.ShowCharCount
+	i.maxChars = max(cfg.MaxChars, 0)
+	i.resize()
+}
+
+// Multiline reports whether the newline keys insert line breaks.
+func (i *Input) Multiline() bool {
+	return i.multiline
+}
+
 // Init initializes the input.
 func (i *Input) Init() tea.Cmd {
-	return textinput.Blink
+	return textarea.Blink
 }
 
 // Update handles input messages.
 func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
+	defer i.resize()
+	defer i.updateChips()
+
 	switch msg := msg.(type) {
+	case completionDebounceMsg:
+		if i.autocomplete != nil {
+			return i, i.autocomplete.handleDebounce(msg)
+		}
+		return i, nil
+
+	case completionsMsg:
+		if i.autocomplete != nil {
+			i.autocomplete.handleResults(msg)
+		}
+		return i, nil
+
 	case tea.KeyMsg:
+		if msg.Paste {
+			i.paste(string(msg.Runes))
+			i.updateSuggestions()
+			return i, nil
+		}
+
 		// Handle autocomplete keys first
 		if i.autocomplete != nil && i.autocomplete.Active() {
 			switch msg.Type {
@@ -62,10 +166,10 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 				i.autocomplete.Previous()
 				return i, nil
 			case tea.KeyEnter:
-				// Accept selected completion
-				if selected := i.autocomplete.GetSelected(); selected != nil {
-					i.model.SetValue(selected.Value)
-					i.model.CursorEnd()
+				// Accept selected completion, unless it is already typed
+				before, _ := i.splitAtCursor()
+				if selected := i.autocomplete.GetSelected(); selected != nil && selected.Value != before {
+					i.acceptCompletion(selected.Value)
 					i.autocomplete.Hide()
 					return i, nil
 				}
@@ -81,18 +185,10 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 			}
 		}
 
-		switch msg.Type {
-		case tea.KeyTab:
-			// Trigger autocomplete
-			if i.autocomplete != nil {
-				i.autocomplete.ShowAuto(i.model.Value())
-			}
-			return i, nil
-
-		case tea.KeyEnter:
-			value := i.model.Value()
+		if i.isSubmit(msg) {
+			value := i.Value()
 			if value != "" {
-				i.model.SetValue("")
+				i.SetValue("")
 				i.historyIndex = -1 // Reset history navigation
 				if i.autocomplete != nil {
 					i.autocomplete.Hide()
@@ -102,8 +198,27 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 				}
 			}
 			return i, nil
+		}
+
+		if i.multiline && i.keymap != nil && i.keymap.Matches(ActionNewline, msg) {
+			i.insert("\n")
+			return i, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyTab:
+			// Trigger autocomplete on the text before the cursor
+			if i.autocomplete != nil {
+				before, _ := i.splitAtCursor()
+				return i, i.autocomplete.Query(before)
+			}
+			return i, nil
 
 		case tea.KeyUp:
+			// Move between lines; history takes over on the first line
+			if !i.onFirstRow() {
+				break
+			}
 			if i.historyProvider != nil {
 				history := i.historyProvider()
 				if len(history) > 0 {
@@ -113,27 +228,29 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 					} else if i.historyIndex > 0 {
 						i.historyIndex--
 					}
-					i.model.SetValue(history[i.historyIndex])
-					i.model.CursorEnd()
+					i.SetValue(history[i.historyIndex])
 				}
 			}
 			return i, nil
 
 		case tea.KeyDown:
+			// Move between lines; history takes over on the last line
+			if !i.onLastRow() {
+				break
+			}
 			if i.historyProvider != nil && i.historyIndex != -1 {
 				history := i.historyProvider()
 				// Validate bounds in case history changed
 				if i.historyIndex >= len(history) {
 					i.historyIndex = -1
-					i.model.SetValue("")
+					i.SetValue("")
 				} else if i.historyIndex < len(history)-1 {
 					i.historyIndex++
-					i.model.SetValue(history[i.historyIndex])
-					i.model.CursorEnd()
+					i.SetValue(history[i.historyIndex])
 				} else {
 					// Past end - clear and reset
 					i.historyIndex = -1
-					i.model.SetValue("")
+					i.SetValue("")
 				}
 			}
 			return i, nil
@@ -144,6 +261,25 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 				i.autocomplete.Hide()
 			}
 			return i, nil
+
+		case tea.KeyBackspace:
+			// A collapsed paste is deleted whole
+			if i.deletePasteBeforeCursor() {
+				i.updateSuggestions()
+				return i, nil
+			}
+
+		case tea.KeyRunes, tea.KeySpace:
+			// Alt-modified keys are the textarea's word edits
+			if msg.Alt {
+				break
+			}
+			if i.insert(string(msg.Runes)) {
+				i.hideAutocompleteOnType()
+				i.updateSuggestions()
+				return i, i.liveComplete()
+			}
+			return i, nil
 		}
 
 		// Hide autocomplete when typing (will re-trigger on Tab)
@@ -157,8 +293,7 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 		if msg.String() == "ctrl+right" {
 			if i.suggestions != nil && i.suggestions.Active() {
 				if top := i.suggestions.Top(); top != nil {
-					i.model.SetValue(top.Action)
-					i.model.CursorEnd()
+					i.SetValue(top.Action)
 					i.suggestions.Hide()
 					return i, nil
 				}
@@ -166,21 +301,208 @@ func (i *Input) Update(msg tea.Msg) (*Input, tea.Cmd) {
 		}
 	}
 
+	value := i.model.Value()
 	var cmd tea.Cmd
 	i.model, cmd = i.model.Update(msg)
 
 	// Update suggestions after input changes
+	i.updateSuggestions()
+
+	if _, ok := msg.(tea.KeyMsg); ok && i.model.Value() != value {
+		cmd = tea.Batch(cmd, i.liveComplete())
+	}
+	return i, cmd
+}
+
+// updateSuggestions re-analyzes suggestions for the current value.
+func (i *Input) updateSuggestions() {
 	if i.suggestions != nil {
-		i.suggestions.Update(i.model.Value())
+		i.suggestions.Update(i.Value())
 	}
+}
 
-	return i, cmd
+// splitAtCursor returns the text before and after the cursor. Collapsed
+// pastes are left collapsed.
+func (i *Input) splitAtCursor() (before, after string) {
+	lines := strings.Split(i.model.Value(), "\n")
+	row := min(i.model.Line(), len(lines)-1)
+	line := []rune(lines[row])
+	info := i.model.LineInfo()
+	col := min(info.StartColumn+info.ColumnOffset, len(line))
+
+	before = strings.Join(append(slices.Clone(lines[:row]), string(line[:col])), "\n")
+	after = strings.Join(append([]string{string(line[col:])}, lines[row+1:]...), "\n")
+	return before, after
+}
+
+// acceptCompletion replaces the text before the cursor with a completion,
+// keeping the text after it, and leaves the cursor at the completion's end.
+func (i *Input) acceptCompletion(value string) {
+	_, tail := i.splitAtCursor()
+	i.model.SetValue(value + tail)
+	for range utf8.RuneCountInString(tail) {
+		i.model, _ = i.model.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	}
+	i.resize()
+}
+
+// liveComplete schedules completion of the text before the cursor, when
+// completing as the user types.
+func (i *Input) liveComplete() tea.Cmd {
+	if i.autocomplete == nil {
+		return nil
+	}
+	before, _ := i.splitAtCursor()
+	return i.autocomplete.Debounce(before)
+}
+
+// hideAutocompleteOnType hides completions once the user keeps typing.
+func (i *Input) hideAutocompleteOnType() {
+	if i.autocomplete != nil && i.autocomplete.Active() {
+		i.autocomplete.Hide()
+	}
+}
+
+// insert inserts text at the cursor, cut to the character limit. It
+// reports whether anything was inserted.
+func (i *Input) insert(text string) bool {
+	text = i.fit(text)
+	if text == "" {
+		return false
+	}
+	i.model.InsertString(text)
+	return true
+}
+
+// fit cuts text to the room left under the character limit.
+func (i *Input) fit(text string) string {
+	if i.maxChars <= 0 {
+		return text
+	}
+	room := i.maxChars - i.Len()
+	if room <= 0 {
+		return ""
+	}
+	if runes := []rune(text); len(runes) > room {
+		return string(runes[:room])
+	}
+	return text
+}
+
+// paste inserts pasted text. A paste with more lines than the editor shows
+// (or any line break in single-line mode) is collapsed into a label such
+// as "[pasted 120 lines]" and expanded again in Value.
+func (i *Input) paste(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = i.fit(text)
+	if text == "" {
+		return
+	}
+
+	lines := strings.Count(text, "\n") + 1
+	if lines == 1 || (i.multiline && lines <= i.maxHeight) {
+		i.model.InsertString(text)
+		return
+	}
+
+	label := fmt.Sprintf("[pasted %d lines]", lines)
+	for n := 2; strings.Contains(i.model.Value(), label); n++ {
+		label = fmt.Sprintf("[pasted %d lines #%d]", lines, n)
+	}
+	i.pastes = append(i.pastes, pastedText{label: label, text: text})
+	i.model.InsertString(label)
+}
+
+// deletePasteBeforeCursor deletes a collapsed paste label that ends at the
+// cursor. It reports whether one was deleted.
+func (i *Input) deletePasteBeforeCursor() bool {
+	if len(i.pastes) == 0 {
+		return false
+	}
+	lines := strings.Split(i.model.Value(), "\n")
+	row := i.model.Line()
+	if row >= len(lines) {
+		return false
+	}
+	line := []rune(lines[row])
+	info := i.model.LineInfo()
+	col := info.StartColumn + info.ColumnOffset
+	if col > len(line) {
+		return false
+	}
+	before := string(line[:col])
+
+	for n, p := range i.pastes {
+		if !strings.HasSuffix(before, p.label) {
+			continue
+		}
+		for range utf8.RuneCountInString(p.label) {
+			i.model, _ = i.model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		}
+		i.pastes = append(i.pastes[:n], i.pastes[n+1:]...)
+		return true
+	}
+	return false
+}
+
+// onFirstRow reports whether the cursor is on the first displayed row.
+func (i *Input) onFirstRow() bool {
+	return i.model.Line() == 0 && i.model.LineInfo().RowOffset == 0
+}
+
+// onLastRow reports whether the cursor is on the last displayed row.
+func (i *Input) onLastRow() bool {
+	info := i.model.LineInfo()
+	return i.model.Line() == i.model.LineCount()-1 && info.RowOffset >= info.Height-1
+}
+
+// resize grows or shrinks the editor to fit its rows, up to maxHeight,
+// and keeps the cursor in view.
+func (i *Input) resize() {
+	rows := i.rows()
+	i.model.SetHeight(min(rows, i.maxHeight))
+	if rows <= i.maxHeight && i.model.LineCount() > 1 {
+		// Everything fits, so scroll back to the first row
+		line := i.model.Line()
+		info := i.model.LineInfo()
+		col := info.StartColumn + info.ColumnOffset
+		for !i.onFirstRow() {
+			i.model.CursorUp()
+		}
+		i.model, _ = i.model.Update(nil)
+		for i.model.Line() < line {
+			i.model.CursorDown()
+		}
+		i.model.SetCursor(col)
+	}
+	i.model, _ = i.model.Update(nil)
+}
+
+// rows returns the number of rows the value takes once wrapped.
+func (i *Input) rows() int {
+	width := i.model.Width()
+	rows := 0
+	for _, line := range strings.Split(i.model.Value(), "\n") {
+		rows++
+		// Leave a cell for the cursor at the end of the line
+		if width > 0 {
+			rows += lipgloss.Width(line) / width
+		}
+	}
+	return max(rows, 1)
 }
 
 // View renders the input.
 func (i *Input) View() string {
 	styles := i.theme.Styles()
 	inputView := styles.Input.Width(i.width - 4).Render(i.model.View())
+	if len(i.chips) > 0 {
+		inputView += "\n" + i.chipsView()
+	}
+	if i.showCount {
+		inputView += "\n" + i.countView()
+	}
 
 	// Show autocomplete dropdown if active (takes priority)
 	if i.autocomplete != nil && i.autocomplete.Active() {
@@ -201,20 +523,61 @@ func (i *Input) View() string {
 	return inputView
 }
 
-// Value returns the current input value.
+// countView renders the character counter, right-aligned under the box.
+func (i *Input) countView() string {
+	styles := i.theme.Styles()
+	count := fmt.Sprintf("%d", i.Len())
+	style := styles.Muted
+	if i.maxChars > 0 {
+		count = fmt.Sprintf("%d/%d", i.Len(), i.maxChars)
+		if i.Len() >= i.maxChars {
+			style = styles.Warning
+		}
+	}
+	width := i.width - 2
+	return lipgloss.PlaceHorizontal(max(width, lipgloss.Width(count)), lipgloss.Right, style.Render(count))
+}
+
+// Height returns the number of rows the input takes, without completion
+// dropdowns.
+func (i *Input) Height() int {
+	h := i.model.Height() + i.theme.Styles().Input.GetVerticalFrameSize()
+	if len(i.chips) > 0 {
+		h++
+	}
+	if i.showCount {
+		h++
+	}
+	return h
+}
+
+// Value returns the current input value, with collapsed pastes expanded.
 func (i *Input) Value() string {
-	return i.model.Value()
+	value := i.model.Value()
+	for _, p := range i.pastes {
+		value = strings.Replace(value, p.label, p.text, 1)
+	}
+	return value
 }
 
-// SetValue sets the input value.
+// Len returns the length of the value in characters.
+func (i *Input) Len() int {
+	return utf8.RuneCountInString(i.Value())
+}
+
+// SetValue sets the input value and moves the cursor to its end.
 func (i *Input) SetValue(value string) {
+	i.pastes = nil
 	i.model.SetValue(value)
+	i.resize()
+	i.updateChips()
 }
 
 // SetWidth sets the input width.
 func (i *Input) SetWidth(width int) {
 	i.width = width
-	i.model.Width = width - 4 - len(i.prefix)
+	i.model.SetWidth(width - 4 - i.theme.Styles().Input.GetHorizontalPadding())
+	i.resize()
 }
 
 // SetHistoryProvider sets the function that provides history items.
@@ -223,10 +586,25 @@ func (i *Input) SetHistoryProvider(provider func() []string) {
 	i.historyProvider = provider
 }
 
+// SetKeymap sets the keymap used to recognise submit keys.
+func (i *Input) SetKeymap(k *Keymap) {
+	i.keymap = k
+}
+
+// isSubmit reports whether msg submits the input.
+func (i *Input) isSubmit(msg tea.KeyMsg) bool {
+	if i.keymap == nil {
+		return msg.Type == tea.KeyEnter
+	}
+	return i.keymap.Matches(ActionSubmit, msg)
+}
+
 // SetAutocomplete sets the autocomplete component for this input.
-// When set, Tab triggers autocomplete and arrow keys navigate completions.
+// When set, Tab triggers autocomplete and arrow keys navigate completions,
+// whose matched characters are highlighted in the theme's primary colour.
 func (i *Input) SetAutocomplete(ac *Autocomplete) {
 	i.autocomplete = ac
+	i.themeAutocomplete()
 }
 
 // Autocomplete returns the autocomplete component, if set.
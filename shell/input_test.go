@@ -1,10 +1,13 @@
 package shell
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/theme"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestInputHistoryNavigation(t *testing.T) {
@@ -76,3 +79,156 @@ func TestInputNoHistoryProvider(t *testing.T) {
 		t.Errorf("expected 'test' unchanged, got %q", input.Value())
 	}
 }
+
+// multilineInput returns a multiline input as the shell configures it.
+func multilineInput(cfg config.InputConfig) *Input {
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetKeymap(DefaultKeymap())
+	cfg.Multiline = true
+	input.ApplyConfig(cfg)
+	input.SetWidth(80)
+	return input
+}
+
+// typeText sends each rune of s as a key press.
+func typeText(input *Input, s string) *Input {
+	for _, r := range s {
+		input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return input
+}
+
+func TestInputMultilineNewline(t *testing.T) {
+	input := multilineInput(config.InputConfig{MaxHeight: 3})
+	base := input.Height()
+
+	input = typeText(input, "one")
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	input = typeText(input, "two")
+
+	if input.Value() != "one\ntwo" {
+		t.Errorf("expected alt+enter to insert a newline, got %q", input.Value())
+	}
+	if input.Height() != base+1 {
+		t.Errorf("expected input to grow to %d rows, got %d", base+1, input.Height())
+	}
+
+	// Growth stops at MaxHeight
+	for range 4 {
+		input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	}
+	if input.Height() != base+2 {
+		t.Errorf("expected input capped at %d rows, got %d", base+2, input.Height())
+	}
+
+	// Enter still submits the whole text
+	_, cmd := input.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected enter to submit")
+	}
+	if msg := cmd().(InputSubmitMsg); msg.Value != "one\ntwo\n\n\n\n" {
+		t.Errorf("unexpected submitted value %q", msg.Value)
+	}
+	if input.Height() != base {
+		t.Errorf("expected input to shrink back to %d rows, got %d", base, input.Height())
+	}
+}
+
+func TestInputSingleLineIgnoresNewline(t *testing.T) {
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetKeymap(DefaultKeymap())
+	input = typeText(input, "hi")
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+
+	if input.Value() != "hi" {
+		t.Errorf("expected no newline without multiline mode, got %q", input.Value())
+	}
+}
+
+func TestInputPasteCollapses(t *testing.T) {
+	input := multilineInput(config.InputConfig{MaxHeight: 3})
+	text := strings.TrimSuffix(strings.Repeat("line\r\n", 120), "\r\n")
+
+	input = typeText(input, "see ")
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true})
+
+	if got := input.model.Value(); got != "see [pasted 120 lines]" {
+		t.Errorf("expected collapsed paste, got %q", got)
+	}
+	want := "see " + strings.ReplaceAll(text, "\r\n", "\n")
+	if input.Value() != want {
+		t.Error("expected Value to expand the paste")
+	}
+
+	// Backspace removes the whole chip
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if input.Value() != "see " {
+		t.Errorf("expected backspace to delete the chip, got %q", input.Value())
+	}
+
+	// Short pastes are inserted as typed
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a\nb"), Paste: true})
+	if input.model.Value() != "see a\nb" {
+		t.Errorf("expected short paste inline, got %q", input.model.Value())
+	}
+}
+
+func TestInputAltKeysEditWords(t *testing.T) {
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetKeymap(DefaultKeymap())
+	input = typeText(input, "foo bar")
+
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b"), Alt: true})
+	if input.Value() != "foo bar" {
+		t.Errorf("expected alt+b to move, not type, got %q", input.Value())
+	}
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true})
+	if input.Value() != "foo " {
+		t.Errorf("expected alt+d to delete the word, got %q", input.Value())
+	}
+}
+
+func TestInputMaxChars(t *testing.T) {
+	input := multilineInput(config.InputConfig{MaxHeight: 3, MaxChars: 5, ShowCharCount: true})
+
+	input = typeText(input, "abcdefg")
+	if input.Value() != "abcde" {
+		t.Errorf("expected input cut at 5 chars, got %q", input.Value())
+	}
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("xyz"), Paste: true})
+	if input.Value() != "abcde" {
+		t.Errorf("expected newline and paste to respect the limit, got %q", input.Value())
+	}
+
+	if view := ansi.Strip(input.View()); !strings.Contains(view, "5/5") {
+		t.Errorf("expected char counter in view\n%s", view)
+	}
+}
+
+func TestInputMultilineHistory(t *testing.T) {
+	input := multilineInput(config.InputConfig{MaxHeight: 5})
+	input.SetHistoryProvider(func() []string { return []string{"old"} })
+
+	input = typeText(input, "one")
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	input = typeText(input, "two")
+
+	// Up on the second line moves the cursor, not history
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if input.Value() != "one\ntwo" || input.model.Line() != 0 {
+		t.Errorf("expected cursor on the first line, got line %d value %q", input.model.Line(), input.Value())
+	}
+
+	// Up on the first line recalls history
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if input.Value() != "old" {
+		t.Errorf("expected history entry, got %q", input.Value())
+	}
+
+	// Down on the last line leaves history
+	input, _ = input.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if input.Value() != "" {
+		t.Errorf("expected history cleared, got %q", input.Value())
+	}
+}
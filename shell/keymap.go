@@ -0,0 +1,431 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/2389-research/tux/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action identifies something a key binding does.
+type Action string
+
+// Built-in actions.
+const (
+	ActionSubmit         Action = "submit"
+	ActionNewline        Action = "newline"
+	ActionInterrupt      Action = "interrupt"
+	ActionCancel         Action = "cancel"
+	ActionQuit           Action = "quit"
+	ActionHelp           Action = "help"
+	ActionQuickActions   Action = "quick_actions"
+	ActionShowErrors     Action = "show_errors"
+	ActionClearChat      Action = "clear_chat"
+	ActionSave           Action = "save"
+	ActionToggleFavorite Action = "toggle_favorite"
+	ActionNextTab        Action = "next_tab"
+	ActionPrevTab        Action = "prev_tab"
+	ActionLineUp         Action = "line_up"
+	ActionLineDown       Action = "line_down"
+	ActionScrollUp       Action = "scroll_up"
+	ActionScrollDown     Action = "scroll_down"
+	ActionScrollTop      Action = "scroll_top"
+	ActionScrollBottom   Action = "scroll_bottom"
+)
+
+// customPrefix namespaces app-defined actions so they cannot shadow
+// built-in ones.
+const customPrefix = "custom:"
+
+// CustomAction returns the action for an app-defined key action, as named
+// in the [keybindings.custom] config table.
+func CustomAction(name string) Action {
+	return Action(customPrefix + name)
+}
+
+// IsCustom reports whether a is an app-defined action.
+func (a Action) IsCustom() bool {
+	return strings.HasPrefix(string(a), customPrefix)
+}
+
+// IsView reports whether a applies to the focused tab content rather than
+// the shell (scrolling).
+func (a Action) IsView() bool {
+	switch a {
+	case ActionLineUp, ActionLineDown, ActionScrollUp, ActionScrollDown, ActionScrollTop, ActionScrollBottom:
+		return true
+	}
+	return false
+}
+
+// ActionMsg is sent to the focused tab content when a view action's keys
+// are pressed. Content should handle these instead of hardcoding keys.
+type ActionMsg struct {
+	Action Action
+}
+
+// KeyAction is an app-defined action that can be bound to keys.
+// Keys can be overridden by users in [keybindings.custom].
+type KeyAction struct {
+	Name        string   // Name used in [keybindings.custom]
+	Description string   // Shown in the help overlay
+	Keys        []string // Default keys, e.g. "ctrl+t" or "g d"
+	Handler     func()
+}
+
+// actionInfo describes a built-in action for the help overlay.
+type actionInfo struct {
+	action      Action
+	category    string
+	description string
+}
+
+// builtinActions lists built-in actions in help order.
+var builtinActions = []actionInfo{
+	{ActionSubmit, "General", "Send message"},
+	{ActionNewline, "General", "New line"},
+	{ActionInterrupt, "General", "Interrupt the running turn"},
+	{ActionCancel, "General", "Close / switch focus"},
+	{ActionHelp, "General", "Toggle help"},
+	{ActionQuickActions, "General", "Quick actions"},
+	{ActionShowErrors, "General", "Show errors"},
+	{ActionClearChat, "General", "Clear chat"},
+	{ActionSave, "General", "Save"},
+	{ActionToggleFavorite, "General", "Toggle favorite"},
+	{ActionQuit, "General", "Quit"},
+	{ActionNextTab, "Navigation", "Next tab"},
+	{ActionPrevTab, "Navigation", "Previous tab"},
+	{ActionLineUp, "Scrolling", "Scroll up one line"},
+	{ActionLineDown, "Scrolling", "Scroll down one line"},
+	{ActionScrollUp, "Scrolling", "Scroll up half a page"},
+	{ActionScrollDown, "Scrolling", "Scroll down half a page"},
+	{ActionScrollTop, "Scrolling", "Go to top"},
+	{ActionScrollBottom, "Scrolling", "Go to bottom"},
+}
+
+// KeyMatch is the result of resolving a key press.
+type KeyMatch int
+
+const (
+	// KeyUnbound means the key (sequence) is not bound.
+	KeyUnbound KeyMatch = iota
+	// KeyPending means the key starts a multi-key sequence.
+	KeyPending
+	// KeyBound means the key completed a binding.
+	KeyBound
+)
+
+// Keymap maps actions to key bindings. A binding is a single key in
+// Bubble Tea notation ("ctrl+c", "?", "pgup") or a space-separated
+// sequence ("g g").
+type Keymap struct {
+	bindings map[Action][][]string
+	custom   []KeyAction
+	pending  []string
+}
+
+// NewKeymap creates an empty keymap.
+func NewKeymap() *Keymap {
+	return &Keymap{bindings: make(map[Action][][]string)}
+}
+
+// DefaultKeymap returns the built-in bindings: those of the default config,
+// plus actions the config does not cover.
+func DefaultKeymap() *Keymap {
+	k := NewKeymap()
+	k.ApplyConfig(config.Default().Keybindings)
+	k.Bind(ActionQuit, "ctrl+c", "ctrl+q")
+	k.Bind(ActionShowErrors, "ctrl+e")
+	k.Bind(ActionClearChat, "ctrl+l")
+	k.Bind(ActionSave, "ctrl+s")
+	k.Bind(ActionToggleFavorite, "ctrl+f")
+	k.Bind(ActionLineUp, "k", "up")
+	k.Bind(ActionLineDown, "j", "down")
+	return k
+}
+
+// ApplyConfig overrides bindings with those set in cfg. Empty fields keep
+// the current bindings. Custom entries rebind app-defined actions.
+func (k *Keymap) ApplyConfig(cfg config.KeybindingsConfig) {
+	fields := []struct {
+		action Action
+		keys   []string
+	}{
+		{ActionSubmit, cfg.Submit},
+		{ActionNewline, cfg.Newline},
+		{ActionInterrupt, cfg.Interrupt},
+		{ActionCancel, cfg.Cancel},
+		{ActionHelp, cfg.Help},
+		{ActionQuickActions, cfg.QuickActions},
+		{ActionNextTab, cfg.NextTab},
+		{ActionPrevTab, cfg.PrevTab},
+		{ActionScrollUp, cfg.ScrollUp},
+		{ActionScrollDown, cfg.ScrollDown},
+		{ActionScrollTop, cfg.ScrollTop},
+		{ActionScrollBottom, cfg.ScrollBottom},
+	}
+	for _, f := range fields {
+		if len(f.keys) > 0 {
+			k.Bind(f.action, f.keys...)
+		}
+	}
+	for name, keys := range cfg.Custom {
+		if len(keys) > 0 {
+			k.Bind(CustomAction(name), keys...)
+		}
+	}
+}
+
+// Bind replaces the bindings for an action.
+func (k *Keymap) Bind(action Action, keys ...string) {
+	seqs := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		if seq := parseSequence(key); len(seq) > 0 {
+			seqs = append(seqs, seq)
+		}
+	}
+	k.bindings[action] = seqs
+}
+
+// AddCustom registers an app-defined action. Its default keys apply unless
+// bindings for it already exist (e.g. from config).
+func (k *Keymap) AddCustom(ka KeyAction) {
+	k.custom = append(k.custom, ka)
+	action := CustomAction(ka.Name)
+	if _, ok := k.bindings[action]; !ok {
+		k.Bind(action, ka.Keys...)
+	}
+}
+
+// Custom returns the registered app-defined action with the given name.
+func (k *Keymap) Custom(name string) (KeyAction, bool) {
+	for _, ka := range k.custom {
+		if ka.Name == name {
+			return ka, true
+		}
+	}
+	return KeyAction{}, false
+}
+
+// Keys returns the bindings for an action in display form.
+func (k *Keymap) Keys(action Action) []string {
+	seqs := k.bindings[action]
+	keys := make([]string, len(seqs))
+	for i, seq := range seqs {
+		keys[i] = strings.Join(seq, " ")
+	}
+	return keys
+}
+
+// Matches reports whether a single key press is bound to action. Multi-key
+// sequences never match; use Resolve for those.
+func (k *Keymap) Matches(action Action, msg tea.KeyMsg) bool {
+	key := msg.String()
+	for _, seq := range k.bindings[action] {
+		if len(seq) == 1 && seq[0] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Eligible reports whether a binding may match right now.
+type Eligible func(action Action, binding []string) bool
+
+// Resolve feeds a key press into the keymap. eligible filters which
+// bindings may match right now; nil allows all. When a pending sequence
+// cannot be completed, it is dropped and the key is resolved on its own.
+func (k *Keymap) Resolve(msg tea.KeyMsg, eligible Eligible) (Action, KeyMatch) {
+	key := msg.String()
+
+	if len(k.pending) > 0 {
+		seq := append(append([]string{}, k.pending...), key)
+		if action, match := k.lookup(seq, eligible); match != KeyUnbound {
+			k.setPending(match, seq)
+			return action, match
+		}
+		k.pending = nil
+	}
+
+	seq := []string{key}
+	action, match := k.lookup(seq, eligible)
+	k.setPending(match, seq)
+	return action, match
+}
+
+// Pending reports whether a multi-key sequence is in progress.
+func (k *Keymap) Pending() bool {
+	return len(k.pending) > 0
+}
+
+// ResetPending abandons any multi-key sequence in progress.
+func (k *Keymap) ResetPending() {
+	k.pending = nil
+}
+
+func (k *Keymap) setPending(match KeyMatch, seq []string) {
+	if match == KeyPending {
+		k.pending = seq
+	} else {
+		k.pending = nil
+	}
+}
+
+// lookup finds the action bound to seq. A sequence that prefixes a longer
+// binding is pending even if it is also bound on its own, so "g" waits for
+// a possible "g g".
+func (k *Keymap) lookup(seq []string, eligible Eligible) (Action, KeyMatch) {
+	var exact Action
+	found := false
+	for _, action := range k.actions() {
+		for _, bound := range k.bindings[action] {
+			if len(bound) < len(seq) || !equalPrefix(bound, seq) {
+				continue
+			}
+			if eligible != nil && !eligible(action, bound) {
+				continue
+			}
+			if len(bound) > len(seq) {
+				return "", KeyPending
+			}
+			if !found {
+				exact, found = action, true
+			}
+		}
+	}
+	if found {
+		return exact, KeyBound
+	}
+	return "", KeyUnbound
+}
+
+// actions returns bound actions in a stable order: built-ins first, then
+// custom actions by name.
+func (k *Keymap) actions() []Action {
+	result := make([]Action, 0, len(k.bindings))
+	for _, info := range builtinActions {
+		if _, ok := k.bindings[info.action]; ok {
+			result = append(result, info.action)
+		}
+	}
+	var custom []Action
+	for action := range k.bindings {
+		if action.IsCustom() {
+			custom = append(custom, action)
+		}
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i] < custom[j] })
+	return append(result, custom...)
+}
+
+// HelpCategories builds help overlay categories from the live bindings.
+// available filters out actions that do nothing in this app; nil shows all.
+// Custom actions are listed only if registered with AddCustom.
+func (k *Keymap) HelpCategories(available func(Action) bool) []Category {
+	var categories []Category
+	index := make(map[string]int)
+
+	add := func(title string, b Binding) {
+		i, ok := index[title]
+		if !ok {
+			i = len(categories)
+			index[title] = i
+			categories = append(categories, Category{Title: title})
+		}
+		categories[i].Bindings = append(categories[i].Bindings, b)
+	}
+
+	for _, info := range builtinActions {
+		keys := k.Keys(info.action)
+		if len(keys) == 0 || (available != nil && !available(info.action)) {
+			continue
+		}
+		add(info.category, Binding{Key: displayKeys(keys), Description: info.description})
+	}
+	for _, ka := range k.custom {
+		keys := k.Keys(CustomAction(ka.Name))
+		if len(keys) == 0 {
+			continue
+		}
+		desc := ka.Description
+		if desc == "" {
+			desc = ka.Name
+		}
+		add("Actions", Binding{Key: displayKeys(keys), Description: desc})
+	}
+	return categories
+}
+
+// displayKeys formats keys for the help overlay.
+func displayKeys(keys []string) string {
+	shown := make([]string, len(keys))
+	for i, key := range keys {
+		if key == " " {
+			key = "space"
+		}
+		shown[i] = key
+	}
+	return strings.Join(shown, "/")
+}
+
+// parseSequence normalizes a binding to Bubble Tea key strings.
+// "g g" and "gg" both become ["g", "g"].
+func parseSequence(binding string) []string {
+	if binding == " " {
+		return []string{" "}
+	}
+	fields := strings.Fields(binding)
+	if len(fields) == 1 && len(fields[0]) == 2 && fields[0][0] == fields[0][1] {
+		// Double-character shorthand, e.g. "gg"
+		fields = []string{fields[0][:1], fields[0][1:]}
+	}
+	for i, f := range fields {
+		fields[i] = normalizeKey(f)
+	}
+	return fields
+}
+
+// keyAliases maps config spellings to Bubble Tea key names.
+var keyAliases = map[string]string{
+	"return":   "enter",
+	"escape":   "esc",
+	"space":    " ",
+	"pgdn":     "pgdown",
+	"pagedown": "pgdown",
+	"pageup":   "pgup",
+	"del":      "delete",
+}
+
+// normalizeKey converts a single key to Bubble Tea notation. Modifiers and
+// named keys are case-insensitive; single characters keep their case.
+func normalizeKey(key string) string {
+	if len(key) == 1 {
+		return key
+	}
+	parts := strings.Split(key, "+")
+	for i, p := range parts {
+		if i < len(parts)-1 || len(p) > 1 {
+			p = strings.ToLower(p)
+		}
+		if alias, ok := keyAliases[p]; ok && i == len(parts)-1 {
+			p = alias
+		}
+		parts[i] = p
+	}
+	// Bubble Tea reports ctrl+letter in lower case
+	if len(parts) > 1 && parts[0] == "ctrl" {
+		parts[len(parts)-1] = strings.ToLower(parts[len(parts)-1])
+	}
+	return strings.Join(parts, "+")
+}
+
+// equalPrefix reports whether seq is a prefix of bound.
+func equalPrefix(bound, seq []string) bool {
+	for i := range seq {
+		if bound[i] != seq[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,309 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/content"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestKeymapResolveSingleKey(t *testing.T) {
+	k := DefaultKeymap()
+
+	if action, match := k.Resolve(tea.KeyMsg{Type: tea.KeyCtrlC}, nil); match != KeyBound || action != ActionQuit {
+		t.Errorf("expected ctrl+c to quit, got %q %v", action, match)
+	}
+	if _, match := k.Resolve(runeKey('z'), nil); match != KeyUnbound {
+		t.Errorf("expected z to be unbound, got %v", match)
+	}
+}
+
+func TestKeymapResolveSequence(t *testing.T) {
+	k := DefaultKeymap()
+
+	if _, match := k.Resolve(runeKey('g'), nil); match != KeyPending {
+		t.Fatalf("expected g to be pending, got %v", match)
+	}
+	if action, match := k.Resolve(runeKey('g'), nil); match != KeyBound || action != ActionScrollTop {
+		t.Errorf("expected g g to scroll to top, got %q %v", action, match)
+	}
+	if k.Pending() {
+		t.Error("expected sequence to be complete")
+	}
+
+	// An unfinished sequence is dropped and the key resolved on its own
+	k.Resolve(runeKey('g'), nil)
+	if action, match := k.Resolve(runeKey('G'), nil); match != KeyBound || action != ActionScrollBottom {
+		t.Errorf("expected G after g to scroll to bottom, got %q %v", action, match)
+	}
+}
+
+func TestKeymapEligibleFilter(t *testing.T) {
+	k := DefaultKeymap()
+	noView := func(a Action, _ []string) bool { return !a.IsView() }
+
+	if _, match := k.Resolve(runeKey('g'), noView); match != KeyUnbound {
+		t.Errorf("expected g to be unbound when view actions are filtered, got %v", match)
+	}
+}
+
+func TestKeymapApplyConfig(t *testing.T) {
+	k := DefaultKeymap()
+	k.ApplyConfig(config.KeybindingsConfig{
+		Help:       []string{"F1"},
+		ScrollDown: []string{"pgdn"},
+		ScrollTop:  []string{"gg"},
+		Custom:     map[string][]string{"deploy": {"ctrl+D"}},
+	})
+
+	if got := k.Keys(ActionHelp); len(got) != 1 || got[0] != "f1" {
+		t.Errorf("expected help [f1], got %v", got)
+	}
+	if !k.Matches(ActionScrollDown, tea.KeyMsg{Type: tea.KeyPgDown}) {
+		t.Error("expected pgdn alias to match the PgDown key")
+	}
+	if got := k.Keys(ActionScrollTop); len(got) != 1 || got[0] != "g g" {
+		t.Errorf("expected gg to be parsed as a sequence, got %v", got)
+	}
+	if got := k.Keys(CustomAction("deploy")); len(got) != 1 || got[0] != "ctrl+d" {
+		t.Errorf("expected custom binding ctrl+d, got %v", got)
+	}
+	// Unset fields keep their defaults
+	if got := k.Keys(ActionQuit); len(got) != 2 {
+		t.Errorf("expected quit defaults to remain, got %v", got)
+	}
+}
+
+func TestKeymapAddCustomKeepsConfigKeys(t *testing.T) {
+	k := DefaultKeymap()
+	k.ApplyConfig(config.KeybindingsConfig{Custom: map[string][]string{"deploy": {"ctrl+y"}}})
+	k.AddCustom(KeyAction{Name: "deploy", Keys: []string{"ctrl+t"}})
+
+	if got := k.Keys(CustomAction("deploy")); len(got) != 1 || got[0] != "ctrl+y" {
+		t.Errorf("expected user keys to win over defaults, got %v", got)
+	}
+}
+
+func TestKeymapHelpCategories(t *testing.T) {
+	k := DefaultKeymap()
+	k.Bind(ActionHelp, "f1", "?")
+	k.AddCustom(KeyAction{Name: "deploy", Description: "Deploy now", Keys: []string{"ctrl+t"}})
+
+	cats := k.HelpCategories(func(a Action) bool { return a != ActionSave })
+
+	var all []Binding
+	titles := map[string]bool{}
+	for _, c := range cats {
+		titles[c.Title] = true
+		all = append(all, c.Bindings...)
+	}
+	find := func(desc string) (Binding, bool) {
+		for _, b := range all {
+			if b.Description == desc {
+				return b, true
+			}
+		}
+		return Binding{}, false
+	}
+
+	if b, ok := find("Toggle help"); !ok || b.Key != "f1/?" {
+		t.Errorf("expected help to show live keys, got %+v", b)
+	}
+	if b, ok := find("Go to top"); !ok || b.Key != "g g/home" {
+		t.Errorf("expected sequence in help, got %+v", b)
+	}
+	if b, ok := find("Deploy now"); !ok || b.Key != "ctrl+t" {
+		t.Errorf("expected custom action in help, got %+v", b)
+	}
+	if _, ok := find("Save"); ok {
+		t.Error("expected unavailable action to be hidden")
+	}
+	for _, title := range []string{"General", "Navigation", "Scrolling", "Actions"} {
+		if !titles[title] {
+			t.Errorf("expected category %q", title)
+		}
+	}
+}
+
+func TestShellHelpKeyOnlyOnEmptyInput(t *testing.T) {
+	s := New(nil, DefaultConfig())
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.SetInputValue("why")
+	s.Update(runeKey('?'))
+	if s.HasModal() {
+		t.Fatal("typing ? in a prompt should not open help")
+	}
+	if s.InputValue() != "why?" {
+		t.Errorf("expected ? to be typed, got %q", s.InputValue())
+	}
+
+	s.ClearInput()
+	s.Update(runeKey('?'))
+	if !s.HasModal() {
+		t.Fatal("? on empty input should open help")
+	}
+	if !strings.Contains(s.View(), "Toggle help") {
+		t.Error("expected generated help bindings")
+	}
+}
+
+func TestShellConfiguredBindings(t *testing.T) {
+	km := DefaultKeymap()
+	km.ApplyConfig(config.KeybindingsConfig{QuickActions: []string{"ctrl+k"}})
+
+	opened := 0
+	cfg := DefaultConfig()
+	cfg.Keymap = km
+	cfg.OnQuickActions = func() { opened++ }
+	s := New(nil, cfg)
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.Update(runeKey(':'))
+	if opened != 0 || s.InputValue() != ":" {
+		t.Errorf("expected ':' to be typed once rebound, opened=%d input=%q", opened, s.InputValue())
+	}
+	// ctrl+k deletes to the end of the line while there is text to edit
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if opened != 0 {
+		t.Error("expected ctrl+k left to the input while it has text")
+	}
+	s.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if opened != 1 {
+		t.Error("expected ctrl+k to open quick actions")
+	}
+}
+
+func TestDefaultKeymapMatchesDefaultConfig(t *testing.T) {
+	km := DefaultKeymap()
+	configured := DefaultKeymap()
+	configured.ApplyConfig(config.Default().Keybindings)
+	for _, info := range builtinActions {
+		if got, want := km.Keys(info.action), configured.Keys(info.action); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: default keymap has %q, default config %q", info.action, got, want)
+		}
+	}
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyCtrlH}, {Type: tea.KeyCtrlK}} {
+		if action, match := km.Resolve(key, nil); match != KeyUnbound {
+			t.Errorf("expected %s unbound by default, got %s", key, action)
+		}
+	}
+}
+
+func TestShellEditingKeysStayInInput(t *testing.T) {
+	km := DefaultKeymap()
+	km.Bind(ActionHelp, "ctrl+h", "?")
+	cfg := DefaultConfig()
+	cfg.Keymap = km
+	s := New(nil, cfg)
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	for _, r := range "abc" {
+		s.Update(runeKey(r))
+	}
+	// Backspace arrives as ctrl+h on many terminals
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlH})
+	if s.modalManager.HasActive() || s.InputValue() != "ab" {
+		t.Errorf("expected ctrl+h to delete a character, got %q (modal %v)", s.InputValue(), s.modalManager.HasActive())
+	}
+}
+
+func TestShellCustomKeyAction(t *testing.T) {
+	ran := 0
+	cfg := DefaultConfig()
+	cfg.KeyActions = []KeyAction{{Name: "deploy", Keys: []string{"ctrl+t"}, Handler: func() { ran++ }}}
+	s := New(nil, cfg)
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if ran != 1 {
+		t.Errorf("expected custom action to run once, got %d", ran)
+	}
+}
+
+// actionRecorder records ActionMsgs it receives.
+type actionRecorder struct {
+	content.Content
+	actions []Action
+}
+
+func (r *actionRecorder) Update(msg tea.Msg) (content.Content, tea.Cmd) {
+	if a, ok := msg.(ActionMsg); ok {
+		r.actions = append(r.actions, a.Action)
+	}
+	return r, nil
+}
+
+func (r *actionRecorder) SetSize(width, height int) {}
+
+func TestShellSendsViewActionsToFocusedTab(t *testing.T) {
+	rec := &actionRecorder{}
+	s := New(nil, DefaultConfig())
+	s.AddTab(Tab{ID: "chat", Label: "Chat", Content: rec})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// Scroll keys are typed into the input while it has focus
+	s.Update(runeKey('j'))
+	if len(rec.actions) != 0 {
+		t.Fatalf("expected no actions while input focused, got %v", rec.actions)
+	}
+
+	s.Focus(FocusTab)
+	s.Update(runeKey('j'))
+	s.Update(runeKey('g'))
+	s.Update(runeKey('g'))
+
+	want := []Action{ActionLineDown, ActionScrollTop}
+	if len(rec.actions) != len(want) {
+		t.Fatalf("expected %v, got %v", want, rec.actions)
+	}
+	for i := range want {
+		if rec.actions[i] != want[i] {
+			t.Errorf("action %d: expected %s, got %s", i, want[i], rec.actions[i])
+		}
+	}
+}
+
+// typingRecorder is tab content collecting text, recording the keys it gets.
+type typingRecorder struct {
+	actionRecorder
+	keys []string
+}
+
+func (r *typingRecorder) Update(msg tea.Msg) (content.Content, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		r.keys = append(r.keys, k.String())
+	}
+	return r.actionRecorder.Update(msg)
+}
+
+func (r *typingRecorder) Typing() bool { return true }
+
+func TestShellLeavesTypingKeysToTextEntry(t *testing.T) {
+	rec := &typingRecorder{}
+	s := New(nil, DefaultConfig())
+	s.AddTab(Tab{ID: "tools", Label: "Tools", Content: rec})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	s.Focus(FocusTab)
+
+	s.Update(runeKey('j'))
+	s.Update(runeKey('?'))
+	s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	if len(rec.actions) != 0 || s.modalManager.HasActive() {
+		t.Errorf("expected no bindings to run while typing, got %v", rec.actions)
+	}
+	if strings.Join(rec.keys, " ") != "j ? esc" {
+		t.Errorf("expected keys to reach the content, got %v", rec.keys)
+	}
+	if s.focused != FocusTab {
+		t.Error("expected esc to stay with the content")
+	}
+}
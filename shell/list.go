@@ -1,8 +1,9 @@
 package shell
 
 import (
-	"strings"
+	"sort"
 
+	"github.com/2389-research/tux/fuzzy"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -21,6 +22,7 @@ type ListModal struct {
 	title      string
 	items      []ListItem
 	filtered   []ListItem
+	positions  [][]int // Runes of each filtered item's title matching the filter
 	selected   int
 	filter     string
 	filterable bool
@@ -29,14 +31,16 @@ type ListModal struct {
 	width      int
 	height     int
 	maxVisible int
+	rows       rowMap // Item index of each rendered row
 
 	// Styles
-	boxStyle      lipgloss.Style
-	titleStyle    lipgloss.Style
-	filterStyle   lipgloss.Style
-	itemStyle     lipgloss.Style
-	selectedStyle lipgloss.Style
-	descStyle     lipgloss.Style
+	boxStyle       lipgloss.Style
+	titleStyle     lipgloss.Style
+	filterStyle    lipgloss.Style
+	itemStyle      lipgloss.Style
+	selectedStyle  lipgloss.Style
+	descStyle      lipgloss.Style
+	highlightStyle lipgloss.Style
 }
 
 // ListModalConfig configures a ListModal.
@@ -76,6 +80,9 @@ func NewListModal(cfg ListModalConfig) *ListModal {
 			Bold(true),
 		descStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#6272a4")),
+		highlightStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#bd93f9")).
+			Bold(true),
 	}
 	return m
 }
@@ -116,13 +123,7 @@ func (m *ListModal) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
 		}
 		return true, nil
 	case tea.KeyEnter:
-		if m.selected >= 0 && m.selected < len(m.filtered) {
-			if m.onSelect != nil {
-				m.onSelect(m.filtered[m.selected])
-			}
-			return true, func() tea.Msg { return PopMsg{} }
-		}
-		return true, nil
+		return true, m.choose()
 	case tea.KeyBackspace:
 		if m.filterable && len(m.filter) > 0 {
 			m.filter = m.filter[:len(m.filter)-1]
@@ -153,32 +154,99 @@ func (m *ListModal) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
 	return false, nil
 }
 
-// applyFilter filters items based on the current filter string.
+// HandleMouse implements MouseHandler. Hovering highlights an item,
+// clicking chooses it and the wheel moves the selection.
+func (m *ListModal) HandleMouse(msg tea.MouseMsg) (bool, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if m.selected > 0 {
+			m.selected--
+		}
+		return true, nil
+	case tea.MouseButtonWheelDown:
+		if m.selected < len(m.filtered)-1 {
+			m.selected++
+		}
+		return true, nil
+	}
+
+	item := m.rows.at(msg.Y - m.boxStyle.GetBorderTopSize() - m.boxStyle.GetPaddingTop())
+	if item < 0 {
+		return false, nil
+	}
+	switch {
+	case msg.Action == tea.MouseActionMotion:
+		m.selected = item
+		return true, nil
+	case isLeftClick(msg):
+		m.selected = item
+		return true, m.choose()
+	}
+	return false, nil
+}
+
+// choose selects the highlighted item and closes the modal.
+func (m *ListModal) choose() tea.Cmd {
+	if m.selected < 0 || m.selected >= len(m.filtered) {
+		return nil
+	}
+	if m.onSelect != nil {
+		m.onSelect(m.filtered[m.selected])
+	}
+	return func() tea.Msg { return PopMsg{} }
+}
+
+// applyFilter filters items fuzzily by the current filter string, best
+// match first. Items whose titles match rank above those matching only by
+// description.
 func (m *ListModal) applyFilter() {
+	m.selected = 0
 	if m.filter == "" {
-		m.filtered = m.items
-		m.selected = 0
+		m.filtered, m.positions = m.items, nil
 		return
 	}
 
-	filter := strings.ToLower(m.filter)
-	m.filtered = make([]ListItem, 0)
+	type match struct {
+		item      ListItem
+		byTitle   bool
+		score     int
+		positions []int
+	}
+	var matches []match
 	for _, item := range m.items {
-		if strings.Contains(strings.ToLower(item.Title), filter) ||
-			strings.Contains(strings.ToLower(item.Description), filter) {
-			m.filtered = append(m.filtered, item)
+		if r, ok := fuzzy.Match(m.filter, item.Title); ok {
+			matches = append(matches, match{item, true, r.Score, r.Positions})
+		} else if r, ok := fuzzy.Match(m.filter, item.Description); ok {
+			matches = append(matches, match{item, false, r.Score, nil})
 		}
 	}
-	m.selected = 0
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].byTitle != matches[j].byTitle {
+			return matches[i].byTitle
+		}
+		return matches[i].score > matches[j].score
+	})
+
+	m.filtered = make([]ListItem, len(matches))
+	m.positions = make([][]int, len(matches))
+	for i, match := range matches {
+		m.filtered[i], m.positions[i] = match.item, match.positions
+	}
 }
 
 // Render implements Modal.
 func (m *ListModal) Render(width, height int) string {
 	var parts []string
+	textWidth := width - 4 - m.boxStyle.GetHorizontalPadding()
+	m.rows = m.rows[:0]
+	add := func(part string, item int) {
+		parts = append(parts, part)
+		m.rows.add(part, textWidth, item)
+	}
 
 	// Title
 	if m.title != "" {
-		parts = append(parts, m.titleStyle.Render(m.title))
+		add(m.titleStyle.Render(m.title), -1)
 	}
 
 	// Filter
@@ -187,14 +255,14 @@ func (m *ListModal) Render(width, height int) string {
 		if filterDisplay == "" {
 			filterDisplay = "Type to filter..."
 		}
-		parts = append(parts, m.filterStyle.Render("> "+filterDisplay))
+		add(m.filterStyle.Render("> "+filterDisplay), -1)
 	}
 
-	parts = append(parts, "")
+	add("", -1)
 
 	// Items
 	if len(m.filtered) == 0 {
-		parts = append(parts, m.descStyle.Render("No items match"))
+		add(m.descStyle.Render("No items match"), -1)
 	} else {
 		start := 0
 		if m.selected >= m.maxVisible {
@@ -214,11 +282,15 @@ func (m *ListModal) Render(width, height int) string {
 				style = m.selectedStyle
 			}
 
-			line := prefix + style.Render(item.Title)
+			var positions []int
+			if i < len(m.positions) {
+				positions = m.positions[i]
+			}
+			line := prefix + fuzzy.Highlight(item.Title, positions, style, m.highlightStyle)
 			if item.Description != "" {
 				line += "\n    " + m.descStyle.Render(item.Description)
 			}
-			parts = append(parts, line)
+			add(line, i)
 		}
 	}
 
@@ -3,8 +3,10 @@ package shell
 import (
 	"strings"
 
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Manager manages a stack of modals with version tracking.
@@ -14,6 +16,14 @@ type Manager struct {
 	width   int
 	height  int
 
+	// Screen region of the top modal when last drawn
+	region Region
+
+	// Backdrop drawn behind modals
+	theme           theme.Theme
+	backdrop        bool
+	backdropOpacity float64
+
 	// Styles
 	backdropStyle lipgloss.Style
 }
@@ -21,18 +31,49 @@ type Manager struct {
 // NewManager creates a new modal manager.
 func NewManager() *Manager {
 	return &Manager{
-		stack:   make([]Modal, 0),
-		version: 0,
+		stack:           make([]Modal, 0),
+		version:         0,
+		backdrop:        true,
+		backdropOpacity: 0.5,
 		backdropStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#44475a")),
 	}
 }
 
+// SetTheme sets the theme whose colours are used for the backdrop.
+func (m *Manager) SetTheme(th theme.Theme) {
+	m.theme = th
+	m.updateBackdropStyle()
+}
+
+// SetBackdrop controls dimming of the screen behind modals. Opacity runs
+// from 0 (screen unchanged) to 1 (screen hidden).
+func (m *Manager) SetBackdrop(enabled bool, opacity float64) {
+	m.backdrop = enabled
+	m.backdropOpacity = min(max(opacity, 0), 1)
+	m.updateBackdropStyle()
+}
+
+// updateBackdropStyle derives the backdrop style from the theme: text is
+// faded towards the background colour by the backdrop opacity.
+func (m *Manager) updateBackdropStyle() {
+	if m.theme == nil {
+		return
+	}
+	bg := m.theme.Background()
+	fg, ok := blendColors(m.theme.Foreground(), bg, m.backdropOpacity)
+	if !ok {
+		fg = m.theme.Muted()
+	}
+	m.backdropStyle = lipgloss.NewStyle().Foreground(fg).Background(bg)
+}
+
 // Push adds a modal to the top of the stack.
 func (m *Manager) Push(modal Modal) {
 	modal.OnPush(m.width, m.height)
 	m.stack = append(m.stack, modal)
 	m.version++
+	m.region = Region{}
 }
 
 // Pop removes and returns the top modal from the stack.
@@ -45,6 +86,7 @@ func (m *Manager) Pop() Modal {
 	modal.OnPop()
 	m.stack = m.stack[:len(m.stack)-1]
 	m.version++
+	m.region = Region{}
 	return modal
 }
 
@@ -95,48 +137,81 @@ func (m *Manager) HandleKey(key tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 	return m.Peek().HandleKey(key)
 }
 
-// Render renders the active modal (if any) with backdrop.
+// HandleMouse routes a mouse event to the active modal if it is inside
+// the modal and the modal implements MouseHandler.
+// Returns true if the event was handled.
+func (m *Manager) HandleMouse(msg tea.MouseMsg) (handled bool, cmd tea.Cmd) {
+	if !m.HasActive() || !m.region.Contains(msg.X, msg.Y) {
+		return false, nil
+	}
+	mh, ok := m.Peek().(MouseHandler)
+	if !ok {
+		return false, nil
+	}
+	return mh.HandleMouse(m.region.Local(msg))
+}
+
+// Render renders the active modal (if any) centered on an empty screen.
 func (m *Manager) Render(width, height int) string {
 	if !m.HasActive() {
 		return ""
 	}
+	return m.centerContent(m.renderModal(m.Peek(), width, height), width, height)
+}
 
-	modal := m.Peek()
-	size := modal.Size()
+// Composite draws the modal stack over base, the rendered screen behind
+// it. Each modal is centered and drawn over the layers below it; with the
+// backdrop enabled, everything below the top modal is dimmed.
+func (m *Manager) Composite(base string, width, height int) string {
+	if !m.HasActive() || width <= 0 || height <= 0 {
+		return base
+	}
+
+	lines := fitLines(base, width, height)
+	for _, modal := range m.stack {
+		if m.backdrop && m.backdropOpacity > 0 {
+			lines = m.dim(lines)
+		}
+		content := m.renderModal(modal, width, height)
+		x, y := m.position(content, width, height)
+		lines = overlay(lines, content, x, y)
+		m.region = Region{X: x, Y: y, Width: lipgloss.Width(content), Height: lipgloss.Height(content)}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dim draws lines as backdrop.
+func (m *Manager) dim(lines []string) []string {
+	if m.backdropOpacity >= 1 {
+		blank := m.backdropStyle.Render(strings.Repeat(" ", ansi.StringWidth(lines[0])))
+		out := make([]string, len(lines))
+		for i := range out {
+			out[i] = blank
+		}
+		return out
+	}
+	return dimLines(lines, m.backdropStyle)
+}
 
-	// Calculate modal dimensions
+// renderModal renders a modal at its size relative to the screen.
+func (m *Manager) renderModal(modal Modal, width, height int) string {
+	size := modal.Size()
 	modalWidth := int(float64(width) * size.WidthPercent())
 	modalHeight := int(float64(height) * size.HeightPercent())
+	return modal.Render(modalWidth, modalHeight)
+}
 
-	// Render modal content
-	content := modal.Render(modalWidth, modalHeight)
-
-	// Center the modal
-	return m.centerContent(content, width, height)
+// position returns the top-left cell that centers content on the screen.
+func (m *Manager) position(content string, width, height int) (x, y int) {
+	contentHeight := lipgloss.Height(content)
+	contentWidth := lipgloss.Width(content)
+	return max((width-contentWidth)/2, 0), max((height-contentHeight)/2, 0)
 }
 
 // centerContent centers content within the given dimensions.
 func (m *Manager) centerContent(content string, width, height int) string {
+	leftPad, topPad := m.position(content, width, height)
 	lines := strings.Split(content, "\n")
-	contentHeight := len(lines)
-	contentWidth := 0
-	for _, line := range lines {
-		w := lipgloss.Width(line)
-		if w > contentWidth {
-			contentWidth = w
-		}
-	}
-
-	// Calculate padding
-	topPad := (height - contentHeight) / 2
-	leftPad := (width - contentWidth) / 2
-
-	if topPad < 0 {
-		topPad = 0
-	}
-	if leftPad < 0 {
-		leftPad = 0
-	}
 
 	// Build centered output
 	var b strings.Builder
@@ -1,9 +1,12 @@
 package shell
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // testModal is a simple modal for testing.
@@ -213,3 +216,78 @@ func TestSizePercent(t *testing.T) {
 		}
 	}
 }
+
+func TestManagerCompositeKeepsBase(t *testing.T) {
+	mgr := NewManager()
+	mgr.SetTheme(theme.NewDraculaTheme())
+
+	base := strings.Repeat("background text\n", 9) + "background text"
+	mgr.Push(newTestModal("top"))
+
+	out := mgr.Composite(base, 40, 10)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 lines, got %d", len(lines))
+	}
+	plain := ansi.Strip(out)
+	if !strings.Contains(plain, "Modal: top") {
+		t.Error("expected modal to be drawn")
+	}
+	if !strings.Contains(ansi.Strip(lines[0]), "background text") {
+		t.Error("expected base to remain visible around the modal")
+	}
+	for i, line := range lines {
+		if w := ansi.StringWidth(line); w != 40 {
+			t.Errorf("line %d: expected width 40, got %d", i, w)
+		}
+	}
+}
+
+func TestManagerCompositeBackdrop(t *testing.T) {
+	th := theme.NewDraculaTheme()
+	mgr := NewManager()
+	mgr.SetTheme(th)
+	mgr.Push(newTestModal("top"))
+
+	base := "base"
+	dimmed := mgr.Composite(base, 20, 3)
+	if !strings.Contains(dimmed, "base") {
+		t.Fatal("expected dimmed base to keep its text")
+	}
+
+	mgr.SetBackdrop(false, 0)
+	plain := mgr.Composite(base, 20, 3)
+	if strings.Split(plain, "\n")[0] != "base"+strings.Repeat(" ", 16) {
+		t.Errorf("expected undimmed base without backdrop, got %q", strings.Split(plain, "\n")[0])
+	}
+
+	mgr.SetBackdrop(true, 1)
+	hidden := ansi.Strip(mgr.Composite(base, 20, 3))
+	if strings.Contains(hidden, "base") {
+		t.Error("expected full opacity to hide the base")
+	}
+}
+
+func TestManagerCompositeLayersStack(t *testing.T) {
+	mgr := NewManager()
+	mgr.SetBackdrop(false, 0)
+
+	bottom := newTestModal("bottom")
+	bottom.size = SizeLarge
+	mgr.Push(bottom)
+	mgr.Push(newTestModal("top"))
+
+	out := ansi.Strip(mgr.Composite("", 40, 5))
+	if !strings.Contains(out, "Modal: top") {
+		t.Error("expected top modal to be drawn")
+	}
+	if !bottom.rendered {
+		t.Error("expected lower modal to be rendered beneath")
+	}
+
+	// Without a modal the base is returned unchanged
+	mgr.Clear()
+	if got := mgr.Composite("base", 40, 5); got != "base" {
+		t.Errorf("expected base unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Mention is an @path token in input text.
+type Mention struct {
+	Path       string // The path, without the @
+	Start, End int    // Byte offsets of the token in the text, @ included
+}
+
+// FindMentions returns the @path mentions in text: words starting with @,
+// or with "(@". Trailing punctuation is not part of the path, so "see
+// @main.go." mentions main.go.
+func FindMentions(text string) []Mention {
+	var mentions []Mention
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' || (i > 0 && !isSpace(text[i-1]) && text[i-1] != '(') {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && !isSpace(text[end]) {
+			end++
+		}
+		path := strings.TrimRight(text[i+1:end], `,;:!?)"'`)
+		if len(path) > 1 && strings.HasSuffix(path, ".") && !strings.HasSuffix(path, "..") && !strings.HasSuffix(path, "/.") {
+			path = path[:len(path)-1]
+		}
+		if path != "" {
+			mentions = append(mentions, Mention{Path: path, Start: i, End: i + 1 + len(path)})
+		}
+		i = end
+	}
+	return mentions
+}
+
+// isSpace reports whether c separates words.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// SetMentions sets the provider resolving @path mentions, whose files are
+// shown as chips under the editor. Pass nil to stop showing them.
+func (i *Input) SetMentions(p *FileProvider) {
+	i.mentions = p
+	i.chipsFor = ""
+	i.updateChips()
+}
+
+// updateChips finds the files mentioned in the value, if it changed.
+func (i *Input) updateChips() {
+	value := i.model.Value()
+	if i.mentions == nil || value == "" {
+		i.chips, i.chipsFor = nil, value
+		return
+	}
+	if value == i.chipsFor {
+		return
+	}
+	i.chips, i.chipsFor = nil, value
+
+	seen := make(map[string]bool)
+	for _, m := range FindMentions(value) {
+		if seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+		info, err := os.Stat(i.mentions.Resolve(m.Path))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		i.chips = append(i.chips, "@"+m.Path+" "+FormatSize(info.Size()))
+	}
+}
+
+// chipsView renders the mentioned files as one line of chips.
+func (i *Input) chipsView() string {
+	styles := i.theme.Styles()
+	parts := []string{styles.Muted.Render("attach")}
+	for _, chip := range i.chips {
+		parts = append(parts, styles.Info.Render("["+chip+"]"))
+	}
+	return ansi.Truncate(strings.Join(parts, " "), max(i.width-2, 1), "…")
+}
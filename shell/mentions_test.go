@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFindMentions(t *testing.T) {
+	tests := map[string][]string{
+		"read @main.go":                 {"main.go"},
+		"see @src/a.go, then @b.txt.":   {"src/a.go", "b.txt"},
+		"(@notes.md) and @../up/x.go!":  {"notes.md", "../up/x.go"},
+		"mail me@example.com or @":      nil,
+		"@first\n@second\tand @./dir/.": {"first", "second", "./dir/."},
+	}
+	for text, want := range tests {
+		var got []string
+		for _, m := range FindMentions(text) {
+			got = append(got, m.Path)
+			if text[m.Start] != '@' || text[m.Start+1:m.End] != m.Path {
+				t.Errorf("%q: offsets %d-%d do not cover @%s", text, m.Start, m.End, m.Path)
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("FindMentions(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestInputMentionChips(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"main.go": "package main\n", "src/": ""})
+
+	input := NewInput(theme.NewDraculaTheme(), "> ", "")
+	input.SetWidth(80)
+	input.SetMentions(NewFileProvider(dir))
+	height := input.Height()
+
+	input.SetValue("fix @main.go and @src/ and @nope.go, @main.go")
+	if len(input.chips) != 1 || input.chips[0] != "@main.go 13 B" {
+		t.Fatalf("expected a chip for the mentioned file only, got %q", input.chips)
+	}
+	if input.Height() != height+1 || !strings.Contains(input.View(), "[@main.go 13 B]") {
+		t.Errorf("expected the chips drawn under the editor, got:\n%s", input.View())
+	}
+
+	input.SetValue("")
+	input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("@main.go")})
+	if len(input.chips) != 1 {
+		t.Error("expected chips found while typing")
+	}
+	input.SetValue("no mentions")
+	if len(input.chips) != 0 || input.Height() != height {
+		t.Errorf("expected the chips gone, got %q", input.chips)
+	}
+}
+
+func TestShellCompletesMentions(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"main.go": "", "src/app.go": ""})
+
+	cfg := DefaultConfig()
+	cfg.Mentions = NewFileProvider(dir)
+	s := New(nil, cfg)
+
+	s.input.SetValue("explain @ma")
+	s.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if sel := s.input.autocomplete.GetSelected(); sel == nil || sel.Value != "explain @main.go" {
+		t.Fatalf("expected @main.go completed, got %+v", sel)
+	}
+	if got := completionValues(cfg.Mentions.GetCompletions("@src/")); len(got) != 1 || got[0] != "@src/app.go" {
+		t.Errorf("expected completions inside a mentioned directory, got %q", got)
+	}
+}
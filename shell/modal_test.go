@@ -2,10 +2,15 @@ package shell
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/2389-research/tux/content"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestSimpleModal(t *testing.T) {
@@ -312,6 +317,37 @@ func TestListModalFiltering(t *testing.T) {
 	}
 }
 
+func TestListModalFuzzyFiltering(t *testing.T) {
+	m := NewListModal(ListModalConfig{
+		ID: "list",
+		Items: []ListItem{
+			{ID: "notes", Title: "Open notes", Description: "Recent session"},
+			{ID: "session", Title: "Switch session"},
+			{ID: "settings", Title: "Settings"},
+		},
+		Filterable: true,
+	})
+
+	m.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ss")})
+	var got []string
+	for _, item := range m.filtered {
+		got = append(got, item.ID)
+	}
+	// "Switch session" matches at word starts; "Open notes" only by description
+	if strings.Join(got, "|") != "session|settings|notes" {
+		t.Errorf("unexpected ranking %q", got)
+	}
+	if m.SelectedItem().ID != "session" {
+		t.Errorf("expected the best match selected, got %q", m.SelectedItem().ID)
+	}
+	if !slices.Equal(m.positions[0], []int{0, 7}) || m.positions[2] != nil {
+		t.Errorf("unexpected match positions %v", m.positions)
+	}
+	if view := m.Render(60, 20); !containsStr(view, "Switch session") {
+		t.Errorf("expected highlighted titles rendered whole, got:\n%s", view)
+	}
+}
+
 func TestListModalNavigation(t *testing.T) {
 	m := NewListModal(ListModalConfig{
 		ID:    "list",
@@ -921,6 +957,43 @@ func TestApprovalModalRenderPreview(t *testing.T) {
 	}
 }
 
+func TestApprovalModalDiff(t *testing.T) {
+	diff := content.NewDiff(nil)
+	var old, new strings.Builder
+	for i := range 40 {
+		fmt.Fprintf(&old, "line %d\n", i)
+		fmt.Fprintf(&new, "line %d changed\n", i)
+	}
+	diff.SetChange("big.txt", old.String(), new.String())
+	m := NewApprovalModal(ApprovalModalConfig{
+		Tool: ToolInfo{ID: "t", Name: "edit", Diff: diff},
+	})
+	if m.Size() != SizeLarge {
+		t.Error("expected a large modal for a diff")
+	}
+
+	view := ansi.Strip(m.Render(90, 30))
+	if !strings.Contains(view, "big.txt +40 -40") || !strings.Contains(view, "- line 0") {
+		t.Errorf("expected diff in modal:\n%s", view)
+	}
+	if strings.Contains(view, "line 39 changed") {
+		t.Error("expected the diff clipped to the modal")
+	}
+	if h := lipgloss.Height(m.Render(90, 30)); h > 30 {
+		t.Errorf("expected modal within its height, got %d rows", h)
+	}
+
+	m.HandleKey(tea.KeyMsg{Type: tea.KeyPgDown})
+	if view := ansi.Strip(m.Render(90, 30)); strings.Contains(view, "- line 0") {
+		t.Error("expected page down to scroll the diff")
+	}
+
+	m.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if !diff.IsSplit() {
+		t.Error("expected s to switch the diff to side by side")
+	}
+}
+
 func TestSizePercentLarge(t *testing.T) {
 	s := SizeLarge
 	if s.HeightPercent() != 0.80 {
@@ -1061,3 +1134,32 @@ func containsStr(s, substr string) bool {
 	}
 	return false
 }
+
+func TestApprovalModalEditable(t *testing.T) {
+	var got []ApprovalDecision
+	m := NewApprovalModal(ApprovalModalConfig{
+		Tool:       ToolInfo{ID: "t", Name: "bash"},
+		Editable:   true,
+		OnDecision: func(d ApprovalDecision) { got = append(got, d) },
+	})
+	if !strings.Contains(ansi.Strip(m.Render(80, 30)), "Edit (Change parameters, then run)") {
+		t.Error("expected the Edit option")
+	}
+
+	handled, cmd := m.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	if !handled || cmd == nil {
+		t.Error("expected 'e' to decide")
+	}
+	if len(got) != 1 || got[0] != DecisionEdit {
+		t.Errorf("expected DecisionEdit, got %v", got)
+	}
+	if len(DefaultApprovalOptions) != 4 {
+		t.Error("expected the default options left unchanged")
+	}
+
+	// Without Editable, 'e' does nothing
+	plain := NewApprovalModal(ApprovalModalConfig{Tool: ToolInfo{ID: "t", Name: "bash"}})
+	if handled, _ := plain.HandleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}}); handled {
+		t.Error("expected 'e' unhandled without Editable")
+	}
+}
@@ -0,0 +1,153 @@
+package shell
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MouseHandler is implemented by modals that respond to the mouse. Event
+// coordinates are relative to the modal's top-left cell.
+type MouseHandler interface {
+	HandleMouse(msg tea.MouseMsg) (handled bool, cmd tea.Cmd)
+}
+
+// WheelScroller is implemented by content that scrolls with the mouse
+// wheel. The shell sets the lines per wheel step from config.
+type WheelScroller interface {
+	SetScrollLines(lines int)
+}
+
+// Region is a rectangle of screen cells.
+type Region struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Contains reports whether the cell at x, y is inside the region.
+func (r Region) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Local returns msg with coordinates relative to the region.
+func (r Region) Local(msg tea.MouseMsg) tea.MouseMsg {
+	msg.X -= r.X
+	msg.Y -= r.Y
+	return msg
+}
+
+// zone identifies a part of the shell layout.
+type zone int
+
+const (
+	zoneNone zone = iota
+	zoneTabBar
+	zoneContent
+	zoneInput
+	zoneStatusBar
+)
+
+// hitTest returns the part of the shell at x, y and its region.
+func (s *Shell) hitTest(x, y int) (zone, Region) {
+	type part struct {
+		zone   zone
+		height int
+		shown  bool
+	}
+	parts := []part{
+		{zoneTabBar, 1, s.config.ShowTabBar},
+		{zoneContent, s.contentHeight(), true},
+		{zoneNone, s.queueHeight(), s.queueHeight() > 0},
+		{zoneInput, s.input.Height(), s.config.ShowInput},
+		{zoneStatusBar, 1, s.config.ShowStatusBar},
+	}
+
+	top := 0
+	for _, p := range parts {
+		if !p.shown {
+			continue
+		}
+		r := Region{X: 0, Y: top, Width: s.width, Height: p.height}
+		if r.Contains(x, y) {
+			return p.zone, r
+		}
+		top += p.height
+	}
+	return zoneNone, Region{}
+}
+
+// handleMouse routes a mouse event to the component under the pointer.
+func (s *Shell) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	mc := s.config.Mouse
+	// Inline, rows are not known relative to the live region
+	if mc == nil || !mc.Enabled || s.Inline() {
+		return nil
+	}
+	// Leave shift-modified events to the terminal for text selection
+	if msg.Shift && mc.ShiftPassthrough {
+		return nil
+	}
+	if msg.Action == tea.MouseActionMotion && !mc.HoverEnabled {
+		return nil
+	}
+
+	// Modal captures the mouse when active
+	if s.modalManager.HasActive() {
+		_, cmd := s.modalManager.HandleMouse(msg)
+		return cmd
+	}
+
+	z, r := s.hitTest(msg.X, msg.Y)
+	if z != zoneTabBar {
+		s.tabs.SetHover(-1)
+	}
+
+	switch z {
+	case zoneTabBar:
+		return s.tabs.HandleMouse(r.Local(msg))
+	case zoneContent:
+		if tab := s.tabs.ActiveTab(); tab != nil && tab.Content != nil {
+			_, cmd := tab.Content.Update(r.Local(msg))
+			return cmd
+		}
+	case zoneInput:
+		if isLeftClick(msg) {
+			s.Focus(FocusInput)
+		}
+	}
+	return nil
+}
+
+// scrollLines returns the lines scrolled per wheel step.
+func (s *Shell) scrollLines() int {
+	if s.config.Mouse != nil && s.config.Mouse.ScrollLines > 0 {
+		return s.config.Mouse.ScrollLines
+	}
+	return 3
+}
+
+// isLeftClick reports whether msg is a left button press.
+func isLeftClick(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// rowMap maps the rendered rows of a list to item indices for mouse
+// hit-testing. Rows that belong to no item hold -1.
+type rowMap []int
+
+// add records the rows text takes when wrapped to width as item's.
+func (r *rowMap) add(text string, width, item int) {
+	if width > 0 {
+		text = lipgloss.NewStyle().Width(width).Render(text)
+	}
+	for i := lipgloss.Height(text); i > 0; i-- {
+		*r = append(*r, item)
+	}
+}
+
+// at returns the item at row, or -1.
+func (r rowMap) at(row int) int {
+	if row < 0 || row >= len(r) {
+		return -1
+	}
+	return r[row]
+}
@@ -0,0 +1,245 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/content"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func mouseConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Mouse = &config.MouseConfig{
+		Enabled:          true,
+		ScrollLines:      5,
+		HoverEnabled:     true,
+		ShiftPassthrough: true,
+	}
+	return cfg
+}
+
+func click(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+}
+
+func hover(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionMotion, Button: tea.MouseButtonNone}
+}
+
+func TestRegionContainsAndLocal(t *testing.T) {
+	r := Region{X: 2, Y: 3, Width: 4, Height: 2}
+	if !r.Contains(2, 3) || !r.Contains(5, 4) {
+		t.Error("expected corners to be inside")
+	}
+	if r.Contains(6, 3) || r.Contains(2, 5) {
+		t.Error("expected cells past the edge to be outside")
+	}
+	local := r.Local(tea.MouseMsg{X: 5, Y: 4})
+	if local.X != 3 || local.Y != 1 {
+		t.Errorf("expected local 3,1, got %d,%d", local.X, local.Y)
+	}
+}
+
+func TestShellHitTest(t *testing.T) {
+	s := New(nil, mouseConfig())
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	tests := []struct {
+		y    int
+		want zone
+	}{
+		{0, zoneTabBar},
+		{1, zoneContent},
+		{19, zoneContent},
+		{20, zoneInput},
+		{22, zoneInput},
+		{23, zoneStatusBar},
+	}
+	for _, tt := range tests {
+		if got, _ := s.hitTest(10, tt.y); got != tt.want {
+			t.Errorf("row %d: expected zone %d, got %d", tt.y, tt.want, got)
+		}
+	}
+}
+
+func TestShellClickActivatesTab(t *testing.T) {
+	s := New(nil, mouseConfig())
+	s.AddTab(Tab{ID: "one", Label: "One"})
+	s.AddTab(Tab{ID: "two", Label: "Two"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// "One" takes columns 0-2, then two spaces
+	s.Update(click(6, 0))
+	if s.tabs.ActiveTab().ID != "two" {
+		t.Errorf("expected click to activate tab two, got %s", s.tabs.ActiveTab().ID)
+	}
+	s.Update(click(4, 0))
+	if s.tabs.ActiveTab().ID != "two" {
+		t.Error("expected click between tabs to do nothing")
+	}
+}
+
+func TestShellMouseDisabled(t *testing.T) {
+	s := New(nil, DefaultConfig())
+	s.AddTab(Tab{ID: "one", Label: "One"})
+	s.AddTab(Tab{ID: "two", Label: "Two"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.Update(click(6, 0))
+	if s.tabs.ActiveTab().ID != "one" {
+		t.Error("expected mouse to be ignored without mouse config")
+	}
+}
+
+func TestShellShiftPassthrough(t *testing.T) {
+	s := New(nil, mouseConfig())
+	s.AddTab(Tab{ID: "one", Label: "One"})
+	s.AddTab(Tab{ID: "two", Label: "Two"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	msg := click(6, 0)
+	msg.Shift = true
+	s.Update(msg)
+	if s.tabs.ActiveTab().ID != "one" {
+		t.Error("expected shift-click to be left to the terminal")
+	}
+}
+
+func TestShellMouseToContent(t *testing.T) {
+	list := content.NewSelectList([]content.SelectItem{
+		{Label: "a"}, {Label: "b"}, {Label: "c"},
+	})
+	s := New(nil, mouseConfig())
+	s.AddTab(Tab{ID: "list", Label: "List", Content: list})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// Content starts below the tab bar
+	s.Update(click(3, 3))
+	if list.Selected() != 2 {
+		t.Errorf("expected third item selected, got %d", list.Selected())
+	}
+
+	s.Update(hover(3, 1))
+	if list.Selected() != 0 {
+		t.Errorf("expected hover to highlight first item, got %d", list.Selected())
+	}
+}
+
+func TestShellHoverDisabled(t *testing.T) {
+	list := content.NewSelectList([]content.SelectItem{{Label: "a"}, {Label: "b"}})
+	cfg := mouseConfig()
+	cfg.Mouse.HoverEnabled = false
+	s := New(nil, cfg)
+	s.AddTab(Tab{ID: "list", Label: "List", Content: list})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	s.Update(hover(3, 2))
+	if list.Selected() != 0 {
+		t.Error("expected motion to be ignored with hover disabled")
+	}
+}
+
+// scrollRecorder records the scroll lines it is given.
+type scrollRecorder struct {
+	actionRecorder
+	lines int
+}
+
+func (r *scrollRecorder) SetScrollLines(lines int) { r.lines = lines }
+
+func TestShellSetsScrollLines(t *testing.T) {
+	rec := &scrollRecorder{}
+	s := New(nil, mouseConfig())
+	s.AddTab(Tab{ID: "r", Label: "R", Content: rec})
+	if rec.lines != 5 {
+		t.Errorf("expected 5 scroll lines, got %d", rec.lines)
+	}
+}
+
+func TestShellClickInputFocuses(t *testing.T) {
+	s := New(nil, mouseConfig())
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	s.Focus(FocusTab)
+
+	s.Update(click(5, 21))
+	if s.Focused() != FocusInput {
+		t.Error("expected click on input to focus it")
+	}
+}
+
+func TestListModalMouse(t *testing.T) {
+	var chosen string
+	m := NewListModal(ListModalConfig{
+		Title: "Pick",
+		Items: []ListItem{{ID: "a", Title: "Alpha"}, {ID: "b", Title: "Beta"}},
+		OnSelect: func(item ListItem) {
+			chosen = item.ID
+		},
+	})
+	m.Render(40, 20)
+
+	// Border, padding, title and blank line come before the items
+	if handled, _ := m.HandleMouse(hover(5, 5)); !handled || m.selected != 1 {
+		t.Fatalf("expected hover to select Beta, handled=%v selected=%d", handled, m.selected)
+	}
+	if handled, _ := m.HandleMouse(hover(5, 2)); handled {
+		t.Error("expected hover over the title not to be handled")
+	}
+
+	handled, cmd := m.HandleMouse(click(5, 4))
+	if !handled || chosen != "a" {
+		t.Errorf("expected click to choose Alpha, got %q", chosen)
+	}
+	if cmd == nil {
+		t.Fatal("expected click to close the modal")
+	}
+	if _, ok := cmd().(PopMsg); !ok {
+		t.Error("expected PopMsg")
+	}
+}
+
+func TestApprovalModalMouse(t *testing.T) {
+	var decision ApprovalDecision = -1
+	m := NewApprovalModal(ApprovalModalConfig{
+		Tool:       ToolInfo{ID: "1", Name: "bash"},
+		OnDecision: func(d ApprovalDecision) { decision = d },
+	})
+	m.Render(60, 20)
+
+	// Border, padding, title, blank, tool and blank precede the options
+	m.HandleMouse(hover(5, 7))
+	if m.Selected() != 1 {
+		t.Errorf("expected hover to select Deny, got %d", m.Selected())
+	}
+	m.HandleMouse(tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	if m.Selected() != 2 {
+		t.Errorf("expected wheel to move selection, got %d", m.Selected())
+	}
+
+	_, cmd := m.HandleMouse(click(5, 9))
+	if decision != DecisionNeverAllow || cmd == nil {
+		t.Errorf("expected click to decide Never Allow, got %d", decision)
+	}
+}
+
+func TestShellRoutesMouseToModal(t *testing.T) {
+	s := New(nil, mouseConfig())
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	m := NewApprovalModal(ApprovalModalConfig{Tool: ToolInfo{ID: "1", Name: "bash"}})
+	s.PushModal(m)
+	s.View()
+
+	r := s.modalManager.region
+	s.Update(hover(r.X+5, r.Y+7))
+	if m.Selected() != 1 {
+		t.Errorf("expected hover through the shell to select Deny, got %d", m.Selected())
+	}
+
+	// Outside the modal nothing happens
+	s.Update(hover(0, 0))
+	if m.Selected() != 1 {
+		t.Error("expected events outside the modal to be ignored")
+	}
+}
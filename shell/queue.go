@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// queuedChipWidth is the widest a queued prompt's chip is drawn.
+const queuedChipWidth = 30
+
+// SetQueued sets the prompts waiting to be sent, shown as chips above the
+// input. Pass nil to clear them.
+func (s *Shell) SetQueued(prompts []string) {
+	s.queued = append([]string(nil), prompts...)
+	s.updateSizes()
+}
+
+// Queued returns the prompts shown as waiting to be sent.
+func (s *Shell) Queued() []string {
+	return append([]string(nil), s.queued...)
+}
+
+// queueHeight is the number of rows the queued prompts take.
+func (s *Shell) queueHeight() int {
+	if len(s.queued) == 0 || !s.config.ShowInput {
+		return 0
+	}
+	return 1
+}
+
+// queueView renders the queued prompts as one line of chips, each showing
+// the start of its prompt.
+func (s *Shell) queueView() string {
+	styles := s.theme.Styles()
+	parts := []string{styles.Muted.Render("queued")}
+	for _, prompt := range s.queued {
+		line, _, more := strings.Cut(strings.TrimSpace(prompt), "\n")
+		if more {
+			line += " …"
+		}
+		line = ansi.Truncate(line, queuedChipWidth, "…")
+		parts = append(parts, styles.Info.Render("["+line+"]"))
+	}
+	return ansi.Truncate(strings.Join(parts, " "), s.width, "…")
+}
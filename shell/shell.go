@@ -3,7 +3,12 @@ package shell
 
 import (
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -12,6 +17,12 @@ import (
 // RefreshMsg triggers a re-render when external state changes.
 type RefreshMsg struct{}
 
+// quitWindow is how long after a first quit key press a second one quits.
+const quitWindow = 2 * time.Second
+
+// quitExpiredMsg clears the quit prompt once the window has passed.
+type quitExpiredMsg struct{}
+
 // Shell is the top-level container that manages tabs, modals, input, and status.
 type Shell struct {
 	// Components
@@ -20,6 +31,7 @@ type Shell struct {
 	statusBar    *StatusBar
 	modalManager *Manager
 	streaming    *StreamingController
+	keymap       *Keymap
 
 	// State
 	width                  int
@@ -27,9 +39,16 @@ type Shell struct {
 	focused                FocusTarget
 	ready                  bool
 	streamingStatusVisible bool
+	queued                 []string  // Prompts waiting for the running turn
+	quitArmed              time.Time // When a quit key was first pressed
 
-	// Runtime
-	program *tea.Program
+	// Runtime; set while Run is active and read from other goroutines
+	program atomic.Pointer[tea.Program]
+
+	// Text waiting to be printed above the live region when inline
+	printMu    sync.Mutex
+	prints     []string
+	printReady chan struct{}
 
 	// Configuration
 	theme  theme.Theme
@@ -50,28 +69,73 @@ type Config struct {
 	InputPlaceholder string
 	// OnInputSubmit is called when the user submits input (presses Enter).
 	OnInputSubmit func(value string)
-	// OnShowErrors is called when user presses Ctrl+E to show errors.
+	// OnInterrupt is called when the user presses Esc (by default) while
+	// streaming, or presses a quit key for the first time while streaming.
+	// If nil, running turns cannot be interrupted from the keyboard.
+	OnInterrupt func()
+	// OnShowErrors is called when user presses Ctrl+E (by default) to show errors.
 	OnShowErrors func()
-	// OnQuickActions is called when user presses ':' to open quick actions.
-	// If nil, the ':' key is passed through to input normally.
+	// OnQuickActions is called when user presses ':' (by default) to open
+	// quick actions. If nil, the key is passed through to input normally.
 	OnQuickActions func()
-	// OnClearChat is called when user presses Ctrl+L to clear the chat.
+	// OnClearChat is called when user presses Ctrl+L (by default) to clear the chat.
 	OnClearChat func()
-	// OnSave is called when user presses Ctrl+S to save.
+	// OnSave is called when user presses Ctrl+S (by default) to save.
 	OnSave func()
-	// OnToggleFavorite is called when user presses Ctrl+F to toggle favorite.
+	// OnToggleFavorite is called when user presses Ctrl+F (by default) to toggle favorite.
 	OnToggleFavorite func()
 	// HistoryProvider returns the list of historical inputs (oldest to newest).
 	HistoryProvider func() []string
-	// HelpCategories defines the keybinding categories shown in the help overlay.
-	// If nil, the help overlay (?) is disabled.
+	// HelpCategories defines extra categories shown in the help overlay,
+	// after those generated from the keymap.
 	HelpCategories []Category
+	// Commands are the slash commands typed in the input, run instead of
+	// being passed to OnInputSubmit. Built-in commands are added for the
+	// hooks that are set, the commands are completed as the "command"
+	// provider unless the Autocomplete has one, and listed in the help
+	// overlay. If nil, input starting with "/" is submitted like any other.
+	Commands *Commands
+	// Mentions resolves @path mentions typed in the input: they are
+	// completed as the "mention" provider unless the Autocomplete has one,
+	// and the files they name are shown as chips under the input. If nil,
+	// @ has no special meaning.
+	Mentions *FileProvider
 	// Autocomplete is the autocomplete component for the input.
 	// If set, Tab triggers completion suggestions.
 	Autocomplete *Autocomplete
+	// Completion holds user autocomplete settings, applied to the
+	// Autocomplete. If nil, completions are shown only on Tab.
+	Completion *config.AutocompleteConfig
 	// Suggestions is the suggestions component for the input.
 	// If set, suggestions are analyzed on each input change.
 	Suggestions *Suggestions
+	// Keymap resolves key presses to actions. If nil, DefaultKeymap is used.
+	Keymap *Keymap
+	// KeyActions are app-defined actions bound through the keymap.
+	KeyActions []KeyAction
+	// StatusSegments are app-defined status bar segments, drawn after the
+	// built-in ones unless StatusBar says otherwise.
+	StatusSegments []StatusSegment
+	// Mouse holds user mouse settings. If nil, the mouse is not used.
+	Mouse *config.MouseConfig
+	// Input holds user prompt editor settings. If nil, the input is a
+	// single line without a character limit.
+	Input *config.InputConfig
+	// Display holds user display settings. If nil, the shell takes the
+	// whole window on the alternate screen. Inline, the mouse is not used.
+	Display *config.DisplayConfig
+	// Modal holds user modal settings; only the backdrop is used. If nil,
+	// modals are drawn over a half-dimmed screen.
+	Modal *config.ModalConfig
+	// StatusBar holds user status bar settings. If nil, the built-in
+	// segments are drawn in their default order.
+	StatusBar *config.StatusBarConfig
+	// OnMessage receives messages the shell does not handle itself, inside
+	// the update loop, before they are passed to the active tab. It
+	// reports whether it handled the message.
+	OnMessage func(msg tea.Msg) (bool, tea.Cmd)
+	// ProgramOptions are added to the Bubble Tea program options in Run.
+	ProgramOptions []tea.ProgramOption
 }
 
 // DefaultConfig returns the default shell configuration.
@@ -103,6 +167,14 @@ func New(th theme.Theme, cfg Config) *Shell {
 		th = theme.NewDraculaTheme()
 	}
 
+	keymap := cfg.Keymap
+	if keymap == nil {
+		keymap = DefaultKeymap()
+	}
+	for _, ka := range cfg.KeyActions {
+		keymap.AddCustom(ka)
+	}
+
 	s := &Shell{
 		theme:                  th,
 		config:                 cfg,
@@ -111,8 +183,27 @@ func New(th theme.Theme, cfg Config) *Shell {
 		statusBar:              NewStatusBar(th),
 		modalManager:           NewManager(),
 		streaming:              NewStreamingController(),
+		keymap:                 keymap,
 		focused:                FocusInput,
 		streamingStatusVisible: true,
+		printReady:             make(chan struct{}, 1),
+	}
+	s.tabs.SetKeymap(keymap)
+	s.input.SetKeymap(keymap)
+	if cfg.Input != nil {
+		s.input.ApplyConfig(*cfg.Input)
+	}
+
+	s.modalManager.SetTheme(th)
+	if cfg.Modal != nil {
+		s.modalManager.SetBackdrop(cfg.Modal.Backdrop, cfg.Modal.BackdropOpacity)
+	}
+
+	for _, seg := range cfg.StatusSegments {
+		s.statusBar.SetSegment(seg)
+	}
+	if cfg.StatusBar != nil {
+		s.statusBar.ApplyConfig(*cfg.StatusBar)
 	}
 
 	// Wire history provider to input
@@ -120,9 +211,34 @@ func New(th theme.Theme, cfg Config) *Shell {
 		s.input.SetHistoryProvider(cfg.HistoryProvider)
 	}
 
+	// Wire commands to autocomplete
+	if cfg.Commands != nil {
+		s.addBuiltinCommands(cfg.Commands)
+		if s.config.Autocomplete == nil {
+			s.config.Autocomplete = NewAutocomplete()
+		}
+		if !s.config.Autocomplete.hasProvider("command") {
+			s.config.Autocomplete.RegisterProvider("command", cfg.Commands)
+		}
+	}
+
+	// Wire mentions to autocomplete and the input
+	if cfg.Mentions != nil {
+		if s.config.Autocomplete == nil {
+			s.config.Autocomplete = NewAutocomplete()
+		}
+		if !s.config.Autocomplete.hasProvider("mention") {
+			s.config.Autocomplete.RegisterProvider("mention", cfg.Mentions)
+		}
+		s.input.SetMentions(cfg.Mentions)
+	}
+
 	// Wire autocomplete to input
-	if cfg.Autocomplete != nil {
-		s.input.SetAutocomplete(cfg.Autocomplete)
+	if s.config.Autocomplete != nil {
+		if cfg.Completion != nil {
+			s.config.Autocomplete.ApplyConfig(*cfg.Completion)
+		}
+		s.input.SetAutocomplete(s.config.Autocomplete)
 	}
 
 	// Wire suggestions to input
@@ -162,65 +278,21 @@ func (s *Shell) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if handled {
 				return s, cmd
 			}
-			// Esc closes modal
-			if msg.Type == tea.KeyEsc {
+			// Cancel closes modal
+			if s.keymap.Matches(ActionCancel, msg) {
 				s.modalManager.Pop()
 				return s, nil
 			}
 		}
 
-		// Global keys
-		switch msg.String() {
-		case "ctrl+c", "ctrl+q":
-			return s, tea.Quit
-		case "ctrl+e":
-			if s.config.OnShowErrors != nil {
-				s.config.OnShowErrors()
-			}
-			return s, nil
-		case "ctrl+l":
-			if s.config.OnClearChat != nil {
-				s.config.OnClearChat()
-			}
+		// Global keys and key sequences
+		action, match := s.keymap.Resolve(msg, s.eligible)
+		switch match {
+		case KeyPending:
 			return s, nil
-		case "ctrl+s":
-			if s.config.OnSave != nil {
-				s.config.OnSave()
-			}
-			return s, nil
-		case "ctrl+f":
-			if s.config.OnToggleFavorite != nil {
-				s.config.OnToggleFavorite()
-			}
-			return s, nil
-		case "esc":
-			// Toggle focus between input and tab content (when no modal active)
-			if !s.modalManager.HasActive() {
-				if s.focused == FocusInput {
-					s.focused = FocusTab
-					s.input.Blur()
-				} else if s.focused == FocusTab {
-					s.focused = FocusInput
-					return s, s.input.Focus()
-				}
-				return s, nil
-			}
-		case "?":
-			// Show help overlay if categories are configured
-			if len(s.config.HelpCategories) > 0 && !s.modalManager.HasActive() {
-				help := NewHelp(s.config.HelpCategories...)
-				modal := NewHelpModal(HelpModalConfig{
-					Help:  help,
-					Theme: s.theme,
-				})
-				s.PushModal(modal)
-				return s, nil
-			}
-		case ":":
-			// Open quick actions if configured and input is empty
-			if s.config.OnQuickActions != nil && s.input.Value() == "" && !s.modalManager.HasActive() {
-				s.config.OnQuickActions()
-				return s, nil
+		case KeyBound:
+			if handled, cmd := s.runAction(action); handled {
+				return s, cmd
 			}
 		}
 
@@ -254,6 +326,9 @@ func (s *Shell) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 
+	case tea.MouseMsg:
+		cmds = append(cmds, s.handleMouse(msg))
+
 	case PopMsg:
 		s.modalManager.Pop()
 
@@ -261,14 +336,34 @@ func (s *Shell) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.modalManager.Push(msg.Modal)
 
 	case InputSubmitMsg:
-		if s.config.OnInputSubmit != nil {
-			s.config.OnInputSubmit(msg.Value)
-		}
+		cmds = append(cmds, s.submit(msg.Value))
+
+	case completionDebounceMsg, completionsMsg:
+		var cmd tea.Cmd
+		s.input, cmd = s.input.Update(msg)
+		cmds = append(cmds, cmd)
 
 	case RefreshMsg:
 		// Just triggers re-render - state already updated externally
 
+	case noticeExpiredMsg:
+		if s.statusBar.notice == msg.notice {
+			s.statusBar.SetNotice("")
+		}
+
+	case quitExpiredMsg:
+		if time.Since(s.quitArmed) >= quitWindow {
+			s.quitArmed = time.Time{}
+			s.statusBar.SetNotice("")
+		}
+
 	default:
+		if s.config.OnMessage != nil {
+			if handled, cmd := s.config.OnMessage(msg); handled {
+				return s, cmd
+			}
+		}
+
 		// Pass unknown messages to active tab content
 		// This allows custom content to receive their own message types
 		// (e.g., sessionsLoadedMsg for history content)
@@ -301,7 +396,10 @@ func (s *Shell) View() string {
 	content := s.tabs.RenderActiveContent(s.width, contentHeight)
 	sections = append(sections, content)
 
-	// Input
+	// Queued prompts and input
+	if s.queueHeight() > 0 {
+		sections = append(sections, s.queueView())
+	}
 	if s.config.ShowInput {
 		sections = append(sections, s.input.View())
 	}
@@ -317,22 +415,249 @@ func (s *Shell) View() string {
 
 	// Overlay modal if active
 	if s.modalManager.HasActive() {
-		modalView := s.modalManager.Render(s.width, s.height)
-		output = s.overlayModal(output, modalView)
+		if s.Inline() {
+			output = s.inlineModalCanvas(output)
+		}
+		output = s.modalManager.Composite(output, s.width, s.height)
 	}
 
 	return output
 }
 
+// eligible reports whether a key binding applies in the current state.
+// Bindings for keys the input needs for typing are skipped while it has
+// focus, except printable keys on an empty input (so "?" opens help).
+func (s *Shell) eligible(action Action, binding []string) bool {
+	modal := s.modalManager.HasActive()
+
+	switch {
+	case action == ActionSubmit, action == ActionNewline:
+		// Handled by the input
+		return false
+	case action.IsView():
+		if modal || s.focused != FocusTab {
+			return false
+		}
+	case action == ActionInterrupt:
+		// Only while a turn is running, so the key can keep its other uses
+		if modal || s.config.OnInterrupt == nil || !s.streaming.IsStreaming() {
+			return false
+		}
+	case action == ActionHelp, action == ActionQuickActions, action == ActionCancel,
+		action == ActionNextTab, action == ActionPrevTab:
+		if modal {
+			return false
+		}
+	}
+
+	if s.focused == FocusTab && !modal && s.tabTyping() {
+		if len(binding) > 1 || action == ActionCancel || action == ActionInterrupt || isTypingKey(binding[0]) {
+			return false
+		}
+	}
+
+	if s.focused == FocusInput && !modal {
+		if len(binding) > 1 {
+			return false
+		}
+		// Characters and editing shortcuts only edit text once there is some
+		key := binding[0]
+		if isTypingKey(key) && !((isPrintableKey(key) || isEditingKey(key)) && s.input.Value() == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// tabTyping reports whether the active tab content is collecting text.
+func (s *Shell) tabTyping() bool {
+	tab := s.tabs.ActiveTab()
+	if tab == nil {
+		return false
+	}
+	te, ok := tab.Content.(TextEntry)
+	return ok && te.Typing()
+}
+
+// runAction performs a built-in or custom action. It reports false if the
+// action does nothing here, so the key falls through to the focused component.
+func (s *Shell) runAction(action Action) (bool, tea.Cmd) {
+	switch action {
+	case ActionQuit:
+		return true, s.quit()
+	case ActionInterrupt:
+		s.config.OnInterrupt()
+		return true, nil
+	case ActionShowErrors:
+		if s.config.OnShowErrors != nil {
+			s.config.OnShowErrors()
+		}
+		return true, nil
+	case ActionClearChat:
+		if s.config.OnClearChat != nil {
+			s.config.OnClearChat()
+		}
+		return true, nil
+	case ActionSave:
+		if s.config.OnSave != nil {
+			s.config.OnSave()
+		}
+		return true, nil
+	case ActionToggleFavorite:
+		if s.config.OnToggleFavorite != nil {
+			s.config.OnToggleFavorite()
+		}
+		return true, nil
+	case ActionCancel:
+		// Toggle focus between input and tab content
+		if s.focused == FocusInput {
+			s.focused = FocusTab
+			s.input.Blur()
+			return true, nil
+		} else if s.focused == FocusTab {
+			s.focused = FocusInput
+			return true, s.input.Focus()
+		}
+		return true, nil
+	case ActionHelp:
+		s.ShowHelp()
+		return true, nil
+	case ActionQuickActions:
+		if s.config.OnQuickActions == nil {
+			return false, nil
+		}
+		s.config.OnQuickActions()
+		return true, nil
+	case ActionNextTab:
+		s.tabs.NextTab()
+		return true, s.tabs.ActivateCurrentTab()
+	case ActionPrevTab:
+		s.tabs.PrevTab()
+		return true, s.tabs.ActivateCurrentTab()
+	}
+
+	if action.IsView() {
+		if tab := s.tabs.ActiveTab(); tab != nil && tab.Content != nil {
+			_, cmd := tab.Content.Update(ActionMsg{Action: action})
+			return true, cmd
+		}
+		return true, nil
+	}
+
+	if action.IsCustom() {
+		ka, ok := s.keymap.Custom(strings.TrimPrefix(string(action), customPrefix))
+		if !ok || ka.Handler == nil {
+			return false, nil
+		}
+		ka.Handler()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// available reports whether an action does anything in this shell, for the
+// help overlay.
+func (s *Shell) available(action Action) bool {
+	switch action {
+	case ActionSubmit:
+		return s.config.ShowInput
+	case ActionNewline:
+		return s.config.ShowInput && s.input.Multiline()
+	case ActionInterrupt:
+		return s.config.OnInterrupt != nil
+	case ActionQuickActions:
+		return s.config.OnQuickActions != nil
+	case ActionShowErrors:
+		return s.config.OnShowErrors != nil
+	case ActionClearChat:
+		return s.config.OnClearChat != nil
+	case ActionSave:
+		return s.config.OnSave != nil
+	case ActionToggleFavorite:
+		return s.config.OnToggleFavorite != nil
+	}
+	return true
+}
+
+// ShowHelp opens the help overlay. Categories are generated from the live
+// key bindings, followed by any configured HelpCategories.
+func (s *Shell) ShowHelp() {
+	categories := s.keymap.HelpCategories(s.available)
+	if s.config.Commands != nil {
+		categories = append(categories, s.config.Commands.HelpCategory())
+	}
+	categories = append(categories, s.config.HelpCategories...)
+	modal := NewHelpModal(HelpModalConfig{
+		Help:  NewHelp(categories...),
+		Theme: s.theme,
+	})
+	s.PushModal(modal)
+}
+
+// Keymap returns the shell's keymap.
+func (s *Shell) Keymap() *Keymap {
+	return s.keymap
+}
+
+// quit quits on the second press of a quit key within quitWindow. The
+// first press only interrupts a running turn and says how to quit.
+func (s *Shell) quit() tea.Cmd {
+	if !s.quitArmed.IsZero() && time.Since(s.quitArmed) < quitWindow {
+		return tea.Quit
+	}
+	if s.config.OnInterrupt != nil && s.streaming.IsStreaming() {
+		s.config.OnInterrupt()
+	}
+
+	s.quitArmed = time.Now()
+	key := "ctrl+c"
+	if keys := s.keymap.Keys(ActionQuit); len(keys) > 0 {
+		key = keys[0]
+	}
+	s.statusBar.SetNotice("Press " + key + " again to quit")
+	return tea.Tick(quitWindow, func(time.Time) tea.Msg { return quitExpiredMsg{} })
+}
+
+// isTypingKey reports whether the input needs key for editing.
+func isTypingKey(key string) bool {
+	if isPrintableKey(key) || isEditingKey(key) {
+		return true
+	}
+	switch key {
+	case "tab", "shift+tab", "enter", "backspace", "delete",
+		"up", "down", "left", "right", "home", "end":
+		return true
+	}
+	return false
+}
+
+// isEditingKey reports whether key is one of the textarea's editing
+// shortcuts. Many terminals send ctrl+h for Backspace.
+func isEditingKey(key string) bool {
+	switch key {
+	case "ctrl+h", "ctrl+k", "ctrl+u", "ctrl+w", "ctrl+a", "ctrl+e",
+		"alt+b", "alt+f", "alt+d", "alt+backspace":
+		return true
+	}
+	return false
+}
+
+// isPrintableKey reports whether key types a single character.
+func isPrintableKey(key string) bool {
+	return utf8.RuneCountInString(key) == 1
+}
+
 // contentHeight calculates available height for tab content.
 func (s *Shell) contentHeight() int {
-	h := s.height
+	h := s.liveHeight()
 	if s.config.ShowTabBar {
 		h -= 1
 	}
 	if s.config.ShowInput {
-		h -= 3 // Input with border
+		h -= s.input.Height()
 	}
+	h -= s.queueHeight()
 	if s.config.ShowStatusBar {
 		h -= 1
 	}
@@ -348,15 +673,11 @@ func (s *Shell) updateSizes() {
 	s.tabs.SetSize(s.width, s.contentHeight())
 }
 
-// overlayModal composites the modal view over the base view.
-func (s *Shell) overlayModal(base, modal string) string {
-	// For now, just return the modal centered
-	// A more sophisticated implementation would blend them
-	return modal
-}
-
 // AddTab adds a tab to the shell.
 func (s *Shell) AddTab(tab Tab) {
+	if ws, ok := tab.Content.(WheelScroller); ok {
+		ws.SetScrollLines(s.scrollLines())
+	}
 	s.tabs.AddTab(tab)
 }
 
@@ -416,6 +737,11 @@ func (s *Shell) ClearInput() {
 	s.input.SetValue("")
 }
 
+// StatusBar returns the status bar, e.g. to add segments at runtime.
+func (s *Shell) StatusBar() *StatusBar {
+	return s.statusBar
+}
+
 // SetStatus updates the status bar.
 func (s *Shell) SetStatus(status Status) {
 	s.statusBar.SetStatus(status)
@@ -426,6 +752,21 @@ func (s *Shell) Theme() theme.Theme {
 	return s.theme
 }
 
+// SetTheme changes the theme the shell is drawn with. Tab content that
+// implements Themed is given it too; open modals keep theirs.
+func (s *Shell) SetTheme(th theme.Theme) {
+	s.theme = th
+	s.tabs.SetTheme(th)
+	s.input.SetTheme(th)
+	s.statusBar.SetTheme(th)
+	s.modalManager.SetTheme(th)
+	for _, tab := range s.tabs.tabs {
+		if t, ok := tab.Content.(Themed); ok {
+			t.SetTheme(th)
+		}
+	}
+}
+
 // Streaming returns the streaming controller.
 func (s *Shell) Streaming() *StreamingController {
 	return s.streaming
@@ -438,17 +779,51 @@ func (s *Shell) SetStreamingStatusVisible(visible bool) {
 
 // Run starts the shell as a Bubble Tea program.
 func (s *Shell) Run() error {
-	s.program = tea.NewProgram(s, tea.WithAltScreen())
-	_, err := s.program.Run()
+	var opts []tea.ProgramOption
+	if !s.Inline() {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	if mc := s.config.Mouse; mc != nil && mc.Enabled && !s.Inline() {
+		// Hover needs motion reported without a button held
+		if mc.HoverEnabled {
+			opts = append(opts, tea.WithMouseAllMotion())
+		} else {
+			opts = append(opts, tea.WithMouseCellMotion())
+		}
+	}
+	opts = append(opts, s.config.ProgramOptions...)
+	p := tea.NewProgram(s, opts...)
+	s.program.Store(p)
+	defer s.program.Store(nil)
+	if s.Inline() {
+		done := make(chan struct{})
+		defer close(done)
+		go s.runPrinter(p, done)
+	}
+	_, err := p.Run()
 	return err
 }
 
+// Running reports whether Run is active, so messages sent with Send are
+// delivered to the update loop.
+func (s *Shell) Running() bool {
+	return s.program.Load() != nil
+}
+
 // Send sends a message to the shell's program to trigger an update.
 // This is used to notify the UI of external state changes.
-// Safe to call before Run() - will be a no-op.
+// Safe to call from any goroutine, and before Run() - will be a no-op.
+// It must not be called from inside Update, where it would block.
 func (s *Shell) Send(msg tea.Msg) {
-	if s.program != nil {
-		s.program.Send(msg)
+	if p := s.program.Load(); p != nil {
+		p.Send(msg)
+	}
+}
+
+// Quit stops a running shell.
+func (s *Shell) Quit() {
+	if p := s.program.Load(); p != nil {
+		p.Quit()
 	}
 }
 
@@ -4,9 +4,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/content"
 	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestShellNew(t *testing.T) {
@@ -459,6 +461,80 @@ func TestShellUpdateQuitKeys(t *testing.T) {
 	// Need to check with proper key representation
 }
 
+func TestShellQuitNeedsSecondPress(t *testing.T) {
+	interrupts := 0
+	cfg := DefaultConfig()
+	cfg.OnInterrupt = func() { interrupts++ }
+	s := New(nil, cfg)
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	s.Streaming().Start()
+
+	// The first press interrupts and explains how to quit
+	s.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if interrupts != 1 {
+		t.Errorf("expected first ctrl+c to interrupt, got %d interrupts", interrupts)
+	}
+	if view := ansi.Strip(s.View()); !strings.Contains(view, "Press ctrl+c again to quit") {
+		t.Errorf("expected quit notice in status bar\n%s", view)
+	}
+
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("expected second ctrl+c to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected second ctrl+c to return tea.Quit")
+	}
+	if interrupts != 1 {
+		t.Errorf("expected second ctrl+c not to interrupt again, got %d", interrupts)
+	}
+}
+
+func TestShellInterruptKey(t *testing.T) {
+	interrupts := 0
+	cfg := DefaultConfig()
+	cfg.OnInterrupt = func() { interrupts++ }
+	s := New(nil, cfg)
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// Idle, esc keeps switching focus
+	s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if interrupts != 0 || s.Focused() != FocusTab {
+		t.Errorf("expected esc to switch focus when idle, got %d interrupts", interrupts)
+	}
+	s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	s.Streaming().Start()
+	s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if interrupts != 1 {
+		t.Errorf("expected esc to interrupt while streaming, got %d", interrupts)
+	}
+	if s.Focused() != FocusInput {
+		t.Error("expected interrupt to leave focus alone")
+	}
+}
+
+func TestShellQueuedPrompts(t *testing.T) {
+	s := New(nil, DefaultConfig())
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	before := s.contentHeight()
+
+	s.SetQueued([]string{"run the tests", "then fix\nwhatever fails"})
+
+	view := ansi.Strip(s.View())
+	if !strings.Contains(view, "queued [run the tests] [then fix …]") {
+		t.Errorf("expected queued prompts as chips\n%s", view)
+	}
+	if s.contentHeight() != before-1 {
+		t.Errorf("expected chips to take a row from the content, got %d of %d", s.contentHeight(), before)
+	}
+
+	s.SetQueued(nil)
+	if s.contentHeight() != before {
+		t.Error("expected content to get the row back")
+	}
+}
+
 func TestShellUpdateFocusTab(t *testing.T) {
 	s := New(nil, DefaultConfig())
 	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
@@ -1059,3 +1135,38 @@ func TestShellRefreshMsgTriggersUpdate(t *testing.T) {
 		t.Error("RefreshMsg should not produce commands")
 	}
 }
+
+func TestShellModalKeepsScreenVisible(t *testing.T) {
+	s := New(nil, DefaultConfig())
+	s.AddTab(Tab{ID: "chat", Label: "Chat"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	s.PushModal(&shellTestModal{id: "overlay"})
+
+	view := ansi.Strip(s.View())
+	if !strings.Contains(view, "test modal") {
+		t.Error("expected modal to be drawn")
+	}
+	if !strings.Contains(view, "Chat") {
+		t.Error("expected tab bar to stay visible behind the modal")
+	}
+	if got := len(strings.Split(view, "\n")); got != 24 {
+		t.Errorf("expected 24 lines, got %d", got)
+	}
+}
+
+func TestShellInputGrowsIntoContent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Input = &config.InputConfig{Multiline: true, MaxHeight: 4}
+	s := New(nil, cfg)
+	s.AddTab(Tab{ID: "chat", Label: "Chat"})
+	s.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	before := s.contentHeight()
+
+	s.SetInputValue("one\ntwo\nthree")
+	if got := s.contentHeight(); got != before-2 {
+		t.Errorf("expected content height %d, got %d", before-2, got)
+	}
+	if got := len(strings.Split(s.View(), "\n")); got != 24 {
+		t.Errorf("expected 24 lines, got %d", got)
+	}
+}
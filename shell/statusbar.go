@@ -2,10 +2,14 @@ package shell
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/theme"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Status represents the current status to display.
@@ -13,8 +17,9 @@ type Status struct {
 	Model      string
 	Connected  bool
 	Streaming  bool
-	TokensUsed int
-	TokensMax  int
+	TokensUsed int     // Tokens in the context window
+	TokensMax  int     // Size of the context window; 0 if unknown
+	Cost       float64 // USD spent in the session
 	Mode       string
 	Message    string
 	Hints      string
@@ -23,106 +28,410 @@ type Status struct {
 	ErrorCount int    // Number of accumulated errors
 }
 
+// Built-in status bar segment names, as used in config.StatusBarConfig.
+const (
+	SegmentModel    = "model"    // Model name
+	SegmentStatus   = "status"   // Connection status
+	SegmentError    = "error"    // Error indicator
+	SegmentProgress = "progress" // Streaming status
+	SegmentTokens   = "tokens"   // Context window gauge
+	SegmentCost     = "cost"     // Session cost
+	SegmentMode     = "mode"     // Mode string
+	SegmentMessage  = "message"  // Custom message
+	SegmentHints    = "hints"    // Key hints (right-aligned)
+)
+
+// SegmentAlign is the side of the status bar a segment is drawn on.
+type SegmentAlign int
+
+const (
+	AlignLeft SegmentAlign = iota
+	AlignRight
+)
+
+// StatusSegment is a named piece of the status bar.
+type StatusSegment struct {
+	// Name identifies the segment in config (order, sections, custom).
+	Name string
+	// Render returns the segment text. An empty string hides the segment.
+	Render func(status Status) string
+	// Priority decides which segments are dropped first when the bar is
+	// too narrow: lower priorities go first.
+	Priority int
+	// Align places the segment on the left or right of the bar.
+	Align SegmentAlign
+}
+
+// segmentSeparator is drawn between segments on the same side.
+const segmentSeparator = " │ "
+
 // StatusBar renders the status bar at the bottom of the shell.
 type StatusBar struct {
 	status           Status
 	theme            theme.Theme
 	streaming        *StreamingController
 	streamingVisible bool
+	notice           string // Shown in place of the hints while set
+
+	segments map[string]StatusSegment
+	order    []string // Segment names in display order
+	sections map[string]config.StatusBarSection
 }
 
 // NewStatusBar creates a new status bar.
 func NewStatusBar(th theme.Theme) *StatusBar {
-	return &StatusBar{
+	s := &StatusBar{
 		theme: th,
 		status: Status{
 			Connected: true,
 		},
+		segments: make(map[string]StatusSegment),
 	}
+	for _, seg := range s.builtinSegments() {
+		s.segments[seg.Name] = seg
+		s.order = append(s.order, seg.Name)
+	}
+	return s
 }
 
-// SetStatus updates the status.
-func (s *StatusBar) SetStatus(status Status) {
-	s.status = status
+// builtinSegments returns the default segments in their default order.
+// Styles are looked up on each render, so they follow SetTheme.
+func (s *StatusBar) builtinSegments() []StatusSegment {
+	return []StatusSegment{
+		{Name: SegmentModel, Priority: 90, Render: func(st Status) string {
+			return st.Model
+		}},
+		{Name: SegmentStatus, Priority: 100, Render: func(st Status) string {
+			switch {
+			case st.Streaming:
+				return s.theme.Styles().Warning.Render("● streaming")
+			case st.Connected:
+				return s.theme.Styles().Success.Render("● connected")
+			default:
+				return s.theme.Styles().Error.Render("○ disconnected")
+			}
+		}},
+		{Name: SegmentError, Priority: 80, Render: func(st Status) string {
+			if st.ErrorText == "" {
+				return ""
+			}
+			text := fmt.Sprintf("⚠ \"%s\"", st.ErrorText)
+			if st.ErrorCount > 1 {
+				text = fmt.Sprintf("⚠ \"%s\" +%d", st.ErrorText, st.ErrorCount-1)
+			}
+			return s.theme.Styles().Error.Render(text)
+		}},
+		{Name: SegmentProgress, Priority: 70, Render: func(Status) string {
+			if !s.streamingVisible || s.streaming == nil {
+				return ""
+			}
+			return s.streaming.RenderStatus(s.theme)
+		}},
+		{Name: SegmentTokens, Priority: 50, Render: func(st Status) string {
+			return s.renderGauge(st.TokensUsed, st.TokensMax)
+		}},
+		{Name: SegmentCost, Priority: 45, Render: func(st Status) string {
+			if st.Cost <= 0 {
+				return ""
+			}
+			return s.theme.Styles().Muted.Render(FormatCost(st.Cost))
+		}},
+		{Name: SegmentMode, Priority: 40, Render: func(st Status) string {
+			if st.Mode == "" {
+				return ""
+			}
+			return s.theme.Styles().Subtitle.Render(st.Mode)
+		}},
+		{Name: SegmentMessage, Priority: 60, Render: func(st Status) string {
+			return st.Message
+		}},
+		{Name: SegmentHints, Priority: 10, Align: AlignRight, Render: func(st Status) string {
+			if s.notice != "" {
+				return s.theme.Styles().Warning.Render(s.notice)
+			}
+			if st.Hints == "" {
+				return ""
+			}
+			return s.theme.Styles().Muted.Render(st.Hints)
+		}},
+	}
 }
 
-// View renders the status bar.
-func (s *StatusBar) View(width int) string {
-	styles := s.theme.Styles()
+// SetSegment adds a segment, or replaces the segment with the same name.
+// New segments are drawn after the existing ones on their side.
+func (s *StatusBar) SetSegment(seg StatusSegment) {
+	if seg.Render == nil {
+		return
+	}
+	if _, ok := s.segments[seg.Name]; !ok {
+		s.order = append(s.order, seg.Name)
+	}
+	s.segments[seg.Name] = seg
+}
 
-	var sections []string
+// RemoveSegment removes a segment.
+func (s *StatusBar) RemoveSegment(name string) {
+	delete(s.segments, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
 
-	// Model
-	if s.status.Model != "" {
-		sections = append(sections, s.status.Model)
+// SetOrder sets the display order. Named segments are drawn in the given
+// order; built-in segments left out are hidden and other segments left out
+// keep their place after the named ones. Unknown names are ignored.
+func (s *StatusBar) SetOrder(names []string) {
+	var order []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if _, ok := s.segments[name]; ok && !seen[name] {
+			order = append(order, name)
+			seen[name] = true
+		}
 	}
+	for _, name := range s.order {
+		if !seen[name] && !s.isBuiltin(name) {
+			order = append(order, name)
+		}
+	}
+	s.order = order
+}
 
-	// Connection status
-	var statusText string
-	if s.status.Streaming {
-		statusText = styles.Warning.Render("● streaming")
-	} else if s.status.Connected {
-		statusText = styles.Success.Render("● connected")
-	} else {
-		statusText = styles.Error.Render("○ disconnected")
+// Order returns the segment names in display order.
+func (s *StatusBar) Order() []string {
+	return append([]string(nil), s.order...)
+}
+
+// ApplyConfig applies user settings: the segment order, per-segment max
+// widths and formats, and the position and priority of custom segments.
+// Call it after registering app segments so custom settings can find them.
+func (s *StatusBar) ApplyConfig(cfg config.StatusBarConfig) {
+	if len(cfg.Order) > 0 {
+		s.SetOrder(cfg.Order)
 	}
-	sections = append(sections, statusText)
+	s.sections = cfg.Sections
 
-	// Error indicator (after connection status section)
-	if s.status.ErrorText != "" {
-		errorText := fmt.Sprintf("⚠ \"%s\"", s.status.ErrorText)
-		if s.status.ErrorCount > 1 {
-			errorText = fmt.Sprintf("⚠ \"%s\" +%d", s.status.ErrorText, s.status.ErrorCount-1)
+	// Place custom segments by position, lowest first so later inserts
+	// land where the user expects
+	names := make([]string, 0, len(cfg.Custom))
+	for name := range cfg.Custom {
+		if _, ok := s.segments[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return cfg.Custom[names[i]].Position < cfg.Custom[names[j]].Position
+	})
+	for _, name := range names {
+		custom := cfg.Custom[name]
+		seg := s.segments[name]
+		if custom.Priority != 0 {
+			seg.Priority = custom.Priority
+			s.segments[name] = seg
 		}
-		sections = append(sections, styles.Error.Render(errorText))
+		s.move(name, custom.Position)
 	}
+}
 
-	// Streaming status
-	if s.streamingVisible && s.streaming != nil {
-		streamingStatus := s.streaming.RenderStatus(s.theme)
-		if streamingStatus != "" {
-			sections = append(sections, streamingStatus)
+// move moves the named segment to index pos in the order.
+func (s *StatusBar) move(name string, pos int) {
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
 		}
 	}
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(s.order) {
+		pos = len(s.order)
+	}
+	s.order = append(s.order[:pos], append([]string{name}, s.order[pos:]...)...)
+}
+
+// gaugeWidth is the number of cells in the context window gauge.
+const gaugeWidth = 8
 
-	// Tokens
-	if s.status.TokensMax > 0 {
-		tokenText := fmt.Sprintf("%dk/%dk", s.status.TokensUsed/1000, s.status.TokensMax/1000)
-		sections = append(sections, styles.Muted.Render(tokenText))
+// Context window use at which the gauge turns to a warning, then an alert.
+const (
+	gaugeWarnAt  = 0.7
+	gaugeAlertAt = 0.9
+)
+
+// renderGauge renders context window use as a bar coloured by how full the
+// window is. Without a known window size only the token count is shown.
+func (s *StatusBar) renderGauge(used, window int) string {
+	styles := s.theme.Styles()
+	if window <= 0 {
+		if used <= 0 {
+			return ""
+		}
+		return styles.Muted.Render(FormatTokens(used) + " tokens")
 	}
 
-	// Mode
-	if s.status.Mode != "" {
-		sections = append(sections, styles.Subtitle.Render(s.status.Mode))
+	frac := float64(used) / float64(window)
+	filled := int(math.Round(frac * gaugeWidth))
+	filled = min(max(filled, 0), gaugeWidth)
+	if used > 0 && filled == 0 {
+		filled = 1
 	}
 
-	// Custom message
-	if s.status.Message != "" {
-		sections = append(sections, s.status.Message)
+	style := styles.Success
+	label := FormatTokens(used) + "/" + FormatTokens(window)
+	switch {
+	case frac >= gaugeAlertAt:
+		style = styles.Error
+		label = "⚠ " + label
+	case frac >= gaugeWarnAt:
+		style = styles.Warning
 	}
+	bar := style.Render(strings.Repeat("▰", filled)) + styles.Muted.Render(strings.Repeat("▱", gaugeWidth-filled))
+	return bar + " " + style.Render(label)
+}
 
-	// Join left sections
-	left := strings.Join(sections, " │ ")
+// FormatTokens formats a token count compactly: 950, 12.5k, 200k, 1.2M.
+func FormatTokens(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 10_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "k"
+	case n < 1_000_000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e6), ".0") + "M"
+	}
+}
 
-	// Hints on right
-	right := ""
-	if s.status.Hints != "" {
-		right = styles.Muted.Render(s.status.Hints)
+// FormatCost formats an amount in USD, keeping fractions of a cent visible.
+func FormatCost(usd float64) string {
+	if usd < 1 {
+		return fmt.Sprintf("$%.3f", usd)
 	}
+	return fmt.Sprintf("$%.2f", usd)
+}
 
-	// Calculate spacing
-	leftWidth := lipgloss.Width(left)
-	rightWidth := lipgloss.Width(right)
-	spacing := width - leftWidth - rightWidth
-	if spacing < 1 {
-		spacing = 1
+// isBuiltin reports whether name is a built-in segment.
+func (s *StatusBar) isBuiltin(name string) bool {
+	switch name {
+	case SegmentModel, SegmentStatus, SegmentError, SegmentProgress,
+		SegmentTokens, SegmentCost, SegmentMode, SegmentMessage, SegmentHints:
+		return true
 	}
+	return false
+}
 
-	bar := left + strings.Repeat(" ", spacing) + right
+// SetNotice shows a short notice in place of the key hints, such as how to
+// confirm quitting. Pass "" to remove it.
+func (s *StatusBar) SetNotice(notice string) {
+	s.notice = notice
+}
+
+// SetTheme sets the theme the status bar is drawn with.
+func (s *StatusBar) SetTheme(th theme.Theme) {
+	s.theme = th
+}
+
+// SetStatus updates the status.
+func (s *StatusBar) SetStatus(status Status) {
+	s.status = status
+}
+
+// renderedSegment is a segment's output for one frame.
+type renderedSegment struct {
+	text     string
+	priority int
+	align    SegmentAlign
+}
+
+// render renders the visible segments in order, applying formats and
+// max widths from config.
+func (s *StatusBar) render() []renderedSegment {
+	var out []renderedSegment
+	for _, name := range s.order {
+		seg, ok := s.segments[name]
+		if !ok {
+			continue
+		}
+		text := seg.Render(s.status)
+		if text == "" {
+			continue
+		}
+		if section, ok := s.sections[name]; ok {
+			if section.Format != "" {
+				text = strings.ReplaceAll(section.Format, "%s", text)
+			}
+			if section.MaxWidth > 0 {
+				text = ansi.Truncate(text, section.MaxWidth, "…")
+			}
+		}
+		out = append(out, renderedSegment{text: text, priority: seg.Priority, align: seg.Align})
+	}
+	return out
+}
+
+// View renders the status bar.
+func (s *StatusBar) View(width int) string {
+	styles := s.theme.Styles()
+	avail := width - styles.StatusBar.GetHorizontalFrameSize()
+
+	segs := s.render()
+	left, right := joinSegments(segs)
+
+	// Drop the lowest-priority segments until the bar fits, latest first
+	// among equals
+	for len(segs) > 1 && barWidth(left, right) > avail {
+		drop := len(segs) - 1
+		for i := len(segs) - 1; i >= 0; i-- {
+			if segs[i].priority < segs[drop].priority {
+				drop = i
+			}
+		}
+		segs = append(segs[:drop], segs[drop+1:]...)
+		left, right = joinSegments(segs)
+	}
+
+	var bar string
+	if barWidth(left, right) > avail {
+		bar = ansi.Truncate(left+right, max(avail, 0), "…")
+	} else {
+		spacing := avail - lipgloss.Width(left) - lipgloss.Width(right)
+		if spacing < 1 {
+			spacing = 1
+		}
+		bar = left + strings.Repeat(" ", spacing) + right
+	}
 
 	return styles.StatusBar.Width(width).Render(bar)
 }
 
+// joinSegments joins the left and right segments with separators.
+func joinSegments(segs []renderedSegment) (left, right string) {
+	var l, r []string
+	for _, seg := range segs {
+		if seg.align == AlignRight {
+			r = append(r, seg.text)
+		} else {
+			l = append(l, seg.text)
+		}
+	}
+	return strings.Join(l, segmentSeparator), strings.Join(r, segmentSeparator)
+}
+
+// barWidth returns the width needed to show left and right with at least
+// one space between them.
+func barWidth(left, right string) int {
+	w := lipgloss.Width(left) + lipgloss.Width(right)
+	if left != "" && right != "" {
+		w++
+	}
+	return w
+}
+
 // SetModel sets the model name.
 func (s *StatusBar) SetModel(model string) {
 	s.status.Model = model
@@ -144,6 +453,11 @@ func (s *StatusBar) SetTokens(used, max int) {
 	s.status.TokensMax = max
 }
 
+// SetCost sets the session cost in USD.
+func (s *StatusBar) SetCost(cost float64) {
+	s.status.Cost = cost
+}
+
 // SetMode sets the mode string.
 func (s *StatusBar) SetMode(mode string) {
 	s.status.Mode = mode
@@ -4,7 +4,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/theme"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestStatusBarErrorIndicator(t *testing.T) {
@@ -45,3 +47,156 @@ func TestStatusBarClearError(t *testing.T) {
 		t.Error("status bar should not show error after clearing")
 	}
 }
+
+func TestStatusBarDefaultOrder(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "opus", Connected: true, Mode: "plan", Hints: "? help"})
+
+	view := ansi.Strip(sb.View(80))
+	model := strings.Index(view, "opus")
+	status := strings.Index(view, "connected")
+	mode := strings.Index(view, "plan")
+	hints := strings.Index(view, "? help")
+	if !(model < status && status < mode && mode < hints) {
+		t.Errorf("unexpected segment order: %q", view)
+	}
+	if !strings.HasSuffix(strings.TrimRight(view, " "), "? help") {
+		t.Errorf("expected hints to be right-aligned: %q", view)
+	}
+}
+
+func TestStatusBarConfigOrderHidesUnlisted(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "opus", Connected: true, Mode: "plan"})
+	sb.ApplyConfig(config.StatusBarConfig{Order: []string{"mode", "model"}})
+
+	view := ansi.Strip(sb.View(80))
+	if strings.Contains(view, "connected") {
+		t.Errorf("expected unlisted status segment to be hidden: %q", view)
+	}
+	if strings.Index(view, "plan") > strings.Index(view, "opus") {
+		t.Errorf("expected mode before model: %q", view)
+	}
+}
+
+func TestStatusBarSectionFormatAndWidth(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "claude-sonnet-with-a-long-name", Connected: true})
+	sb.ApplyConfig(config.StatusBarConfig{Sections: map[string]config.StatusBarSection{
+		"model": {Format: "[%s]", MaxWidth: 10},
+	}})
+
+	view := ansi.Strip(sb.View(80))
+	if !strings.Contains(view, "[claude-s…") {
+		t.Errorf("expected formatted, truncated model: %q", view)
+	}
+}
+
+func TestStatusBarCustomSegment(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "opus", Connected: true})
+	sb.SetSegment(StatusSegment{Name: "git", Priority: 50, Render: func(Status) string { return "main" }})
+
+	view := ansi.Strip(sb.View(80))
+	if strings.Index(view, "main") < strings.Index(view, "connected") {
+		t.Errorf("expected custom segment after built-ins: %q", view)
+	}
+
+	sb.ApplyConfig(config.StatusBarConfig{Custom: map[string]config.CustomSection{
+		"git": {Position: 0},
+	}})
+	view = ansi.Strip(sb.View(80))
+	if strings.Index(view, "main") > strings.Index(view, "opus") {
+		t.Errorf("expected custom segment moved to the front: %q", view)
+	}
+
+	sb.RemoveSegment("git")
+	if strings.Contains(ansi.Strip(sb.View(80)), "main") {
+		t.Error("expected removed segment to be gone")
+	}
+}
+
+func TestStatusBarDropsLowPriorityWhenNarrow(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "opus", Connected: true, Mode: "plan", Hints: "ctrl+h help"})
+	sb.SetSegment(StatusSegment{Name: "cost", Priority: 95, Render: func(Status) string { return "$0.12" }})
+
+	view := sb.View(30)
+	if w := ansi.StringWidth(view); w != 30 {
+		t.Errorf("expected width 30, got %d", w)
+	}
+	plain := ansi.Strip(view)
+	for _, want := range []string{"connected", "$0.12", "opus"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected high-priority %q to stay: %q", want, plain)
+		}
+	}
+	for _, gone := range []string{"plan", "help"} {
+		if strings.Contains(plain, gone) {
+			t.Errorf("expected low-priority %q to be dropped: %q", gone, plain)
+		}
+	}
+
+	// The last segment is truncated rather than overflowing
+	if w := ansi.StringWidth(sb.View(5)); w != 5 {
+		t.Errorf("expected width 5, got %d", w)
+	}
+}
+
+func TestStatusBarTokenGauge(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+
+	sb.SetTokens(50000, 200000)
+	view := ansi.Strip(sb.View(80))
+	if !strings.Contains(view, "▰▰▱▱▱▱▱▱ 50k/200k") {
+		t.Errorf("expected quarter-full gauge: %q", view)
+	}
+	if strings.Contains(view, "⚠") {
+		t.Errorf("expected no warning below the limit: %q", view)
+	}
+
+	sb.SetTokens(190000, 200000)
+	view = ansi.Strip(sb.View(80))
+	if !strings.Contains(view, "⚠ 190k/200k") {
+		t.Errorf("expected warning near the limit: %q", view)
+	}
+
+	// Unknown window size shows the count alone
+	sb.SetTokens(1200, 0)
+	view = ansi.Strip(sb.View(80))
+	if !strings.Contains(view, "1.2k tokens") || strings.Contains(view, "▰") {
+		t.Errorf("expected plain token count: %q", view)
+	}
+}
+
+func TestStatusBarCost(t *testing.T) {
+	sb := NewStatusBar(theme.NewDraculaTheme())
+	sb.SetStatus(Status{Model: "opus"})
+	if strings.Contains(ansi.Strip(sb.View(80)), "$") {
+		t.Error("expected no cost before any is set")
+	}
+
+	sb.SetCost(0.0423)
+	if view := ansi.Strip(sb.View(80)); !strings.Contains(view, "$0.042") {
+		t.Errorf("expected cost segment: %q", view)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := map[int]string{
+		950:       "950",
+		1200:      "1.2k",
+		3000:      "3k",
+		12500:     "12k",
+		200000:    "200k",
+		1_300_000: "1.3M",
+	}
+	for n, want := range tests {
+		if got := FormatTokens(n); got != want {
+			t.Errorf("FormatTokens(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := FormatCost(12.5); got != "$12.50" {
+		t.Errorf("FormatCost(12.5) = %q, want $12.50", got)
+	}
+}
@@ -2,6 +2,7 @@ package shell
 
 import (
 	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/theme"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -18,3 +19,16 @@ type TabContent interface {
 	// OnDeactivate is called when the tab becomes inactive.
 	OnDeactivate()
 }
+
+// TextEntry can be implemented by tab content that collects typed text,
+// such as a filter query. While Typing reports true, the content receives
+// the keys it needs for typing (and Esc) instead of the key bindings on them.
+type TextEntry interface {
+	Typing() bool
+}
+
+// Themed can be implemented by tab content drawn with the shell's theme, to
+// follow it when SetTheme changes it.
+type Themed interface {
+	SetTheme(th theme.Theme)
+}
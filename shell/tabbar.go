@@ -25,9 +25,11 @@ type TabBar struct {
 	tabs       []Tab
 	active     int
 	lastActive int // Track previous active tab for lifecycle hooks
+	hover      int // Tab under the mouse pointer, or -1
 	width      int
 	height     int
 	theme      theme.Theme
+	keymap     *Keymap
 }
 
 // NewTabBar creates a new tab bar.
@@ -36,9 +38,21 @@ func NewTabBar(th theme.Theme) *TabBar {
 		tabs:       make([]Tab, 0),
 		theme:      th,
 		lastActive: -1, // No previous tab initially
+		hover:      -1,
+		keymap:     DefaultKeymap(),
 	}
 }
 
+// SetKeymap sets the keymap used for tab navigation keys.
+func (t *TabBar) SetKeymap(k *Keymap) {
+	t.keymap = k
+}
+
+// SetTheme sets the theme the tab bar is drawn with.
+func (t *TabBar) SetTheme(th theme.Theme) {
+	t.theme = th
+}
+
 // AddTab adds a tab.
 func (t *TabBar) AddTab(tab Tab) {
 	t.tabs = append(t.tabs, tab)
@@ -52,6 +66,7 @@ func (t *TabBar) RemoveTab(id string) {
 	for i, tab := range t.tabs {
 		if tab.ID == id {
 			t.tabs = append(t.tabs[:i], t.tabs[i+1:]...)
+			t.hover = -1
 			if t.active >= len(t.tabs) {
 				t.active = len(t.tabs) - 1
 			}
@@ -127,11 +142,11 @@ func (t *TabBar) SetSize(width, height int) {
 func (t *TabBar) HandleKey(msg tea.KeyMsg) tea.Cmd {
 	var cmds []tea.Cmd
 
-	switch msg.String() {
-	case "tab", "ctrl+tab":
+	switch {
+	case t.keymap.Matches(ActionNextTab, msg):
 		t.NextTab()
 		cmds = append(cmds, t.ActivateCurrentTab())
-	case "shift+tab", "ctrl+shift+tab":
+	case t.keymap.Matches(ActionPrevTab, msg):
 		t.PrevTab()
 		cmds = append(cmds, t.ActivateCurrentTab())
 	}
@@ -147,6 +162,41 @@ func (t *TabBar) HandleKey(msg tea.KeyMsg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// HandleMouse handles a mouse event with coordinates relative to the tab
+// bar: a click activates the tab under the pointer and motion highlights it.
+func (t *TabBar) HandleMouse(msg tea.MouseMsg) tea.Cmd {
+	index := t.tabAt(msg.X)
+	switch {
+	case msg.Action == tea.MouseActionMotion:
+		t.hover = index
+	case isLeftClick(msg) && index >= 0:
+		t.active = index
+		return t.ActivateCurrentTab()
+	}
+	return nil
+}
+
+// SetHover sets the tab highlighted under the mouse pointer (-1 for none).
+func (t *TabBar) SetHover(index int) {
+	t.hover = index
+}
+
+// tabAt returns the index of the tab drawn at column x, or -1.
+func (t *TabBar) tabAt(x int) int {
+	pos := 0
+	for i, tab := range t.tabs {
+		if tab.Hidden {
+			continue
+		}
+		w := lipgloss.Width(t.renderTab(i))
+		if x >= pos && x < pos+w {
+			return i
+		}
+		pos += w + lipgloss.Width(tabSeparator)
+	}
+	return -1
+}
+
 // NextTab switches to the next tab.
 func (t *TabBar) NextTab() {
 	if len(t.tabs) > 0 {
@@ -170,34 +220,44 @@ func (t *TabBar) View() string {
 		return ""
 	}
 
-	styles := t.theme.Styles()
 	var tabs []string
-
 	for i, tab := range t.tabs {
 		if tab.Hidden {
 			continue
 		}
-
-		label := tab.Label
-		if tab.Badge != "" {
-			label += " " + tab.Badge
-		}
-
-		var style lipgloss.Style
-		if i == t.active {
-			style = styles.TabActive
-		} else {
-			style = styles.TabInactive
-		}
-
-		tabs = append(tabs, style.Render(label))
+		tabs = append(tabs, t.renderTab(i))
 	}
 
 	if len(tabs) == 0 {
 		return ""
 	}
 
-	return strings.Join(tabs, "  ")
+	return strings.Join(tabs, tabSeparator)
+}
+
+// tabSeparator is drawn between tabs.
+const tabSeparator = "  "
+
+// renderTab renders the label of tab i.
+func (t *TabBar) renderTab(i int) string {
+	styles := t.theme.Styles()
+	tab := t.tabs[i]
+
+	label := tab.Label
+	if tab.Badge != "" {
+		label += " " + tab.Badge
+	}
+
+	var style lipgloss.Style
+	switch {
+	case i == t.active:
+		style = styles.TabActive
+	case i == t.hover:
+		style = styles.TabInactive.Foreground(t.theme.Foreground())
+	default:
+		style = styles.TabInactive
+	}
+	return style.Render(label)
 }
 
 // RenderActiveContent renders the content of the active tab.
@@ -2,26 +2,94 @@
 package tux
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
-// Compile-time check that ToolsContent implements content.Content.
-var _ content.Content = (*ToolsContent)(nil)
+// Compile-time checks that ToolsContent is tab content that collects
+// typed filter text.
+var (
+	_ content.Content  = (*ToolsContent)(nil)
+	_ shell.TabContent = (*ToolsContent)(nil)
+	_ shell.TextEntry  = (*ToolsContent)(nil)
+)
+
+// toolsTickInterval is how often elapsed times and spinners of running
+// calls are redrawn while the Tools tab is active.
+const toolsTickInterval = 100 * time.Millisecond
+
+// toolsSpinner animates running calls.
+var toolsSpinner = spinner.MiniDot
+
+// ToolStatusFilter selects which tool calls the Tools tab lists.
+type ToolStatusFilter int
+
+const (
+	// ToolFilterAll lists every call.
+	ToolFilterAll ToolStatusFilter = iota
+	// ToolFilterRunning lists calls still in flight.
+	ToolFilterRunning
+	// ToolFilterSuccess lists calls that succeeded.
+	ToolFilterSuccess
+	// ToolFilterFailed lists calls that failed.
+	ToolFilterFailed
+)
+
+// String returns the filter name shown in the Tools tab header.
+func (f ToolStatusFilter) String() string {
+	switch f {
+	case ToolFilterRunning:
+		return "running"
+	case ToolFilterSuccess:
+		return "success"
+	case ToolFilterFailed:
+		return "failed"
+	default:
+		return "all"
+	}
+}
 
 // ToolsContent displays the tool call timeline in the Tools tab.
+//
+// When the tab has focus, the line keys move the cursor, Enter or Space
+// expands a call to show its parameters, full output and duration, "f"
+// cycles the status filter, "/" filters by tool name and "y" copies the
+// selected call's output.
 type ToolsContent struct {
 	mu     sync.Mutex
 	theme  theme.Theme
 	items  []toolItem
 	width  int
 	height int
+
+	viewport viewport.Model
+	cursor   int             // Index into the visible items
+	expanded map[string]bool // Expanded calls by ID
+	starts   []int           // First viewport line of each visible item
+
+	status    ToolStatusFilter
+	name      string // Tool name filter
+	typing    bool   // Whether the name filter is being edited
+	flash     string // One-off notice, such as a copy result
+	clipboard func(string) error
+
+	active  bool // Whether the tab is shown, so ticks keep running
+	tick    int  // Generation of the current tick chain
+	ticking bool // Whether the current chain has a tick scheduled
 }
 
 type toolItem struct {
@@ -32,6 +100,12 @@ type toolItem struct {
 	success   bool
 	completed bool
 	timestamp time.Time
+	finished  time.Time
+}
+
+// toolsTickMsg redraws running calls. Ticks from an older chain are dropped.
+type toolsTickMsg struct {
+	gen int
 }
 
 // NewToolsContent creates a new ToolsContent.
@@ -39,9 +113,16 @@ func NewToolsContent(th theme.Theme) *ToolsContent {
 	if th == nil {
 		panic("NewToolsContent: nil theme")
 	}
+	// Keys are resolved by the shell keymap, not the viewport
+	vp := viewport.New(0, 0)
+	vp.KeyMap = viewport.KeyMap{}
+
 	return &ToolsContent{
-		theme: th,
-		items: make([]toolItem, 0),
+		theme:     th,
+		items:     make([]toolItem, 0),
+		viewport:  vp,
+		expanded:  make(map[string]bool),
+		clipboard: clipboard.WriteAll,
 	}
 }
 
@@ -50,11 +131,281 @@ func (c *ToolsContent) Init() tea.Cmd {
 	return nil
 }
 
+// OnActivate implements shell.TabContent. It starts redrawing running
+// calls while the tab is shown.
+func (c *ToolsContent) OnActivate() tea.Cmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = true
+	c.tick++
+	c.ticking = false
+	return c.startTicks()
+}
+
+// resumeTicks starts redrawing again once a call is pending, if the tab
+// is shown and no tick is scheduled.
+func (c *ToolsContent) resumeTicks() tea.Cmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.startTicks()
+}
+
+// startTicks starts a tick chain if the tab is shown, none is running and
+// a call is pending.
+// Must be called with mutex held.
+func (c *ToolsContent) startTicks() tea.Cmd {
+	if !c.active || c.ticking || !c.pending() {
+		return nil
+	}
+	c.tick++
+	c.ticking = true
+	return c.tickCmd()
+}
+
+// pending reports whether any call is still waiting for its result.
+// Must be called with mutex held.
+func (c *ToolsContent) pending() bool {
+	for i := range c.items {
+		if !c.items[i].completed {
+			return true
+		}
+	}
+	return false
+}
+
+// OnDeactivate implements shell.TabContent.
+func (c *ToolsContent) OnDeactivate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = false
+	c.typing = false
+}
+
+// Typing implements shell.TextEntry. It reports whether the name filter
+// is being edited.
+func (c *ToolsContent) Typing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.typing
+}
+
+// tickCmd schedules the next redraw for the current tick chain.
+// Must be called with mutex held.
+func (c *ToolsContent) tickCmd() tea.Cmd {
+	gen := c.tick
+	return tea.Tick(toolsTickInterval, func(time.Time) tea.Msg {
+		return toolsTickMsg{gen: gen}
+	})
+}
+
 // Update implements content.Content.
+// Line and scroll keys arrive as shell.ActionMsg so they follow the keymap.
 func (c *ToolsContent) Update(msg tea.Msg) (content.Content, tea.Cmd) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch msg := msg.(type) {
+	case toolsTickMsg:
+		if msg.gen != c.tick {
+			return c, nil
+		}
+		// Redraws stop once nothing is pending
+		if !c.active || !c.pending() {
+			c.ticking = false
+			return c, nil
+		}
+		return c, c.tickCmd()
+
+	case shell.ActionMsg:
+		c.flash = ""
+		switch msg.Action {
+		case shell.ActionLineUp:
+			c.moveCursor(c.cursor - 1)
+		case shell.ActionLineDown:
+			c.moveCursor(c.cursor + 1)
+		case shell.ActionScrollUp:
+			c.viewport.HalfViewUp()
+		case shell.ActionScrollDown:
+			c.viewport.HalfViewDown()
+		case shell.ActionScrollTop:
+			c.moveCursor(0)
+		case shell.ActionScrollBottom:
+			c.moveCursor(len(c.visible()) - 1)
+		}
+
+	case tea.KeyMsg:
+		c.flash = ""
+		if c.typing {
+			c.editFilter(msg)
+			return c, nil
+		}
+		c.handleKey(msg)
+
+	case tea.MouseMsg:
+		if tea.MouseEvent(msg).IsWheel() {
+			c.viewport, _ = c.viewport.Update(msg)
+		} else if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			c.clickAt(msg.Y)
+		}
+	}
+
 	return c, nil
 }
 
+// handleKey handles the Tools tab's own keys.
+// Must be called with mutex held.
+func (c *ToolsContent) handleKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "enter", " ":
+		if item := c.selected(); item != nil {
+			c.expanded[item.id] = !c.expanded[item.id]
+			c.moveCursor(c.cursor)
+		}
+	case "f":
+		c.setFilter(func() { c.status = (c.status + 1) % (ToolFilterFailed + 1) })
+	case "/":
+		c.typing = true
+	case "y":
+		c.copySelected()
+	}
+}
+
+// editFilter edits the name filter while it is being typed. Enter keeps
+// the filter and Esc clears it.
+// Must be called with mutex held.
+func (c *ToolsContent) editFilter(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		c.typing = false
+	case tea.KeyEsc:
+		c.typing = false
+		c.setFilter(func() { c.name = "" })
+	case tea.KeyBackspace:
+		if r := []rune(c.name); len(r) > 0 {
+			c.setFilter(func() { c.name = string(r[:len(r)-1]) })
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		c.setFilter(func() { c.name += string(msg.Runes) })
+	}
+}
+
+// setFilter changes the filter, keeping the cursor on the same call when
+// it is still listed.
+// Must be called with mutex held.
+func (c *ToolsContent) setFilter(change func()) {
+	var id string
+	if item := c.selected(); item != nil {
+		id = item.id
+	}
+	change()
+
+	cursor := 0
+	for i, item := range c.visible() {
+		if item.id == id {
+			cursor = i
+			break
+		}
+	}
+	c.viewport.GotoTop()
+	c.moveCursor(cursor)
+}
+
+// copySelected copies the selected call's output to the clipboard.
+// Must be called with mutex held.
+func (c *ToolsContent) copySelected() {
+	item := c.selected()
+	if item == nil {
+		return
+	}
+	if item.output == "" {
+		c.flash = "Nothing to copy"
+		return
+	}
+	if err := c.clipboard(item.output); err != nil {
+		c.flash = fmt.Sprintf("Copy failed: %v", err)
+		return
+	}
+	c.flash = fmt.Sprintf("Copied output of %s", item.name)
+}
+
+// clickAt selects the call drawn on row y of the tab.
+// Must be called with mutex held.
+func (c *ToolsContent) clickAt(y int) {
+	if y < 1 {
+		return
+	}
+	line := y - 1 + c.viewport.YOffset // Below the header row
+	for i := len(c.starts) - 1; i >= 0; i-- {
+		if line >= c.starts[i] {
+			c.moveCursor(i)
+			return
+		}
+	}
+}
+
+// visible returns the calls that pass the filters.
+// Must be called with mutex held.
+func (c *ToolsContent) visible() []*toolItem {
+	name := strings.ToLower(c.name)
+	var items []*toolItem
+	for i := range c.items {
+		item := &c.items[i]
+		if name != "" && !strings.Contains(strings.ToLower(item.name), name) {
+			continue
+		}
+		switch c.status {
+		case ToolFilterRunning:
+			if item.completed {
+				continue
+			}
+		case ToolFilterSuccess:
+			if !item.completed || !item.success {
+				continue
+			}
+		case ToolFilterFailed:
+			if !item.completed || item.success {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// selected returns the call under the cursor, or nil.
+// Must be called with mutex held.
+func (c *ToolsContent) selected() *toolItem {
+	items := c.visible()
+	if c.cursor < 0 || c.cursor >= len(items) {
+		return nil
+	}
+	return items[c.cursor]
+}
+
+// moveCursor moves the cursor to index and scrolls the call into view.
+// Must be called with mutex held.
+func (c *ToolsContent) moveCursor(index int) {
+	n := len(c.visible())
+	c.cursor = max(min(index, n-1), 0)
+	c.refresh()
+	if c.cursor >= len(c.starts) {
+		return
+	}
+
+	// Show the whole call when it fits, and always its first line
+	top := c.starts[c.cursor]
+	bottom := c.viewport.TotalLineCount()
+	if c.cursor+1 < len(c.starts) {
+		bottom = c.starts[c.cursor+1]
+	}
+	if bottom > c.viewport.YOffset+c.viewport.Height {
+		c.viewport.SetYOffset(bottom - c.viewport.Height)
+	}
+	if top < c.viewport.YOffset {
+		c.viewport.SetYOffset(top)
+	}
+}
+
 // View implements content.Content.
 func (c *ToolsContent) View() string {
 	c.mu.Lock()
@@ -64,34 +415,267 @@ func (c *ToolsContent) View() string {
 		return "No tool calls yet"
 	}
 
-	var parts []string
+	c.refresh()
+	if c.height <= 0 {
+		// Not sized yet, so show everything
+		body, _ := c.render()
+		return c.header() + "\n" + body
+	}
+	return c.header() + "\n" + c.viewport.View()
+}
+
+// SetTheme implements shell.Themed.
+func (c *ToolsContent) SetTheme(th theme.Theme) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = th
+	c.refresh()
+}
 
+// refresh re-renders the calls into the viewport.
+// Must be called with mutex held.
+func (c *ToolsContent) refresh() {
+	body, starts := c.render()
+	c.starts = starts
+	c.viewport.SetContent(body)
+}
+
+// header renders the summary line above the calls.
+// Must be called with mutex held.
+func (c *ToolsContent) header() string {
+	styles := c.theme.Styles()
+
+	running, failed := 0, 0
 	for _, item := range c.items {
-		var status string
-		if item.completed {
-			if item.success {
-				status = "✓"
-			} else {
-				status = "✗"
-			}
-		} else {
-			status = "⋯"
+		switch {
+		case !item.completed:
+			running++
+		case !item.success:
+			failed++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d calls", len(c.items))}
+	if running > 0 {
+		parts = append(parts, fmt.Sprintf("%d running", running))
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+	}
+	if c.status != ToolFilterAll {
+		parts = append(parts, "showing "+c.status.String())
+	}
+	if c.typing {
+		parts = append(parts, "/"+c.name+"▏")
+	} else if c.name != "" {
+		parts = append(parts, "/"+c.name)
+	}
+	line := styles.Title.Render("Tools") + " " + styles.Muted.Render(strings.Join(parts, " · "))
+
+	hint := "enter expand · f status · / name · y copy"
+	if c.flash != "" {
+		hint = c.flash
+	}
+	if c.width > 0 {
+		gap := c.width - lipgloss.Width(line) - lipgloss.Width(hint)
+		if gap < 2 {
+			return ansi.Truncate(line, c.width, "…")
+		}
+		line += strings.Repeat(" ", gap) + styles.Muted.Render(hint)
+	}
+	return line
+}
+
+// render renders the visible calls and the first line of each.
+// Must be called with mutex held.
+func (c *ToolsContent) render() (string, []int) {
+	styles := c.theme.Styles()
+	items := c.visible()
+	if len(items) == 0 {
+		return styles.Muted.Render("No tool calls match the filter"), nil
+	}
+
+	var lines []string
+	starts := make([]int, len(items))
+	for i, item := range items {
+		starts[i] = len(lines)
+		lines = append(lines, c.renderSummary(item, i == c.cursor))
+		if c.expanded[item.id] {
+			lines = append(lines, c.renderDetails(item)...)
+		}
+	}
+	return strings.Join(lines, "\n"), starts
+}
+
+// renderSummary renders the one-line summary of a call.
+// Must be called with mutex held.
+func (c *ToolsContent) renderSummary(item *toolItem, selected bool) string {
+	styles := c.theme.Styles()
+
+	cursor := "  "
+	name := styles.ListItem.Render(item.name)
+	if selected {
+		cursor = styles.ListItemSelected.Render("▸ ")
+		name = styles.ListItemSelected.Render(item.name)
+	}
+
+	var status, summary string
+	switch {
+	case !item.completed:
+		elapsed := time.Since(item.timestamp)
+		frame := toolsSpinner.Frames[int(elapsed/toolsSpinner.FPS)%len(toolsSpinner.Frames)]
+		status = styles.ToolExecuting.Render(frame)
+		summary = formatParams(item.params)
+	case item.success:
+		status = styles.ToolSuccess.Render("✓")
+		summary = firstLine(item.output)
+	default:
+		status = styles.ToolError.Render("✗")
+		summary = firstLine(item.output)
+	}
+
+	line := cursor + status + " " + name
+	duration := styles.Muted.Render(formatElapsed(item.elapsed()))
+	if summary != "" {
+		room := c.width - lipgloss.Width(line) - lipgloss.Width(duration) - 5
+		if c.width <= 0 {
+			room = 50
+		}
+		if room > 0 {
+			line += styles.Muted.Render(" → " + ansi.Truncate(summary, room, "…"))
 		}
+	}
+	return line + "  " + duration
+}
+
+// resultLine renders a finished call on one line, as printed into the
+// scrollback in inline mode: status, name, parameters and the first line of
+// output. Returns "" for unknown and unfinished calls.
+func (c *ToolsContent) resultLine(id string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	styles := c.theme.Styles()
 
-		line := fmt.Sprintf("%s %s", status, item.name)
-		if item.completed && item.output != "" {
-			// Truncate output for display
-			output := item.output
-			if len(output) > 50 {
-				output = output[:47] + "..."
+	for i := range c.items {
+		item := &c.items[i]
+		if item.id != id || !item.completed {
+			continue
+		}
+		status := styles.ToolSuccess.Render("✓")
+		if !item.success {
+			status = styles.ToolError.Render("✗")
+		}
+		line := status + " " + strings.TrimSpace(item.name+" "+formatParams(item.params))
+		if c.width > 0 {
+			line = ansi.Truncate(line, c.width*2/3, "…")
+		}
+		duration := styles.Muted.Render(formatElapsed(item.elapsed()))
+		if summary := firstLine(item.output); summary != "" {
+			room := c.width - lipgloss.Width(line) - lipgloss.Width(duration) - 5
+			if c.width <= 0 {
+				room = 50
 			}
-			line += fmt.Sprintf(" → %s", output)
+			if room > 0 {
+				line += styles.Muted.Render(" → " + ansi.Truncate(summary, room, "…"))
+			}
+		}
+		return line + "  " + duration
+	}
+	return ""
+}
+
+// renderDetails renders an expanded call: parameters, full output and
+// timing.
+// Must be called with mutex held.
+func (c *ToolsContent) renderDetails(item *toolItem) []string {
+	styles := c.theme.Styles()
+	const indent = "    "
+	width := c.width - len(indent)
+
+	wrap := func(s string) []string {
+		if width > 0 {
+			s = ansi.Wrap(s, width, "")
+		}
+		return strings.Split(s, "\n")
+	}
+	section := func(title string, body []string) []string {
+		out := []string{indent + styles.Subtitle.Render(title)}
+		for _, line := range body {
+			out = append(out, indent+line)
 		}
+		return out
+	}
+
+	var lines []string
+	if len(item.params) > 0 {
+		params, err := json.MarshalIndent(item.params, "", "  ")
+		if err != nil {
+			params = []byte(fmt.Sprint(item.params))
+		}
+		lines = append(lines, section("Params", wrap(string(params)))...)
+	}
+
+	switch {
+	case !item.completed:
+		lines = append(lines, section("Output", []string{styles.Muted.Render("running…")})...)
+	case item.output == "":
+		lines = append(lines, section("Output", []string{styles.Muted.Render("(no output)")})...)
+	default:
+		lines = append(lines, section("Output", wrap(item.output))...)
+	}
+
+	timing := fmt.Sprintf("started %s · took %s", item.timestamp.Format("15:04:05"), formatElapsed(item.elapsed()))
+	if !item.completed {
+		timing = fmt.Sprintf("started %s · running for %s", item.timestamp.Format("15:04:05"), formatElapsed(item.elapsed()))
+	}
+	return append(lines, indent+styles.Muted.Render(timing), "")
+}
+
+// elapsed returns how long the call ran, or has been running.
+func (t toolItem) elapsed() time.Duration {
+	if t.completed {
+		return t.finished.Sub(t.timestamp)
+	}
+	return time.Since(t.timestamp)
+}
+
+// formatParams renders call parameters on one line as key=value pairs.
+func formatParams(params map[string]any) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return strings.Join(parts, " ")
+}
 
-		parts = append(parts, line)
+// firstLine returns the first non-blank line of s, marking that more
+// follows.
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	line, rest, more := strings.Cut(s, "\n")
+	if more && strings.TrimSpace(rest) != "" {
+		return strings.TrimSpace(line) + " …"
 	}
+	return line
+}
 
-	return strings.Join(parts, "\n")
+// formatElapsed formats a duration for the Tools tab.
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "0s"
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
 }
 
 // Value implements content.Content.
@@ -110,22 +694,43 @@ func (c *ToolsContent) SetSize(width, height int) {
 	defer c.mu.Unlock()
 	c.width = width
 	c.height = height
+	c.viewport.Width = width
+	c.viewport.Height = max(height-1, 1) // Below the header row
+}
+
+// SetScrollLines sets how many lines a mouse wheel step scrolls.
+func (c *ToolsContent) SetScrollLines(lines int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.viewport.MouseWheelDelta = lines
 }
 
 // AddToolCall adds a tool call to the timeline.
 func (c *ToolsContent) AddToolCall(id, name string, params map[string]any) {
+	c.addToolCallAt(id, name, params, time.Now())
+}
+
+// addToolCallAt adds a tool call with an explicit timestamp, used when
+// restoring a saved session.
+func (c *ToolsContent) addToolCallAt(id, name string, params map[string]any, ts time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.items = append(c.items, toolItem{
 		id:        id,
 		name:      name,
 		params:    params,
-		timestamp: time.Now(),
+		timestamp: ts,
 	})
 }
 
 // AddToolResult adds a result to an existing tool call.
 func (c *ToolsContent) AddToolResult(id, output string, success bool) {
+	c.addToolResultAt(id, output, success, time.Now())
+}
+
+// addToolResultAt adds a result with an explicit completion time, used
+// when restoring a saved session.
+func (c *ToolsContent) addToolResultAt(id, output string, success bool, ts time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	for i := range c.items {
@@ -133,6 +738,7 @@ func (c *ToolsContent) AddToolResult(id, output string, success bool) {
 			c.items[i].output = output
 			c.items[i].success = success
 			c.items[i].completed = true
+			c.items[i].finished = ts
 			return
 		}
 	}
@@ -4,8 +4,12 @@ package tux
 import (
 	"strings"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestNewToolsContent(t *testing.T) {
@@ -61,8 +65,8 @@ func TestToolsContentPendingStatus(t *testing.T) {
 	tools.AddToolCall("tool-1", "read_file", map[string]any{"path": "/tmp/test.txt"})
 
 	view := tools.View()
-	if !strings.Contains(view, "⋯") {
-		t.Errorf("View should contain pending marker, got: %s", view)
+	if !strings.Contains(view, "1 running") || !strings.Contains(view, "path=/tmp/test.txt") {
+		t.Errorf("View should show the call as running with its params, got: %s", view)
 	}
 }
 
@@ -110,7 +114,174 @@ func TestToolsContentMultipleCalls(t *testing.T) {
 	if !strings.Contains(view, "✓") {
 		t.Errorf("View should contain success marker for completed tool, got: %s", view)
 	}
-	if !strings.Contains(view, "⋯") {
-		t.Errorf("View should contain pending marker for pending tool, got: %s", view)
+	if !strings.Contains(view, "1 running") {
+		t.Errorf("View should count the pending tool as running, got: %s", view)
 	}
 }
+
+// toolsWithCalls returns sized tools content with a succeeded, a failed
+// and a running call.
+func toolsWithCalls() *ToolsContent {
+	tools := NewToolsContent(theme.NewDraculaTheme())
+	tools.SetSize(80, 20)
+	tools.AddToolCall("tool-1", "read_file", map[string]any{"path": "/a"})
+	tools.AddToolResult("tool-1", "line one\nline two\nline three", true)
+	tools.AddToolCall("tool-2", "write_file", map[string]any{"path": "/b"})
+	tools.AddToolResult("tool-2", "permission denied", false)
+	tools.AddToolCall("tool-3", "run_command", map[string]any{"cmd": "make"})
+	return tools
+}
+
+func TestToolsContentTruncatesUTF8(t *testing.T) {
+	tools := NewToolsContent(theme.NewDraculaTheme())
+	tools.SetSize(40, 10)
+	tools.AddToolCall("tool-1", "read_file", nil)
+	tools.AddToolResult("tool-1", strings.Repeat("héllo wörld ", 20), true)
+
+	view := ansi.Strip(tools.View())
+	if !utf8.ValidString(view) {
+		t.Errorf("view contains invalid UTF-8: %q", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > 40 {
+			t.Errorf("line exceeds width (%d): %q", w, line)
+		}
+	}
+}
+
+func TestToolsContentExpand(t *testing.T) {
+	tools := toolsWithCalls()
+
+	tools.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view := ansi.Strip(tools.View())
+	for _, want := range []string{`"path": "/a"`, "line two", "line three", "took"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in expanded view\n%s", want, view)
+		}
+	}
+
+	// Enter again collapses
+	tools.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ansi.Strip(tools.View()); strings.Contains(view, "line three") {
+		t.Errorf("expected call to collapse\n%s", view)
+	}
+}
+
+func TestToolsContentCursor(t *testing.T) {
+	tools := toolsWithCalls()
+
+	tools.Update(shell.ActionMsg{Action: shell.ActionLineDown})
+	tools.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ansi.Strip(tools.View()); !strings.Contains(view, "▸ ✗ write_file") {
+		t.Errorf("expected cursor on the second call\n%s", view)
+	}
+
+	tools.Update(shell.ActionMsg{Action: shell.ActionScrollBottom})
+	tools.Update(shell.ActionMsg{Action: shell.ActionLineDown})
+	tools.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view := ansi.Strip(tools.View()); !strings.Contains(view, "running for") {
+		t.Errorf("expected the running call expanded\n%s", view)
+	}
+}
+
+func TestToolsContentFilters(t *testing.T) {
+	tools := toolsWithCalls()
+
+	// f cycles all -> running -> success -> failed
+	tools.Update(runes("f"))
+	view := ansi.Strip(tools.View())
+	if !strings.Contains(view, "run_command") || strings.Contains(view, "read_file") {
+		t.Errorf("expected only running calls\n%s", view)
+	}
+	tools.Update(runes("f"))
+	tools.Update(runes("f"))
+	view = ansi.Strip(tools.View())
+	if !strings.Contains(view, "write_file") || strings.Contains(view, "read_file") {
+		t.Errorf("expected only failed calls\n%s", view)
+	}
+	tools.Update(runes("f"))
+
+	// Name filter is typed after "/"
+	tools.Update(runes("/"))
+	if !tools.Typing() {
+		t.Fatal("expected / to start typing a filter")
+	}
+	tools.Update(runes("read"))
+	tools.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	view = ansi.Strip(tools.View())
+	if tools.Typing() || !strings.Contains(view, "read_file") || strings.Contains(view, "write_file") {
+		t.Errorf("expected only read_file after filtering\n%s", view)
+	}
+
+	// Esc while typing clears the filter
+	tools.Update(runes("/"))
+	tools.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if view := ansi.Strip(tools.View()); !strings.Contains(view, "write_file") {
+		t.Errorf("expected filter cleared\n%s", view)
+	}
+}
+
+func TestToolsContentCopy(t *testing.T) {
+	tools := toolsWithCalls()
+	var copied string
+	tools.clipboard = func(s string) error {
+		copied = s
+		return nil
+	}
+
+	tools.Update(runes("y"))
+	if copied != "line one\nline two\nline three" {
+		t.Errorf("expected full output copied, got %q", copied)
+	}
+	if view := ansi.Strip(tools.View()); !strings.Contains(view, "Copied output of read_file") {
+		t.Errorf("expected copy notice\n%s", view)
+	}
+}
+
+func TestToolsContentTicksWhileActive(t *testing.T) {
+	tools := toolsWithCalls()
+
+	if cmd := tools.OnActivate(); cmd == nil {
+		t.Fatal("expected activation to start ticking")
+	}
+	if _, cmd := tools.Update(toolsTickMsg{gen: tools.tick}); cmd == nil {
+		t.Error("expected tick to schedule the next one")
+	}
+	if _, cmd := tools.Update(toolsTickMsg{gen: tools.tick - 1}); cmd != nil {
+		t.Error("expected stale tick to be dropped")
+	}
+	tools.OnDeactivate()
+	if _, cmd := tools.Update(toolsTickMsg{gen: tools.tick}); cmd != nil {
+		t.Error("expected ticking to stop when the tab is hidden")
+	}
+}
+
+func TestToolsContentTicksOnlyWhilePending(t *testing.T) {
+	tools := toolsWithCalls()
+	tools.AddToolResult("tool-3", "ok", true)
+
+	if cmd := tools.OnActivate(); cmd != nil {
+		t.Error("expected no ticks without a pending call")
+	}
+	tools.AddToolCall("tool-4", "read_file", nil)
+	if cmd := tools.resumeTicks(); cmd == nil {
+		t.Fatal("expected a new call to start ticking")
+	}
+	if cmd := tools.resumeTicks(); cmd != nil {
+		t.Error("expected one tick chain at a time")
+	}
+
+	tools.AddToolResult("tool-4", "ok", true)
+	if _, cmd := tools.Update(toolsTickMsg{gen: tools.tick}); cmd != nil {
+		t.Error("expected ticking to stop once every call finished")
+	}
+	tools.AddToolCall("tool-5", "read_file", nil)
+	if cmd := tools.resumeTicks(); cmd == nil {
+		t.Error("expected ticking to resume for the next call")
+	}
+}
+
+// runes returns a key press typing s.
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
@@ -11,26 +11,49 @@ package tux
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
+	"github.com/2389-research/tux/agent"
 	"github.com/2389-research/tux/config"
 	"github.com/2389-research/tux/content"
+	"github.com/2389-research/tux/policy"
+	"github.com/2389-research/tux/session"
 	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 const Version = "0.1.0"
 
 // Agent is the interface that agent implementations must satisfy.
 // This includes orchestrator agents and any custom agent implementations.
+//
+// The App drives an agent one run at a time:
+//
+//   - Subscribe is called once per run, just before Run. The channel it
+//     returns carries only that run's events and is closed once the run
+//     is over; each call returns a new channel.
+//   - Run's context carries the run's ID (see RunIDFromContext). Events
+//     may be stamped with it; unstamped events are attributed to the run
+//     whose channel delivered them.
+//   - A run that finishes without error ends with an EventComplete.
+//   - When the context is cancelled, Run returns promptly and the channel
+//     is closed. Events sent after cancelling are not shown, but the App
+//     keeps reading them until Run returns, so sending never blocks.
+//   - Once Run returns, the App waits briefly for the channel to close,
+//     then ends the turn and stops reading it; events sent later are lost.
+//
+// tuxtest.AgentConformance checks an implementation against this contract.
 type Agent interface {
 	// Run starts the agent with the given prompt.
 	// It runs until completion or context cancellation.
 	Run(ctx context.Context, prompt string) error
 
-	// Subscribe returns a channel of events from the agent.
-	// The channel is closed when the agent completes.
+	// Subscribe returns a channel of events for the next run.
+	// The channel is closed when that run completes.
 	Subscribe() <-chan Event
 
 	// Cancel cancels the current agent run.
@@ -40,14 +63,37 @@ type Agent interface {
 // Event represents an event from the agent.
 type Event struct {
 	Type       EventType
-	Text       string                  // For EventText
+	RunID      uint64                  // Run the event belongs to; set by the App if the agent leaves it 0
+	Text       string                  // For EventText, EventThinking
 	ToolName   string                  // For EventToolCall, EventToolResult, EventApproval
 	ToolID     string                  // For EventToolCall, EventToolResult, EventApproval
 	ToolParams map[string]any          // For EventToolCall, EventApproval
+	Diff       string                  // For EventApproval - unified diff of the change, if the tool edits files
 	ToolOutput string                  // For EventToolResult
 	Success    bool                    // For EventToolResult
 	Error      error                   // For EventError
+	Usage      *TokenUsage             // For EventUsage, EventComplete
 	Response   chan ApprovalDecision   // For EventApproval - send decision here
+	EditResponse chan ApprovalResponse // For EventApproval - if set, the user may edit the parameters; the answer goes here instead of Response
+
+	preview *changePreview  // For EventApproval - file change, computed before the update loop
+	ctx     context.Context // For EventApproval - the run's context; the answer is dropped once it is done
+}
+
+// runIDKey is the context key for the current run's ID.
+type runIDKey struct{}
+
+// ContextWithRunID returns a context carrying a run ID, as passed to
+// Agent.Run.
+func ContextWithRunID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunIDFromContext returns the run ID carried by the context given to
+// Agent.Run. The bool is false if there is none.
+func RunIDFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(runIDKey{}).(uint64)
+	return id, ok
 }
 
 // EventType identifies the type of agent event.
@@ -55,6 +101,8 @@ type EventType string
 
 const (
 	EventText       EventType = "text"
+	EventThinking   EventType = "thinking"
+	EventUsage      EventType = "usage"
 	EventToolCall   EventType = "tool_call"
 	EventToolResult EventType = "tool_result"
 	EventComplete   EventType = "complete"
@@ -73,6 +121,14 @@ const (
 	DecisionNeverAllow  = shell.DecisionNeverAllow
 )
 
+// ApprovalResponse is a decision on an approval request, with the
+// parameters the user edited before approving. It is sent on
+// Event.EditResponse.
+type ApprovalResponse struct {
+	Decision ApprovalDecision
+	Params   map[string]any // Parameters to run the tool with; nil if unchanged
+}
+
 // TabDef defines a custom tab.
 type TabDef struct {
 	ID       string
@@ -92,14 +148,49 @@ type appConfig struct {
 	removedTabs      map[string]bool
 	helpCategories   []shell.Category
 	autocomplete     *shell.Autocomplete
+	completion       *config.AutocompleteConfig
 	suggestions      *shell.Suggestions
 	onQuickActions   func()
 	onClearChat      func()
 	onSave           func()
 	onToggleFavorite func()
+	// Backend agent loop
+	classifier       agent.Classifier
+	// Remembered approval decisions
+	policy *policy.Store
+	// Session persistence
+	sessionStore session.Store
+	sessionID    string
+	// Key bindings
+	keybindings *config.KeybindingsConfig
+	keyActions  []KeyAction
+	// Slash commands
+	commands []Command
+	// @path attachments
+	attachmentLimit int64
+	noAttachments   bool
+	// Mouse and modal backdrop
+	mouse *config.MouseConfig
+	modal *config.ModalConfig
+	// Status bar
+	statusBar      *config.StatusBarConfig
+	statusSegments []StatusSegment
+	// Bubble Tea program options
+	programOptions []tea.ProgramOption
 	// Input config
 	inputPrefix      string
 	inputPlaceholder string
+	input            *config.InputConfig
+	// Model for the status bar, context window and prices
+	model  string
+	models map[string]config.ModelConfig
+	// Inline rendering
+	display *config.DisplayConfig
+	inline  bool
+	// Headless runs
+	headless       bool
+	headlessFormat HeadlessFormat
+	headlessPrompt string
 }
 
 // defaultAppConfig returns the default configuration with Dracula theme
@@ -138,8 +229,8 @@ type HelpCategory = shell.Category
 // HelpBinding is a re-export of shell.Binding for API convenience.
 type HelpBinding = shell.Binding
 
-// WithHelpCategories sets the help overlay categories.
-// When set, pressing '?' shows the help overlay with these keybindings.
+// WithHelpCategories adds categories to the help overlay, shown after the
+// key bindings generated from the keymap (e.g. slash commands).
 func WithHelpCategories(categories ...HelpCategory) Option {
 	return func(c *appConfig) {
 		c.helpCategories = categories
@@ -155,6 +246,9 @@ type Completion = shell.Completion
 // CompletionProvider is a re-export of shell.CompletionProvider for API convenience.
 type CompletionProvider = shell.CompletionProvider
 
+// AsyncCompletionProvider is a re-export of shell.AsyncCompletionProvider for API convenience.
+type AsyncCompletionProvider = shell.AsyncCompletionProvider
+
 // ListItem is a re-export of shell.ListItem for API convenience.
 type ListItem = shell.ListItem
 
@@ -207,13 +301,107 @@ func WithConfig(cfg *Config) Option {
 	return func(c *appConfig) {
 		// Apply theme from config
 		c.theme = cfg.BuildTheme()
+		// Apply key bindings
+		keybindings := cfg.Keybindings
+		c.keybindings = &keybindings
+		// Apply mouse settings
+		mouse := cfg.Mouse
+		c.mouse = &mouse
+		// Apply modal backdrop
+		modal := cfg.Modal
+		c.modal = &modal
+		// Apply display mode
+		display := cfg.Display
+		c.display = &display
+		// Apply autocomplete settings
+		completion := cfg.Autocomplete
+		c.completion = &completion
+		// Apply status bar layout
+		statusBar := cfg.StatusBar
+		c.statusBar = &statusBar
 		// Apply input config
+		input := cfg.Input
+		c.input = &input
 		if cfg.Input.Prefix != "" {
 			c.inputPrefix = cfg.Input.Prefix
 		}
 		if cfg.Input.Placeholder != "" {
 			c.inputPlaceholder = cfg.Input.Placeholder
 		}
+		// Apply model context windows and prices
+		for name, m := range cfg.Models {
+			if c.models == nil {
+				c.models = make(map[string]config.ModelConfig)
+			}
+			c.models[name] = m
+		}
+	}
+}
+
+// KeyAction is a re-export of shell.KeyAction for API convenience.
+type KeyAction = shell.KeyAction
+
+// WithKeyAction registers an app-defined action bound to keys. Users can
+// rebind it with [keybindings.custom] in their config, using the action's
+// Name. It is listed in the help overlay.
+func WithKeyAction(action KeyAction) Option {
+	return func(c *appConfig) {
+		c.keyActions = append(c.keyActions, action)
+	}
+}
+
+// Command is a re-export of shell.Command for API convenience.
+type Command = shell.Command
+
+// CommandArg is a re-export of shell.CommandArg for API convenience.
+type CommandArg = shell.CommandArg
+
+// Argument types of a CommandArg, re-exported from the shell package.
+const (
+	ArgString = shell.ArgString
+	ArgInt    = shell.ArgInt
+	ArgBool   = shell.ArgBool
+)
+
+// WithCommand registers a slash command: typing "/name args" runs its
+// Handler instead of sending the text to the agent. Commands are completed
+// with Tab and listed in the help overlay, alongside the built-in /help,
+// /theme, /quit, /errors, and /clear and /save when those are set up. A
+// command with a built-in's name replaces it. Start a prompt with "//" to
+// send it with a leading slash.
+func WithCommand(cmd Command) Option {
+	return func(c *appConfig) {
+		c.commands = append(c.commands, cmd)
+	}
+}
+
+// StatusSegment is a re-export of shell.StatusSegment for API convenience.
+type StatusSegment = shell.StatusSegment
+
+// WithAttachmentLimit sets the largest file, in bytes, attached to a prompt
+// by an @path mention. Sending a prompt that mentions a larger file asks
+// whether to send it without that file. The default is
+// DefaultAttachmentLimit.
+func WithAttachmentLimit(n int64) Option {
+	return func(c *appConfig) {
+		c.attachmentLimit = n
+	}
+}
+
+// WithoutAttachments turns @path mentions off: they are not completed or
+// attached, and reach the agent as plain text.
+func WithoutAttachments() Option {
+	return func(c *appConfig) {
+		c.noAttachments = true
+	}
+}
+
+// WithStatusSegment adds an app-defined status bar segment, such as a git
+// branch or running cost. Users can move it and change its priority with
+// [statusbar.custom.<name>] in their config.
+func WithStatusSegment(seg StatusSegment) Option {
+	return func(c *appConfig) {
+		c.statusSegments = append(c.statusSegments, seg)
 	}
 }
 
@@ -231,6 +419,25 @@ func WithInputPlaceholder(placeholder string) Option {
 	}
 }
 
+// WithProgramOptions adds Bubble Tea program options used by Run, e.g.
+// tea.WithOutput to render somewhere other than stdout.
+func WithProgramOptions(opts ...tea.ProgramOption) Option {
+	return func(c *appConfig) {
+		c.programOptions = append(c.programOptions, opts...)
+	}
+}
+
+// WithInline draws the App inline instead of on the alternate screen, as
+// the [display] config's inline setting does: finished messages and tool
+// results are printed into the terminal's scrollback, above a live region
+// holding the streaming answer, input and status bar. Modals still take the
+// whole window while open.
+func WithInline() Option {
+	return func(c *appConfig) {
+		c.inline = true
+	}
+}
+
 // NewAutocomplete creates a new autocomplete component.
 func NewAutocomplete() *Autocomplete {
 	return shell.NewAutocomplete()
@@ -241,6 +448,14 @@ func NewCommandProvider(commands []Completion) *shell.CommandProvider {
 	return shell.NewCommandProvider(commands)
 }
 
+// NewFileProvider creates a filesystem path completion provider resolving
+// relative paths against root, or the working directory if root is "".
+// Register it as "file" to complete words starting "./", "../", "~/" or
+// "/" with Tab.
+func NewFileProvider(root string) *shell.FileProvider {
+	return shell.NewFileProvider(root)
+}
+
 // NewHistoryProvider creates a history completion provider.
 func NewHistoryProvider(history []string) *shell.HistoryProvider {
 	return shell.NewHistoryProvider(history)
@@ -291,6 +506,45 @@ func WithToggleFavorite(fn func()) Option {
 	}
 }
 
+// WithClassifier sets how tool calls are pre-sorted when the App is driven
+// by an agent.Backend (see NewFromBackend). Defaults to asking for approval
+// on every tool call.
+func WithClassifier(fn agent.Classifier) Option {
+	return func(c *appConfig) {
+		c.classifier = fn
+	}
+}
+
+// WithPolicy sets the store that remembers "Always Allow" and "Never Allow"
+// decisions. Tool calls matching a stored rule are resolved without showing
+// the approval modal. Load a persistent store with
+// policy.Load(policy.DefaultPath(appName)).
+func WithPolicy(store *policy.Store) Option {
+	return func(c *appConfig) {
+		c.policy = store
+	}
+}
+
+// WithSessionStore sets where conversation transcripts are persisted.
+// When set without WithSession, recording starts on the first save (Ctrl+S)
+// under a newly generated session ID.
+func WithSessionStore(store SessionStore) Option {
+	return func(c *appConfig) {
+		c.sessionStore = store
+	}
+}
+
+// WithSession resumes (or starts) the session with the given ID. Its
+// transcript is loaded into the Chat and Tools tabs and input history on
+// startup, and new messages, tool calls and errors are appended as they
+// happen. Without WithSessionStore, sessions are kept as JSONL files under
+// $XDG_DATA_HOME/tux/sessions.
+func WithSession(id string) Option {
+	return func(c *appConfig) {
+		c.sessionID = id
+	}
+}
+
 // App is the main agent TUI application.
 type App struct {
 	agent  Agent
@@ -302,13 +556,41 @@ type App struct {
 	tools *ToolsContent
 
 	// Runtime state
-	mu     sync.Mutex
-	ctx    context.Context
-	cancel context.CancelFunc
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	runID   uint64 // Current run; events from other runs are dropped
+	running bool   // Whether the current run's turn is still going
+
+	// Prompts submitted during a run, sent together when it ends, and
+	// the files they attach. Only touched inside the update loop.
+	queued            []string
+	queuedAttachments []Attachment
+
+	// Resolves @path mentions, nil if attachments are off
+	mentions        *shell.FileProvider
+	attachmentLimit int64
+
+	// Approval requests waiting on the user, nil when none are. Only
+	// touched inside the update loop.
+	approvals *approvalQueue
 
 	// Error tracking
 	errors      []error
 	errorsInRun bool
+
+	// Status bar contents, guarded by mu
+	status shell.Status
+
+	// Token usage, guarded by mu
+	usage usageTracker
+
+	// Session transcript, guarded by mu
+	sessionID string
+	records   []session.Record
+
+	// Session and permission writes, in order
+	writes writeQueue
 }
 
 // New creates a new App with the given agent and options.
@@ -337,14 +619,41 @@ func New(agent Agent, opts ...Option) *App {
 	tools := NewToolsContent(cfg.theme)
 
 	app := &App{
-		agent:  agent,
-		config: cfg,
-		chat:   chat,
-		tools:  tools,
+		agent:     agent,
+		config:    cfg,
+		chat:      chat,
+		tools:     tools,
+		sessionID: cfg.sessionID,
+		status:    shell.Status{Model: cfg.model},
+		usage:     usageTracker{model: cfg.model},
+	}
+
+	// Default session store and Ctrl+S behaviour
+	if cfg.sessionID != "" && cfg.sessionStore == nil {
+		cfg.sessionStore = session.NewFileStore(session.DefaultDir("tux"))
 	}
+	if cfg.sessionStore != nil && cfg.onSave == nil {
+		cfg.onSave = func() {
+			// Save off the UI thread
+			go app.saveSessionFromShortcut()
+		}
+	}
+
+	// Wire input submission to agent, and agent events to the update loop
+	shellCfg.OnInputSubmit = app.submitPrompt
+	shellCfg.OnInterrupt = app.interrupt
+	shellCfg.OnMessage = app.handleMessage
+	shellCfg.ProgramOptions = cfg.programOptions
 
-	// Wire input submission to agent
-	shellCfg.OnInputSubmit = app.submitInput
+	// Wire @path mentions
+	app.attachmentLimit = cfg.attachmentLimit
+	if app.attachmentLimit <= 0 {
+		app.attachmentLimit = DefaultAttachmentLimit
+	}
+	if !cfg.noAttachments {
+		app.mentions = shell.NewFileProvider("")
+		shellCfg.Mentions = app.mentions
+	}
 
 	// Wire history provider
 	shellCfg.HistoryProvider = func() []string {
@@ -361,17 +670,61 @@ func New(agent Agent, opts ...Option) *App {
 		if len(errs) > 0 {
 			modal := shell.NewErrorModal(shell.ErrorModalConfig{
 				Errors: errs,
-				Theme:  cfg.theme,
+				Theme:  app.shell.Theme(),
 			})
 			app.shell.PushModal(modal)
 		}
 	}
 
+	// Wire key bindings
+	keymap := shell.DefaultKeymap()
+	if cfg.keybindings != nil {
+		keymap.ApplyConfig(*cfg.keybindings)
+	}
+	shellCfg.Keymap = keymap
+	shellCfg.KeyActions = append(cfg.keyActions, KeyAction{
+		Name:        "toggle_thinking",
+		Description: "Show or hide reasoning",
+		Keys:        []string{"ctrl+t"},
+		Handler:     chat.ToggleThinking,
+	}, KeyAction{
+		Name:        "usage",
+		Description: "Show token usage and cost",
+		Keys:        []string{"ctrl+g"},
+		Handler:     app.showUsage,
+	})
+
+	// Wire mouse and modal backdrop
+	shellCfg.Mouse = cfg.mouse
+	shellCfg.Modal = cfg.modal
+
+	// Wire display mode
+	shellCfg.Display = cfg.display
+	if cfg.inline {
+		display := config.DisplayConfig{}
+		if cfg.display != nil {
+			display = *cfg.display
+		}
+		display.Inline = true
+		shellCfg.Display = &display
+	}
+
+	// Wire prompt editor settings
+	shellCfg.Input = cfg.input
+
+	// Wire status bar
+	shellCfg.StatusSegments = cfg.statusSegments
+	shellCfg.StatusBar = cfg.statusBar
+
+	// Wire slash commands
+	shellCfg.Commands = shell.NewCommands(cfg.commands...)
+
 	// Wire help categories
 	shellCfg.HelpCategories = cfg.helpCategories
 
 	// Wire autocomplete
 	shellCfg.Autocomplete = cfg.autocomplete
+	shellCfg.Completion = cfg.completion
 
 	// Wire suggestions
 	shellCfg.Suggestions = cfg.suggestions
@@ -386,6 +739,9 @@ func New(agent Agent, opts ...Option) *App {
 
 	sh := shell.New(cfg.theme, shellCfg)
 	app.shell = sh
+	if sh.Inline() {
+		chat.setPrinter(sh.Println)
+	}
 
 	// Add default tabs (unless removed)
 	if !cfg.removedTabs["chat"] {
@@ -415,126 +771,325 @@ func New(agent Agent, opts ...Option) *App {
 		})
 	}
 
+	app.restoreSession()
+
 	return app
 }
 
-// Run starts the App.
+// Run starts the App. When headless (see WithHeadless) it sends one prompt
+// and returns once the agent finishes, with the first error it reported.
 func (a *App) Run() error {
+	if a.isHeadless() {
+		return a.runHeadless()
+	}
+	defer a.flushWrites()
 	return a.shell.Run()
 }
 
-// submitInput starts an agent run with the given prompt.
-func (a *App) submitInput(prompt string) {
+// Quit stops a running App. A headless run is cancelled.
+func (a *App) Quit() {
+	a.shell.Quit()
+	if a.isHeadless() {
+		a.cancelRun()
+	}
+}
+
+// submitPrompt sends a prompt typed by the user with the files it
+// mentions, first asking about any over the size limit.
+func (a *App) submitPrompt(prompt string) {
+	attached, tooLarge := a.resolveAttachments(prompt)
+	if len(tooLarge) > 0 {
+		a.confirmAttachments(prompt, attached, tooLarge)
+		return
+	}
+	a.sendPrompt(prompt, attached)
+}
+
+// sendPrompt sends a prompt and its attachments. While a run is going it
+// is queued instead, and sent when that run's turn ends.
+func (a *App) sendPrompt(prompt string, attachments []Attachment) {
+	if a.isRunning() {
+		a.queued = append(a.queued, prompt)
+		a.queuedAttachments = append(a.queuedAttachments, attachments...)
+		a.shell.SetQueued(a.queued)
+		return
+	}
+	a.submitInput(prompt, attachments...)
+}
+
+// submitInput starts an agent run with the given prompt and attachments.
+func (a *App) submitInput(prompt string, attachments ...Attachment) {
+	// Close off an interrupted answer before the new prompt
+	a.finishPartialAnswer("[cancelled]")
+
 	// Add user message to chat
-	a.chat.AddUserMessage(prompt)
+	refs := attachmentRecords(attachments)
+	a.chat.addUserMessage(prompt, attachmentRefs(refs))
+	a.record(session.Record{Type: session.RecordUser, Content: prompt, Attachments: refs})
+	a.startTurnUsage(prompt)
 
 	// Cancel any existing run before starting a new one
 	a.mu.Lock()
 	if a.cancel != nil {
 		a.cancel()
 	}
-	a.ctx, a.cancel = context.WithCancel(context.Background())
+	a.runID++
+	id := a.runID
+	a.ctx, a.cancel = context.WithCancel(ContextWithRunID(context.Background(), id))
 	ctx := a.ctx
+	a.running = true
+	a.errorsInRun = false
 	a.mu.Unlock()
+	a.dropExpiredApprovals()
 
-	// Subscribe to events
+	// Subscribe to this run's events
 	events := a.agent.Subscribe()
 
 	// Start streaming display
 	a.shell.Streaming().Start()
 
-	// Run agent in goroutine
+	// Run agent in goroutine, forwarding its events to the update loop
+	// until the run ends
+	finished := make(chan error, 1)
+	go a.pumpEvents(ctx, id, events, finished)
 	go func() {
-		// Start event processing
-		go a.processEvents(events)
-
-		// Run agent
-		if err := a.agent.Run(ctx, prompt); err != nil {
-			// Use local ctx (not a.ctx) to check if THIS run was cancelled
-			if ctx.Err() == nil {
-				// Not cancelled, real error
-				a.processEvent(Event{Type: EventError, Error: err})
-			}
-		}
+		finished <- a.runAgent(ctx, prompt, attachments)
 	}()
 }
 
-// processEvents reads events from the channel and routes them.
-func (a *App) processEvents(events <-chan Event) {
-	for event := range events {
-		a.processEvent(event)
+// runDone handles the end of a run, after all its events: it reports the
+// error Run returned, unless the run already reported it, and ends the
+// turn.
+func (a *App) runDone(id uint64, err error) {
+	current, cancelled := a.runState(Event{RunID: id})
+	if !current || cancelled {
+		return
+	}
+	if err != nil && !a.reported(err) {
+		a.processEvent(Event{Type: EventError, RunID: id, Error: err})
+	}
+	a.endTurn(id)
+}
+
+// endTurn marks a run's turn as over and sends any prompts queued during
+// it. A zero id means the current run.
+func (a *App) endTurn(id uint64) {
+	a.mu.Lock()
+	if id == 0 {
+		id = a.runID
+	}
+	ended := id == a.runID && a.running
+	a.running = false
+	a.mu.Unlock()
+	a.dropExpiredApprovals()
+	if !ended {
+		return
+	}
+
+	a.shell.Streaming().End()
+	a.finishTurnUsage()
+	a.sendQueued()
+}
+
+// sendQueued sends the prompts queued during the last run as one prompt.
+func (a *App) sendQueued() {
+	if len(a.queued) == 0 {
+		return
+	}
+	prompt := strings.Join(a.queued, "\n\n")
+	attachments := a.queuedAttachments
+	a.queued, a.queuedAttachments = nil, nil
+	a.shell.SetQueued(nil)
+	a.submitInput(prompt, attachments...)
+}
+
+// interrupt cancels the running turn from the keyboard, marking its
+// partial answer as interrupted.
+func (a *App) interrupt() {
+	if !a.isRunning() {
+		return
+	}
+	a.cancelRun()
+	a.dropExpiredApprovals()
+	a.finishPartialAnswer("[interrupted]")
+	a.finishTurnUsage()
+	a.sendQueued()
+}
+
+// runState reports whether an event belongs to the current run, and
+// whether that run was cancelled. Events without a run ID are not tied to
+// a run and always apply.
+func (a *App) runState(event Event) (current, cancelled bool) {
+	if event.RunID == 0 {
+		return true, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if event.RunID != a.runID {
+		return false, false
+	}
+	return true, a.ctx != nil && a.ctx.Err() != nil
+}
+
+// finishPartialAnswer closes off an answer cut short by cancelling its run,
+// ending it with mark.
+func (a *App) finishPartialAnswer(mark string) {
+	if a.chat.pendingText() != "" || a.chat.pendingThinking() != "" {
+		a.chat.AppendText("\n\n" + mark)
+		a.recordAnswer()
+		a.chat.FinishAssistantMessage()
+	}
+	a.shell.Streaming().End()
+}
+
+// recordAnswer records the assistant message being streamed, with its
+// reasoning.
+func (a *App) recordAnswer() {
+	text, thinking := a.chat.pendingText(), a.chat.pendingThinking()
+	if text != "" || thinking != "" {
+		a.record(session.Record{Type: session.RecordAssistant, Content: text, Thinking: thinking})
 	}
 }
 
 // processEvent routes an agent event to the appropriate content.
-// This is called for each event received from the agent's event channel.
+// It mutates UI state, so it runs inside the update loop; events from
+// other goroutines arrive through Send.
 func (a *App) processEvent(event Event) {
+	// Drop late events of superseded and cancelled runs
+	current, cancelled := a.runState(event)
+	if !current {
+		return
+	}
+	if cancelled {
+		a.finishPartialAnswer("[cancelled]")
+		return
+	}
+
 	streaming := a.shell.Streaming()
 
 	switch event.Type {
 	case EventText:
 		a.chat.AppendText(event.Text)
+		streaming.SetThinking(false)
 		streaming.AppendToken(event.Text)
 
+	case EventThinking:
+		a.chat.AppendThinking(event.Text)
+		streaming.SetThinking(true)
+
 	case EventToolCall:
+		if a.shell.Inline() {
+			// Print the answer so far above the call's result
+			a.recordAnswer()
+			a.chat.FinishAssistantMessage()
+		}
 		a.tools.AddToolCall(event.ToolID, event.ToolName, event.ToolParams)
 		streaming.StartToolCall(event.ToolID, event.ToolName)
+		a.record(session.Record{
+			Type:     session.RecordToolCall,
+			ToolID:   event.ToolID,
+			ToolName: event.ToolName,
+			Params:   event.ToolParams,
+		})
 
 	case EventToolResult:
 		a.tools.AddToolResult(event.ToolID, event.ToolOutput, event.Success)
 		streaming.EndToolCall(event.ToolID)
+		if line := a.tools.resultLine(event.ToolID); line != "" {
+			a.shell.Println(line)
+		}
+		a.record(session.Record{
+			Type:    session.RecordToolResult,
+			ToolID:  event.ToolID,
+			Content: event.ToolOutput,
+			Success: event.Success,
+		})
+
+	case EventUsage:
+		if event.Usage != nil {
+			a.addUsage(*event.Usage)
+		}
 
 	case EventComplete:
+		a.recordAnswer()
 		a.chat.FinishAssistantMessage()
 		streaming.End()
 		a.mu.Lock()
 		// Clear errors only if no errors in this run
-		if !a.errorsInRun {
+		clearErrors := !a.errorsInRun
+		if clearErrors {
 			a.errors = nil
-			a.shell.SetStatus(shell.Status{}) // Clear error indicator
 		}
 		a.errorsInRun = false // Reset for next run
 		a.mu.Unlock()
+		if clearErrors {
+			a.UpdateStatus(func(st *Status) { st.ErrorText, st.ErrorCount = "", 0 })
+		}
+		if event.Usage != nil {
+			a.completeUsage(*event.Usage)
+		}
+		a.finishTurnUsage()
+		a.endTurn(event.RunID)
 
 	case EventError:
-		a.mu.Lock()
 		// Handle nil error defensively
 		err := event.Error
 		if err == nil {
 			err = fmt.Errorf("unknown error")
 		}
-		a.errors = append(a.errors, err)
-		a.errorsInRun = true
-		errCount := len(a.errors)
-		errText := a.errors[0].Error()
-		a.mu.Unlock()
-		// Update status bar outside mutex
-		a.shell.SetStatus(shell.Status{
-			ErrorText:  errText,
-			ErrorCount: errCount,
-		})
+		a.addError(err)
+		a.record(session.Record{Type: session.RecordError, Content: err.Error()})
 
 	case EventApproval:
-		modal := shell.NewApprovalModal(shell.ApprovalModalConfig{
-			Tool: shell.ToolInfo{
-				ID:     event.ToolID,
-				Name:   event.ToolName,
-				Params: event.ToolParams,
-			},
-			OnDecision: func(decision shell.ApprovalDecision) {
-				if event.Response != nil {
-					// Send asynchronously to avoid blocking UI thread
-					go func(d shell.ApprovalDecision) {
-						event.Response <- d
-					}(decision)
-				}
-			},
-		})
-		a.shell.PushModal(modal)
+		// Resolve from remembered decisions without asking
+		if d, ok := a.policyDecision(event); ok {
+			respondApproval(event, ApprovalResponse{Decision: d})
+			break
+		}
+
+		a.askApproval(event)
+	}
+}
+
+// reported reports whether err is already in the error list.
+func (a *App) reported(err error) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, e := range a.errors {
+		if errors.Is(e, err) {
+			return true
+		}
 	}
+	return false
+}
+
+// addError records an error for the status bar and error modal.
+func (a *App) addError(err error) {
+	a.mu.Lock()
+	a.errors = append(a.errors, err)
+	a.errorsInRun = true
+	errCount := len(a.errors)
+	errText := a.errors[0].Error()
+	a.mu.Unlock()
+	// Update status bar outside mutex
+	a.UpdateStatus(func(st *Status) {
+		st.ErrorText = errText
+		st.ErrorCount = errCount
+	})
+}
+
+// Status is a re-export of shell.Status for API convenience.
+type Status = shell.Status
 
-	// Trigger UI refresh after external state change
-	a.shell.Send(shell.RefreshMsg{})
+// UpdateStatus changes the status bar: fn edits the current status in
+// place. Like other App methods that change the UI, call it from inside the
+// update loop (e.g. a key action handler), not from other goroutines.
+func (a *App) UpdateStatus(fn func(*Status)) {
+	a.mu.Lock()
+	fn(&a.status)
+	status := a.status
+	a.mu.Unlock()
+	a.shell.SetStatus(status)
 }
 
 // cancelRun cancels the current agent run.
@@ -546,6 +1101,7 @@ func (a *App) cancelRun() {
 		a.cancel()
 		a.cancel = nil
 	}
+	a.running = false
 	a.mu.Unlock()
 
 	// Call agent.Cancel() outside the mutex to avoid blocking while holding lock
@@ -555,12 +1111,12 @@ func (a *App) cancelRun() {
 }
 
 // isRunning returns true if an agent run is in progress.
-// Thread-safe: acquires mutex before accessing ctx.
+// Thread-safe: acquires mutex before accessing run state.
 func (a *App) isRunning() bool {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	return a.ctx != nil && a.ctx.Err() == nil
+	return a.running
 }
 
 // ClearChat clears all messages from the chat display.
@@ -4,11 +4,14 @@ package tux
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestFullConversationFlow(t *testing.T) {
@@ -146,3 +149,100 @@ func TestConversationWithError(t *testing.T) {
 	// Error display will be implemented in future work
 	_ = app.chat.View()
 }
+
+// stressAgent streams a long answer with interleaved tool calls and
+// approvals. It answers each approval by pressing "y" until the decision
+// arrives, like a user would.
+type stressAgent struct {
+	app       *App
+	events    chan Event
+	tokens    int
+	decisions int
+}
+
+func (a *stressAgent) Run(ctx context.Context, prompt string) error {
+	defer close(a.events)
+	for i := 0; i < a.tokens; i++ {
+		a.events <- Event{Type: EventText, Text: "tok "}
+		if i%20 == 19 {
+			a.events <- Event{Type: EventText, Text: "\n\n"}
+		}
+		if i%1000 == 999 {
+			id := fmt.Sprintf("tool-%d", i)
+			a.events <- Event{Type: EventToolCall, ToolID: id, ToolName: "read_file", ToolParams: map[string]any{"n": i}}
+			a.events <- Event{Type: EventToolResult, ToolID: id, ToolOutput: "ok", Success: true}
+		}
+		if i%20000 == 19999 {
+			a.approve(i)
+		}
+	}
+	a.events <- Event{Type: EventText, Text: "END"}
+	a.events <- Event{Type: EventComplete}
+	return nil
+}
+
+// approve asks for approval and waits for the decision.
+func (a *stressAgent) approve(i int) {
+	response := make(chan ApprovalDecision, 1)
+	a.events <- Event{Type: EventApproval, ToolID: fmt.Sprintf("approve-%d", i), ToolName: "write_file", Response: response}
+	for {
+		select {
+		case <-response:
+			a.decisions++
+			return
+		case <-time.After(5 * time.Millisecond):
+			a.app.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		}
+	}
+}
+
+func (a *stressAgent) Subscribe() <-chan Event { return a.events }
+
+func (a *stressAgent) Cancel() {}
+
+func TestStressEventsThroughUpdateLoop(t *testing.T) {
+	agent := &stressAgent{events: make(chan Event, 256), tokens: 100_000}
+	app := New(agent, WithProgramOptions(tea.WithInput(nil), tea.WithOutput(io.Discard)))
+	agent.app = app
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	for !app.shell.Running() {
+		time.Sleep(time.Millisecond)
+	}
+	app.Send(tea.WindowSizeMsg{Width: 80, Height: 24})
+	app.Send(shell.InputSubmitMsg{Value: "go"})
+
+	// The answer is finished once the complete event has been applied
+	deadline := time.Now().Add(time.Minute)
+	var answer string
+	for answer == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the run to complete")
+		}
+		time.Sleep(10 * time.Millisecond)
+		for _, msg := range app.chat.Value().([]chatMessage) {
+			if msg.role == "assistant" {
+				answer = msg.content
+			}
+		}
+	}
+
+	app.Quit()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if got := strings.Count(answer, "tok "); got != agent.tokens {
+		t.Errorf("expected %d tokens in the answer, got %d", agent.tokens, got)
+	}
+	if !strings.HasSuffix(answer, "END") {
+		t.Error("expected tokens in order, ending with END")
+	}
+	if got := len(app.tools.Value().([]toolItem)); got != agent.tokens/1000 {
+		t.Errorf("expected %d tool calls, got %d", agent.tokens/1000, got)
+	}
+	if agent.decisions != agent.tokens/20000 {
+		t.Errorf("expected %d approvals answered, got %d", agent.tokens/20000, agent.decisions)
+	}
+}
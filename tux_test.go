@@ -3,13 +3,17 @@ package tux
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/shell"
 	"github.com/2389-research/tux/theme"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // mockAgent implements Agent for testing
@@ -652,3 +656,322 @@ func TestFullApprovalFlow(t *testing.T) {
 		t.Error("denied tool should show failure marker")
 	}
 }
+
+func TestAppAppliesConfigKeybindings(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Keybindings.Help = []string{"f2"}
+	cfg.Keybindings.Custom = map[string][]string{"deploy": {"ctrl+y"}}
+
+	deployed := 0
+	app := New(&mockAgent{}, WithConfig(cfg), WithKeyAction(KeyAction{
+		Name:        "deploy",
+		Description: "Deploy",
+		Keys:        []string{"ctrl+t"},
+		Handler:     func() { deployed++ },
+	}))
+
+	km := app.shell.Keymap()
+	if keys := km.Keys(shell.ActionHelp); len(keys) != 1 || keys[0] != "f2" {
+		t.Errorf("expected help bound to f2, got %v", keys)
+	}
+
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if deployed != 1 {
+		t.Errorf("expected configured key to run the action, got %d runs", deployed)
+	}
+	app.shell.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if deployed != 1 {
+		t.Error("expected default key to be replaced by the configured one")
+	}
+}
+
+func TestAppStatusSegment(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StatusBar.Custom = map[string]config.CustomSection{"branch": {Position: 0}}
+
+	app := New(&mockAgent{}, WithConfig(cfg), WithStatusSegment(StatusSegment{
+		Name:   "branch",
+		Render: func(shell.Status) string { return "main" },
+	}))
+
+	order := app.shell.StatusBar().Order()
+	if len(order) == 0 || order[0] != "branch" {
+		t.Errorf("expected branch segment first, got %v", order)
+	}
+}
+
+// startRun makes id the app's current run, as submitInput does.
+func startRun(app *App, id uint64) context.CancelFunc {
+	app.runID = id
+	app.ctx, app.cancel = context.WithCancel(ContextWithRunID(context.Background(), id))
+	return app.cancel
+}
+
+func TestAppDropsSupersededRunEvents(t *testing.T) {
+	app := New(&mockAgent{})
+	startRun(app, 2)
+
+	app.processEvent(Event{Type: EventText, RunID: 1, Text: "late"})
+	app.processEvent(Event{Type: EventText, RunID: 2, Text: "now"})
+
+	if got := app.chat.pendingText(); got != "now" {
+		t.Errorf("expected only the current run's text, got %q", got)
+	}
+}
+
+func TestAppMarksCancelledRun(t *testing.T) {
+	app := New(&mockAgent{})
+	cancel := startRun(app, 1)
+
+	app.processEvent(Event{Type: EventText, RunID: 1, Text: "partial"})
+	cancel()
+	app.processEvent(Event{Type: EventText, RunID: 1, Text: " more"})
+
+	if app.chat.pendingText() != "" {
+		t.Errorf("expected the cancelled answer to be finished, got pending %q", app.chat.pendingText())
+	}
+	if n := len(app.chat.messages); n != 1 || app.chat.messages[0].content != "partial\n\n[cancelled]" {
+		t.Errorf("expected one message marked cancelled, got %+v", app.chat.messages)
+	}
+
+	// Errors without a run ID are still shown
+	app.processEvent(Event{Type: EventError, Error: errors.New("disk full")})
+	if len(app.errors) != 1 {
+		t.Errorf("expected app-level error to be recorded, got %d", len(app.errors))
+	}
+}
+
+func TestPumpEventsStampsRunID(t *testing.T) {
+	app := New(&mockAgent{})
+	startRun(app, 5)
+
+	events := make(chan Event, 2)
+	events <- Event{Type: EventText, Text: "hi"}
+	events <- Event{Type: EventText, RunID: 4, Text: " stale"}
+	close(events)
+	finished := make(chan error, 1)
+	finished <- nil
+	app.pumpEvents(app.ctx, 5, events, finished)
+
+	if got := app.chat.pendingText(); got != "hi" {
+		t.Errorf("expected unstamped text attributed to run 5, got %q", got)
+	}
+}
+
+func TestPumpEventsEndsTurnWithChannelOpen(t *testing.T) {
+	app := New(&mockAgent{})
+	startRun(app, 5)
+	app.running = true
+
+	// The agent never closes its channel
+	events := make(chan Event, 1)
+	events <- Event{Type: EventText, Text: "done"}
+	finished := make(chan error, 1)
+	finished <- nil
+
+	pumped := make(chan struct{})
+	go func() {
+		app.pumpEvents(app.ctx, 5, events, finished)
+		close(pumped)
+	}()
+	select {
+	case <-pumped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the pump to stop shortly after Run returned")
+	}
+	if app.isRunning() {
+		t.Error("expected the turn ended")
+	}
+	if got := app.chat.pendingText(); got != "done" {
+		t.Errorf("expected the drained text shown, got %q", got)
+	}
+}
+
+func TestPumpEventsDrainsCancelledRun(t *testing.T) {
+	app := New(&mockAgent{})
+	cancel := startRun(app, 5)
+	cancel()
+
+	agent := newLingeringAgent()
+	ctx, stop := context.WithCancel(context.Background())
+	finished := make(chan error, 1)
+	returned := make(chan struct{})
+	go func() {
+		finished <- agent.Run(ctx, "go")
+		close(returned)
+	}()
+	<-agent.started
+	stop()
+
+	go app.pumpEvents(app.ctx, 5, agent.events, finished)
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("expected the pump to take the late events until Run returned")
+	}
+}
+
+// steerAgent records prompts and runs until cancelled.
+type steerAgent struct {
+	prompts   chan string
+	cancelled chan struct{}
+}
+
+func newSteerAgent() *steerAgent {
+	return &steerAgent{prompts: make(chan string, 4), cancelled: make(chan struct{}, 4)}
+}
+
+func (s *steerAgent) Run(ctx context.Context, prompt string) error {
+	s.prompts <- prompt
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *steerAgent) Subscribe() <-chan Event { return make(chan Event) }
+
+func (s *steerAgent) Cancel() { s.cancelled <- struct{}{} }
+
+// nextPrompt waits for the agent to be run.
+func (s *steerAgent) nextPrompt(t *testing.T) string {
+	t.Helper()
+	select {
+	case p := <-s.prompts:
+		return p
+	case <-time.After(time.Second):
+		t.Fatal("expected the agent to be run")
+		return ""
+	}
+}
+
+func TestAppQueuesPromptsDuringRun(t *testing.T) {
+	agent := newSteerAgent()
+	app := New(agent)
+
+	app.submitPrompt("first")
+	agent.nextPrompt(t)
+	app.submitPrompt("second")
+	app.submitPrompt("third")
+
+	if got := app.shell.Queued(); len(got) != 2 {
+		t.Fatalf("expected two queued prompts, got %v", got)
+	}
+	select {
+	case p := <-agent.prompts:
+		t.Fatalf("expected queued prompts to wait, but %q was run", p)
+	default:
+	}
+
+	// When the turn completes, the queue goes out as one prompt
+	app.processEvent(Event{Type: EventComplete, RunID: 1})
+	if got := agent.nextPrompt(t); got != "second\n\nthird" {
+		t.Errorf("expected merged queued prompts, got %q", got)
+	}
+	if len(app.shell.Queued()) != 0 {
+		t.Error("expected queue cleared once sent")
+	}
+	app.cancelRun()
+}
+
+func TestAppInterrupt(t *testing.T) {
+	agent := newSteerAgent()
+	app := New(agent)
+
+	app.submitPrompt("write a poem")
+	agent.nextPrompt(t)
+	app.processEvent(Event{Type: EventText, RunID: 1, Text: "Roses"})
+
+	app.interrupt()
+
+	select {
+	case <-agent.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the agent to be cancelled")
+	}
+	if app.isRunning() || app.shell.Streaming().IsStreaming() {
+		t.Error("expected the turn to be over")
+	}
+	last := app.chat.messages[len(app.chat.messages)-1]
+	if last.content != "Roses\n\n[interrupted]" {
+		t.Errorf("expected partial answer marked interrupted, got %q", last.content)
+	}
+
+	// Late output of the interrupted run is dropped
+	app.processEvent(Event{Type: EventText, RunID: 1, Text: " are red"})
+	if app.chat.pendingText() != "" {
+		t.Errorf("expected late text dropped, got %q", app.chat.pendingText())
+	}
+}
+
+func TestAppInline(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Display.Inline = true
+	if app := New(&mockAgent{}, WithConfig(cfg)); !app.shell.Inline() {
+		t.Error("expected the config's inline setting applied")
+	}
+	if app := New(&mockAgent{}); app.shell.Inline() {
+		t.Error("expected the alternate screen by default")
+	}
+
+	app := New(&mockAgent{}, WithInline())
+	if !app.shell.Inline() {
+		t.Fatal("expected WithInline to draw inline")
+	}
+	var printed []string
+	app.chat.setPrinter(func(s string) { printed = append(printed, ansi.Strip(s)) })
+	app.chat.SetSize(80, 10)
+
+	// The answer so far is printed before a tool call, in order
+	app.processEvent(Event{Type: EventText, Text: "Reading it"})
+	app.processEvent(Event{Type: EventToolCall, ToolID: "t1", ToolName: "read_file", ToolParams: map[string]any{"path": "a.go"}})
+	if len(printed) != 1 || !strings.Contains(printed[0], "Reading it") {
+		t.Errorf("expected the answer printed at the tool call, got %q", printed)
+	}
+	app.processEvent(Event{Type: EventToolResult, ToolID: "t1", ToolOutput: "package a", Success: true})
+	line := ansi.Strip(app.tools.resultLine("t1"))
+	if !strings.HasPrefix(line, "✓ read_file path=a.go → package a") {
+		t.Errorf("unexpected result line %q", line)
+	}
+}
+
+func TestAppCommands(t *testing.T) {
+	var mode string
+	app := New(&mockAgent{}, WithCommand(Command{
+		Name: "review",
+		Args: []CommandArg{{Name: "mode", Choices: []string{"fast", "deep"}}},
+		Handler: func(args shell.Values) tea.Cmd {
+			mode = args.String("mode")
+			return nil
+		},
+	}))
+
+	app.shell.Update(shell.InputSubmitMsg{Value: "/review deep"})
+	if mode != "deep" {
+		t.Errorf("expected the command run with deep, got %q", mode)
+	}
+	if msgs := app.chat.UserMessages(); len(msgs) != 0 {
+		t.Errorf("expected nothing sent to the agent, got %q", msgs)
+	}
+
+	// The built-in /theme restyles the tabs
+	app.shell.Update(shell.InputSubmitMsg{Value: "/theme gruvbox"})
+	if app.chat.theme.Name() != "gruvbox" || app.tools.theme.Name() != "gruvbox" {
+		t.Errorf("expected the tabs in gruvbox, got %s and %s", app.chat.theme.Name(), app.tools.theme.Name())
+	}
+}
+
+func TestAppAutocompleteConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Autocomplete.DelayMs = 0
+	app := New(&mockAgent{}, WithConfig(cfg))
+	app.shell.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	// Commands complete as they are typed
+	_, cmd := app.shell.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	if cmd == nil {
+		t.Fatal("expected a debounced completion")
+	}
+	app.shell.Update(cmd())
+	if view := app.shell.View(); !strings.Contains(view, "/help") {
+		t.Errorf("expected the command list shown while typing, got:\n%s", view)
+	}
+}
@@ -0,0 +1,145 @@
+// Package tuxtest provides helpers for testing tux integrations.
+package tuxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/2389-research/tux"
+)
+
+// Timeout bounds how long AgentConformance waits for a run to finish or for
+// its channel to close.
+var Timeout = 5 * time.Second
+
+// AgentConformance checks that agents built by newAgent follow the run
+// contract documented on tux.Agent. Each subtest uses a fresh agent, and
+// every run is given a short prompt that the agent should be able to answer
+// without outside input.
+func AgentConformance(t *testing.T, newAgent func() tux.Agent) {
+	t.Helper()
+
+	t.Run("SubscribeReturnsChannel", func(t *testing.T) {
+		if newAgent().Subscribe() == nil {
+			t.Fatal("Subscribe returned a nil channel")
+		}
+	})
+
+	t.Run("RunClosesChannel", func(t *testing.T) {
+		a := newAgent()
+		events := drain(a.Subscribe())
+
+		err := run(t, a, tux.ContextWithRunID(context.Background(), 7))
+		got := wait(t, events, "channel to close after Run returned")
+
+		for _, ev := range got {
+			if ev.RunID != 0 && ev.RunID != 7 {
+				t.Errorf("event %v has run ID %d, want 0 or 7", ev.Type, ev.RunID)
+			}
+		}
+		if err != nil {
+			return
+		}
+		if len(got) == 0 || got[len(got)-1].Type != tux.EventComplete {
+			t.Errorf("run returned nil but did not end with EventComplete (got %d events)", len(got))
+		}
+	})
+
+	t.Run("ChannelPerRun", func(t *testing.T) {
+		a := newAgent()
+
+		first := a.Subscribe()
+		firstEvents := drain(first)
+		run(t, a, tux.ContextWithRunID(context.Background(), 1))
+		wait(t, firstEvents, "first run's channel to close")
+
+		second := a.Subscribe()
+		if second == first {
+			t.Fatal("Subscribe returned the same channel for two runs")
+		}
+		secondEvents := drain(second)
+		run(t, a, tux.ContextWithRunID(context.Background(), 2))
+		for _, ev := range wait(t, secondEvents, "second run's channel to close") {
+			if ev.RunID == 1 {
+				t.Errorf("second run delivered event %v from the first run", ev.Type)
+			}
+		}
+	})
+
+	t.Run("CancelStopsRun", func(t *testing.T) {
+		a := newAgent()
+		events := drain(a.Subscribe())
+
+		ctx, cancel := context.WithCancel(tux.ContextWithRunID(context.Background(), 3))
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			a.Run(ctx, "hello")
+		}()
+
+		cancel()
+		a.Cancel()
+
+		select {
+		case <-done:
+		case <-time.After(Timeout):
+			t.Fatalf("Run did not return within %v of being cancelled", Timeout)
+		}
+		wait(t, events, "channel to close after a cancelled run")
+	})
+}
+
+// run runs the agent with a short prompt, failing the test if it does not
+// return in time.
+func run(t *testing.T, a tux.Agent, ctx context.Context) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- a.Run(ctx, "hello") }()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(Timeout + time.Second):
+		t.Fatalf("Run did not return within %v", Timeout)
+		return nil
+	}
+}
+
+// drain reads a run's channel until it is closed, then delivers everything
+// it received.
+func drain(ch <-chan tux.Event) <-chan []tux.Event {
+	out := make(chan []tux.Event, 1)
+	go func() {
+		var events []tux.Event
+		for ev := range ch {
+			if ev.Type == tux.EventApproval && ev.Response != nil {
+				// Nobody is there to decide; let the run carry on
+				select {
+				case ev.Response <- tux.DecisionDeny:
+				default:
+				}
+			}
+			events = append(events, ev)
+		}
+		out <- events
+	}()
+	return out
+}
+
+// wait returns the events drained from a channel, failing the test if the
+// channel is not closed in time.
+func wait(t *testing.T, events <-chan []tux.Event, what string) []tux.Event {
+	t.Helper()
+	select {
+	case got := <-events:
+		return got
+	case <-time.After(Timeout):
+		t.Fatalf("timed out after %v waiting for %s", Timeout, what)
+		return nil
+	}
+}
@@ -0,0 +1,333 @@
+// usage.go
+package tux
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/2389-research/tux/agent"
+	"github.com/2389-research/tux/config"
+	"github.com/2389-research/tux/session"
+	"github.com/2389-research/tux/shell"
+	"github.com/2389-research/tux/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TokenUsage is a re-export of agent.TokenUsage for API convenience.
+// CacheHits counts input tokens read from cache; they are part of
+// InputTokens.
+type TokenUsage = agent.TokenUsage
+
+// ModelInfo is a re-export of config.ModelConfig for API convenience: a
+// model's context window and prices.
+type ModelInfo = config.ModelConfig
+
+// TurnUsage is the token usage and cost of one turn.
+type TurnUsage struct {
+	Prompt string
+	Model  string
+	Usage  TokenUsage
+	Cost   float64 // USD; 0 if the model has no prices
+}
+
+// WithModel sets the model shown in the status bar. Its context window and
+// prices, from WithModelInfo or the [models] config table, drive the
+// context gauge and the session cost.
+func WithModel(name string) Option {
+	return func(c *appConfig) {
+		c.model = name
+	}
+}
+
+// WithModelInfo sets the context window and prices of a model, replacing
+// any set by earlier options.
+func WithModelInfo(name string, info ModelInfo) Option {
+	return func(c *appConfig) {
+		if c.models == nil {
+			c.models = make(map[string]config.ModelConfig)
+		}
+		c.models[name] = info
+	}
+}
+
+// usageTracker accumulates token usage over a session. It is guarded by
+// App.mu.
+type usageTracker struct {
+	model    string
+	turns    []TurnUsage
+	current  *TurnUsage // Running turn; nil between turns
+	reported bool       // Whether the running turn sent EventUsage
+	context  int        // Tokens in the context window at the last report
+}
+
+// Usage returns the usage of each finished turn in the session, oldest
+// first.
+func (a *App) Usage() []TurnUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]TurnUsage(nil), a.usage.turns...)
+}
+
+// TotalUsage returns the token usage and cost of the session so far,
+// including the running turn.
+func (a *App) TotalUsage() (TokenUsage, float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.totalUsageLocked()
+}
+
+// totalUsageLocked is TotalUsage with a.mu held.
+func (a *App) totalUsageLocked() (TokenUsage, float64) {
+	var total TokenUsage
+	var cost float64
+	for _, t := range a.usage.turns {
+		total = addUsage(total, t.Usage)
+		cost += t.Cost
+	}
+	if t := a.usage.current; t != nil {
+		total = addUsage(total, t.Usage)
+		cost += a.costOf(t.Model, t.Usage)
+	}
+	return total, cost
+}
+
+// costOf prices usage with the model's prices, or 0 if it has none.
+func (a *App) costOf(model string, u TokenUsage) float64 {
+	m, ok := a.config.models[model]
+	if !ok {
+		return 0
+	}
+	return m.Cost(u.InputTokens, u.OutputTokens, u.CacheHits)
+}
+
+// startTurnUsage starts counting usage for a new turn.
+func (a *App) startTurnUsage(prompt string) {
+	a.mu.Lock()
+	a.usage.current = &TurnUsage{Prompt: prompt, Model: a.usage.model}
+	a.usage.reported = false
+	a.mu.Unlock()
+}
+
+// runningTurn returns the running turn, starting one for usage that
+// arrives outside a turn. Must be called with a.mu held.
+func (a *App) runningTurn() *TurnUsage {
+	if a.usage.current == nil {
+		a.usage.current = &TurnUsage{Model: a.usage.model}
+	}
+	return a.usage.current
+}
+
+// addUsage adds the usage of one model request (EventUsage) to the running
+// turn.
+func (a *App) addUsage(u TokenUsage) {
+	a.mu.Lock()
+	t := a.runningTurn()
+	t.Usage = addUsage(t.Usage, u)
+	a.usage.reported = true
+	a.usage.context = contextTokens(u)
+	a.mu.Unlock()
+	a.refreshUsageStatus()
+}
+
+// completeUsage sets the running turn's usage to the total reported with
+// EventComplete.
+func (a *App) completeUsage(u TokenUsage) {
+	a.mu.Lock()
+	a.runningTurn().Usage = u
+	if !a.usage.reported {
+		a.usage.context = contextTokens(u)
+	}
+	a.mu.Unlock()
+	a.refreshUsageStatus()
+}
+
+// finishTurnUsage prices and records the running turn, if it used any
+// tokens.
+func (a *App) finishTurnUsage() {
+	a.mu.Lock()
+	t := a.usage.current
+	a.usage.current = nil
+	if t == nil || t.Usage == (TokenUsage{}) {
+		a.mu.Unlock()
+		return
+	}
+	t.Cost = a.costOf(t.Model, t.Usage)
+	a.usage.turns = append(a.usage.turns, *t)
+	a.mu.Unlock()
+
+	a.record(session.Record{
+		Type:  session.RecordUsage,
+		Model: t.Model,
+		Usage: &session.Usage{
+			InputTokens:  t.Usage.InputTokens,
+			OutputTokens: t.Usage.OutputTokens,
+			CacheHits:    t.Usage.CacheHits,
+			Cost:         t.Cost,
+		},
+	})
+	a.refreshUsageStatus()
+}
+
+// restoreUsage adds a turn's usage from a session transcript.
+func (a *App) restoreUsage(rec session.Record, prompt string) {
+	if rec.Usage == nil {
+		return
+	}
+	u := TokenUsage{
+		InputTokens:  rec.Usage.InputTokens,
+		OutputTokens: rec.Usage.OutputTokens,
+		TotalTokens:  rec.Usage.InputTokens + rec.Usage.OutputTokens,
+		CacheHits:    rec.Usage.CacheHits,
+	}
+	a.mu.Lock()
+	a.usage.turns = append(a.usage.turns, TurnUsage{Prompt: prompt, Model: rec.Model, Usage: u, Cost: rec.Usage.Cost})
+	a.usage.context = contextTokens(u)
+	a.mu.Unlock()
+}
+
+// refreshUsageStatus shows the context window use and session cost in the
+// status bar.
+func (a *App) refreshUsageStatus() {
+	a.mu.Lock()
+	used := a.usage.context
+	window := a.config.models[a.usage.model].ContextWindow
+	_, cost := a.totalUsageLocked()
+	a.mu.Unlock()
+
+	a.UpdateStatus(func(st *Status) {
+		st.TokensUsed = used
+		st.TokensMax = window
+		st.Cost = cost
+	})
+}
+
+// showUsage opens the per-turn usage breakdown.
+func (a *App) showUsage() {
+	a.shell.PushModal(a.newUsageModal())
+}
+
+// newUsageModal returns a usage breakdown of the session so far.
+func (a *App) newUsageModal() *usageModal {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	m := &usageModal{
+		turns:   append([]TurnUsage(nil), a.usage.turns...),
+		context: a.usage.context,
+		window:  a.config.models[a.usage.model].ContextWindow,
+		theme:   a.shell.Theme(),
+	}
+	m.total, m.cost = a.totalUsageLocked()
+	return m
+}
+
+// addUsage returns the sum of two usages.
+func addUsage(a, b TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:  a.InputTokens + b.InputTokens,
+		OutputTokens: a.OutputTokens + b.OutputTokens,
+		TotalTokens:  a.TotalTokens + b.TotalTokens,
+		CacheHits:    a.CacheHits + b.CacheHits,
+	}
+}
+
+// contextTokens estimates the tokens in the context window after a request:
+// its input plus the reply.
+func contextTokens(u TokenUsage) int {
+	if n := u.InputTokens + u.OutputTokens; n > 0 {
+		return n
+	}
+	return u.TotalTokens
+}
+
+// usageModal shows token usage and cost per turn.
+type usageModal struct {
+	turns   []TurnUsage
+	total   TokenUsage
+	cost    float64
+	context int
+	window  int
+	theme   theme.Theme
+}
+
+// ID implements shell.Modal.
+func (m *usageModal) ID() string { return "usage-modal" }
+
+// Title implements shell.Modal.
+func (m *usageModal) Title() string { return "Usage" }
+
+// Size implements shell.Modal.
+func (m *usageModal) Size() shell.Size { return shell.SizeLarge }
+
+// OnPush implements shell.Modal.
+func (m *usageModal) OnPush(width, height int) {}
+
+// OnPop implements shell.Modal.
+func (m *usageModal) OnPop() {}
+
+// HandleKey implements shell.Modal.
+func (m *usageModal) HandleKey(key tea.KeyMsg) (bool, tea.Cmd) {
+	switch key.String() {
+	case "esc", "enter", "q", "ctrl+g":
+		return true, func() tea.Msg { return shell.PopMsg{} }
+	}
+	return true, nil
+}
+
+// Render implements shell.Modal.
+func (m *usageModal) Render(width, height int) string {
+	styles := m.theme.Styles()
+	inner := max(width-4-styles.ModalBox.GetHorizontalFrameSize(), 20)
+
+	// Prompt column takes what the numbers leave
+	const numbers = 4 + 3*9 + 10
+	promptWidth := max(inner-numbers, 8)
+	row := func(n, prompt, in, out, cached, cost string) string {
+		prompt = ansi.Truncate(prompt, promptWidth, "…")
+		return fmt.Sprintf("%-4s%-*s%9s%9s%9s%10s", n, promptWidth, prompt, in, out, cached, cost)
+	}
+	price := func(turn TurnUsage) string {
+		if turn.Cost == 0 {
+			return "-"
+		}
+		return shell.FormatCost(turn.Cost)
+	}
+
+	lines := []string{
+		styles.ModalTitle.Render("Token usage"),
+		"",
+		styles.Muted.Render(row("#", "Prompt", "In", "Out", "Cached", "Cost")),
+	}
+
+	// Latest turns when they do not all fit
+	turns := m.turns
+	if avail := height - 12; avail > 0 && len(turns) > avail {
+		turns = turns[len(turns)-avail:]
+	}
+	first := len(m.turns) - len(turns) + 1
+	for i, t := range turns {
+		prompt, _, _ := strings.Cut(strings.TrimSpace(t.Prompt), "\n")
+		lines = append(lines, row(fmt.Sprint(first+i), prompt,
+			shell.FormatTokens(t.Usage.InputTokens), shell.FormatTokens(t.Usage.OutputTokens),
+			shell.FormatTokens(t.Usage.CacheHits), price(t)))
+	}
+	if len(m.turns) == 0 {
+		lines = append(lines, styles.Muted.Render("No usage reported yet"))
+	}
+
+	total := TurnUsage{Usage: m.total, Cost: m.cost}
+	lines = append(lines, styles.Emphasized.Render(row("", "Total",
+		shell.FormatTokens(m.total.InputTokens), shell.FormatTokens(m.total.OutputTokens),
+		shell.FormatTokens(m.total.CacheHits), price(total))))
+
+	lines = append(lines, "")
+	if m.window > 0 {
+		lines = append(lines, fmt.Sprintf("Context: %s of %s (%d%%)",
+			shell.FormatTokens(m.context), shell.FormatTokens(m.window), m.context*100/m.window))
+	} else if m.context > 0 {
+		lines = append(lines, "Context: "+shell.FormatTokens(m.context)+" tokens")
+	}
+	lines = append(lines, styles.ModalFooter.Render("Press Esc to close"))
+
+	return styles.ModalBox.Width(width - 4).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,111 @@
+package tux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/tux/session"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func newUsageApp(opts ...Option) *App {
+	opts = append([]Option{
+		WithModel("sonnet"),
+		WithModelInfo("sonnet", ModelInfo{ContextWindow: 1000, InputPrice: 3, OutputPrice: 15}),
+	}, opts...)
+	return New(&mockAgent{}, opts...)
+}
+
+func TestAppTracksUsage(t *testing.T) {
+	app := newUsageApp()
+
+	app.startTurnUsage("hello")
+	app.processEvent(Event{Type: EventUsage, Usage: &TokenUsage{InputTokens: 400, OutputTokens: 100}})
+	if app.status.TokensUsed != 500 || app.status.TokensMax != 1000 {
+		t.Errorf("expected 500 of 1000 tokens in context, got %d of %d", app.status.TokensUsed, app.status.TokensMax)
+	}
+
+	app.processEvent(Event{Type: EventUsage, Usage: &TokenUsage{InputTokens: 600, OutputTokens: 50}})
+	if app.status.TokensUsed != 650 {
+		t.Errorf("expected context from the latest request, got %d", app.status.TokensUsed)
+	}
+
+	app.processEvent(Event{Type: EventComplete, Usage: &TokenUsage{InputTokens: 1000, OutputTokens: 150}})
+	turns := app.Usage()
+	if len(turns) != 1 || turns[0].Prompt != "hello" || turns[0].Model != "sonnet" {
+		t.Fatalf("expected one turn for the prompt, got %+v", turns)
+	}
+	if turns[0].Usage.InputTokens != 1000 || turns[0].Usage.OutputTokens != 150 {
+		t.Errorf("expected the completed total to win, got %+v", turns[0].Usage)
+	}
+
+	wantCost := (1000*3 + 150*15) / 1e6
+	if _, cost := app.TotalUsage(); cost != wantCost || app.status.Cost != wantCost {
+		t.Errorf("expected cost %v, got %v (status %v)", wantCost, cost, app.status.Cost)
+	}
+	if app.status.TokensUsed != 650 {
+		t.Errorf("expected completion not to reset reported context, got %d", app.status.TokensUsed)
+	}
+}
+
+func TestAppUsageWithoutPrices(t *testing.T) {
+	app := New(&mockAgent{}, WithModel("unknown"))
+
+	app.startTurnUsage("hi")
+	app.processEvent(Event{Type: EventComplete, Usage: &TokenUsage{InputTokens: 20, OutputTokens: 5}})
+
+	if app.status.TokensUsed != 25 || app.status.TokensMax != 0 || app.status.Cost != 0 {
+		t.Errorf("expected tokens without a window or cost, got %+v", app.status)
+	}
+}
+
+func TestSessionKeepsUsage(t *testing.T) {
+	store := session.NewFileStore(t.TempDir())
+	app := newUsageApp(WithSessionStore(store), WithSession("s1"))
+
+	app.submitInput("hello")
+	app.processEvent(Event{Type: EventText, Text: "hi"})
+	app.processEvent(Event{Type: EventComplete, Usage: &TokenUsage{InputTokens: 300, OutputTokens: 20}})
+
+	app.flushWrites()
+	recs, _ := store.Load("s1")
+	var usage *session.Record
+	for i := range recs {
+		if recs[i].Type == session.RecordUsage {
+			usage = &recs[i]
+		}
+	}
+	if usage == nil || usage.Model != "sonnet" || usage.Usage.InputTokens != 300 || usage.Usage.Cost == 0 {
+		t.Fatalf("expected priced usage recorded, got %+v", recs)
+	}
+
+	resumed := newUsageApp(WithSessionStore(store), WithSession("s1"))
+	turns := resumed.Usage()
+	if len(turns) != 1 || turns[0].Prompt != "hello" || turns[0].Cost != usage.Usage.Cost {
+		t.Errorf("expected usage restored, got %+v", turns)
+	}
+	if resumed.status.TokensUsed != 320 || resumed.status.Cost != usage.Usage.Cost {
+		t.Errorf("expected restored status, got %+v", resumed.status)
+	}
+}
+
+func TestUsageModal(t *testing.T) {
+	app := newUsageApp()
+	app.startTurnUsage("explain the build\nin detail")
+	app.processEvent(Event{Type: EventComplete, Usage: &TokenUsage{InputTokens: 400, OutputTokens: 100}})
+
+	app.showUsage()
+	if !app.shell.HasModal() {
+		t.Fatal("expected usage modal")
+	}
+
+	view := ansi.Strip(app.newUsageModal().Render(100, 30))
+	for _, want := range []string{"explain the build", "400", "Total", "$0.003", "Context: 500 of 1k (50%)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in usage modal:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "in detail") {
+		t.Errorf("expected only the prompt's first line:\n%s", view)
+	}
+}
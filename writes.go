@@ -0,0 +1,47 @@
+// writes.go
+package tux
+
+import "sync"
+
+// writeQueue runs the App's disk writes off the update loop, one at a time
+// in the order they were queued, so writes to the same file never
+// interleave.
+type writeQueue struct {
+	mu      sync.Mutex
+	pending []func()
+	running bool
+}
+
+// enqueue queues a write, starting a writer goroutine if none is running.
+func (w *writeQueue) enqueue(write func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, write)
+	if !w.running {
+		w.running = true
+		go w.drain()
+	}
+}
+
+// drain runs queued writes until none are left.
+func (w *writeQueue) drain() {
+	for {
+		w.mu.Lock()
+		if len(w.pending) == 0 {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		write := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+		write()
+	}
+}
+
+// flushWrites waits until the writes queued so far are done.
+func (a *App) flushWrites() {
+	done := make(chan struct{})
+	a.writes.enqueue(func() { close(done) })
+	<-done
+}